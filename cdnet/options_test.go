@@ -325,6 +325,26 @@ func TestGetArgsThirdPartyLinters(t *testing.T) {
 				"--clang-args", "-I/usr/include",
 			},
 		},
+		{
+			name: "(clang) compile database generation",
+			options: &platform.QodanaOptions{
+				ClangCompileDbCommand: "cmake -B build -DCMAKE_EXPORT_COMPILE_COMMANDS=ON",
+				Linter:                platform.DockerImageMap[platform.QDCL],
+			},
+			expected: []string{
+				"--clang-db-generate", "cmake -B build -DCMAKE_EXPORT_COMPILE_COMMANDS=ON",
+			},
+		},
+		{
+			name: "(dotnet) target frameworks",
+			options: &platform.QodanaOptions{
+				CdnetTargetFrameworks: []string{"net8.0", "!net48"},
+				Linter:                platform.DockerImageMap[platform.QDNET],
+			},
+			expected: []string{
+				"--framework", "net8.0", "--framework", "!net48",
+			},
+		},
 		{
 			name: "using flag in non 3rd party linter",
 			options: &platform.QodanaOptions{
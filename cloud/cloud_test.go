@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"errors"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"testing"
@@ -101,3 +102,46 @@ func TestGetReportUrl(t *testing.T) {
 		})
 	}
 }
+
+func TestDownloadLatestSarif(t *testing.T) {
+	t.Setenv(QodanaCloudRequestRetriesEnv, "1")
+	var server *httptest.Server
+	reports := `{"items":[{"reportId":"r1"}]}`
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/storage/qodana.sarif.json" && r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/projects":
+			_, _ = w.Write([]byte(`{"id":"p1","name":"project"}`))
+		case "/projects/p1/timeline":
+			_, _ = w.Write([]byte(reports))
+		case "/reports/r1/files":
+			_, _ = w.Write([]byte(`{"files":[{"file":"qodana.sarif.json","url":"` + server.URL + `/storage/qodana.sarif.json"}]}`))
+		case "/storage/qodana.sarif.json":
+			_, _ = w.Write([]byte(`{"runs":[]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := (&QdApiEndpoints{CloudApiUrl: server.URL}).NewCloudApiClient("token")
+	path := filepath.Join(t.TempDir(), "baseline.sarif.json")
+	if err := client.DownloadLatestSarif(path); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"runs":[]}` {
+		t.Errorf("Expected the downloaded report, got %s", data)
+	}
+
+	reports = `{"items":[]}`
+	if err := client.DownloadLatestSarif(path); !errors.Is(err, ErrNoReports) {
+		t.Errorf("Expected ErrNoReports, got %v", err)
+	}
+}
@@ -62,7 +62,19 @@ const (
 	CommunityLicensePlan   = "COMMUNITY"
 )
 
-var TokenDeclinedError = errors.New("token was declined by Qodana Cloud server")
+var (
+	// ErrTokenMissing is returned when a license is required, but no Qodana Cloud token is provided.
+	ErrTokenMissing = errors.New("Qodana Cloud token is not provided")
+	// ErrTokenInvalid is returned when Qodana Cloud declines the provided token.
+	ErrTokenInvalid = errors.New("token was declined by Qodana Cloud server")
+	// ErrLicenseExpired is returned when the organization of the token has no active license.
+	ErrLicenseExpired = errors.New("Qodana Cloud license has expired")
+	// ErrCloudUnreachable is returned when Qodana Cloud gives no proper response after all attempts.
+	ErrCloudUnreachable = errors.New("failed to get proper response from Qodana Cloud server")
+)
+
+// TokenDeclinedError is kept for compatibility, use ErrTokenInvalid instead.
+var TokenDeclinedError = ErrTokenInvalid
 
 var EmptyTokenMessage = `Starting from version 2023.2 release versions of Qodana Linters require connection to Qodana Cloud. 
 To continue using Qodana, please ensure you have an access token and provide the token as the QODANA_TOKEN environment variable.
@@ -100,12 +112,21 @@ func (o *LicenseToken) IsAllowedToSendFUS() bool {
 }
 
 func DeserializeLicenseData(data []byte) LicenseData {
+	ld, err := ParseLicenseData(data)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return ld
+}
+
+// ParseLicenseData parses the license response of Qodana Cloud.
+func ParseLicenseData(data []byte) (LicenseData, error) {
 	var ld LicenseData
 	err := json.Unmarshal(data, &ld)
 	if err != nil {
-		log.Fatalf("License deserialization failed. License response data:\n%s\nError: '%v'", string(data), err)
+		return ld, fmt.Errorf("License deserialization failed. License response data:\n%s\nError: '%w'", string(data), err)
 	}
-	return ld
+	return ld, nil
 }
 
 func (endpoints *QdApiEndpoints) RequestLicenseData(token string) ([]byte, error) {
@@ -113,7 +134,7 @@ func (endpoints *QdApiEndpoints) RequestLicenseData(token string) ([]byte, error
 	cooldown := getCooldown()
 	for i := 1; i <= attempts; i++ {
 		license, err := requestLicenseDataAttempt(endpoints.LintersApiUrl, token)
-		if errors.Is(err, TokenDeclinedError) {
+		if errors.Is(err, ErrTokenInvalid) || errors.Is(err, ErrLicenseExpired) {
 			return nil, err
 		}
 		if err != nil {
@@ -131,7 +152,7 @@ func (endpoints *QdApiEndpoints) RequestLicenseData(token string) ([]byte, error
 			return license, nil
 		}
 	}
-	return nil, errors.New("failed to get proper response from Qodana Cloud server")
+	return nil, ErrCloudUnreachable
 }
 
 func requestLicenseDataAttempt(endpoint string, token string) ([]byte, error) {
@@ -161,7 +182,10 @@ func requestLicenseDataAttempt(endpoint string, token string) ([]byte, error) {
 		return nil, fmt.Errorf("Reading license response failed\n. %w", err)
 	}
 	if resp.StatusCode == 401 || resp.StatusCode == 404 {
-		return nil, TokenDeclinedError
+		return nil, ErrTokenInvalid
+	}
+	if resp.StatusCode == 402 {
+		return nil, ErrLicenseExpired
 	}
 	if resp.StatusCode == 200 {
 		return bodyText, nil
@@ -221,15 +245,26 @@ func SetupLicenseToken(token string) {
 }
 
 func (endpoints *QdApiEndpoints) GetLicenseData(token string) LicenseData {
+	licenseData, err := endpoints.RequestLicense(token)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return licenseData
+}
+
+// RequestLicense obtains the license data for the given token.
+// The returned error wraps one of ErrTokenInvalid, ErrLicenseExpired or ErrCloudUnreachable
+// when Qodana Cloud fails to provide the license.
+func (endpoints *QdApiEndpoints) RequestLicense(token string) (LicenseData, error) {
 	licenseDataResponse, err := endpoints.RequestLicenseData(token)
-	if errors.Is(err, TokenDeclinedError) {
-		log.Fatalf("License request: %v\n%s", err, DeclinedTokenErrorMessage)
+	if errors.Is(err, ErrTokenInvalid) || errors.Is(err, ErrLicenseExpired) {
+		return LicenseData{}, fmt.Errorf("License request: %w\n%s", err, DeclinedTokenErrorMessage)
 	}
 	if err != nil {
 		errMessage := fmt.Sprintf(GeneralLicenseErrorMessage, endpoints.RootEndpoint.GetCloudUrl())
-		log.Fatalf("License request: %v\n%s", err, errMessage)
+		return LicenseData{}, fmt.Errorf("License request: %w\n%s", err, errMessage)
 	}
-	return DeserializeLicenseData(licenseDataResponse)
+	return ParseLicenseData(licenseDataResponse)
 }
 
 func (endpoints *QdApiEndpoints) GetLicensePlan(token string) string {
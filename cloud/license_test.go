@@ -17,6 +17,7 @@
 package cloud
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -223,6 +224,67 @@ func TestRequestLicenseData(t *testing.T) {
 	}
 }
 
+func TestRequestLicenseErrors(t *testing.T) {
+	t.Setenv(QodanaLicenseRequestCooldownEnv, "0")
+	t.Setenv(QodanaLicenseRequestAttemptsCountEnv, "2")
+	for _, testData := range []struct {
+		name     string
+		status   int
+		body     string
+		expected error
+	}{
+		{
+			name:     "unauthorized",
+			status:   http.StatusUnauthorized,
+			expected: ErrTokenInvalid,
+		},
+		{
+			name:     "not found",
+			status:   http.StatusNotFound,
+			expected: ErrTokenInvalid,
+		},
+		{
+			name:     "payment required",
+			status:   http.StatusPaymentRequired,
+			expected: ErrLicenseExpired,
+		},
+		{
+			name:     "server errors",
+			status:   http.StatusInternalServerError,
+			expected: ErrCloudUnreachable,
+		},
+		{
+			name:     "success",
+			status:   http.StatusOK,
+			body:     `{"licenseKey":"key"}`,
+			expected: nil,
+		},
+	} {
+		t.Run(testData.name, func(t *testing.T) {
+			svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(testData.status)
+				_, _ = fmt.Fprint(w, testData.body)
+			}))
+			defer svr.Close()
+
+			apis := QdApiEndpoints{LintersApiUrl: svr.URL, RootEndpoint: &QdRootEndpoint{Host: "qodana.cloud"}}
+			data, err := apis.RequestLicense("token")
+			if testData.expected == nil {
+				if err != nil {
+					t.Fatalf("expected no error, got '%v'", err)
+				}
+				if data.LicenseKey != "key" {
+					t.Errorf("expected license key to be 'key' got '%s'", data.LicenseKey)
+				}
+				return
+			}
+			if !errors.Is(err, testData.expected) {
+				t.Errorf("expected error to wrap '%v' got '%v'", testData.expected, err)
+			}
+		})
+	}
+}
+
 func TestExtractLicenseKey(t *testing.T) {
 	for _, testData := range []struct {
 		name        string
@@ -18,7 +18,12 @@ package cloud
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 )
@@ -58,3 +63,97 @@ func readOpenInIde(resultsDir, fileName string) (string, error) {
 	log.Debugf("Found report URL from (%s): %s", filePath, data.Cloud.URL)
 	return data.Cloud.URL, nil
 }
+
+// ErrNoReports is returned when the project has no reports on Qodana Cloud yet.
+var ErrNoReports = errors.New("the project has no reports on Qodana Cloud")
+
+const sarifReportFile = "qodana.sarif.json"
+
+type project struct {
+	Id string `json:"id"`
+}
+
+type projectTimeline struct {
+	Items []struct {
+		ReportId string `json:"reportId"`
+	} `json:"items"`
+}
+
+type reportFiles struct {
+	Files []struct {
+		File string `json:"file"`
+		Url  string `json:"url"`
+	} `json:"files"`
+}
+
+// DownloadLatestSarif downloads the SARIF report of the latest analysis of the token project to path.
+func (client *QdClient) DownloadLatestSarif(path string) error {
+	reportId, err := client.requestLatestReportId()
+	if err != nil {
+		return err
+	}
+	request := NewCloudRequest(fmt.Sprintf("/reports/%s/files?paths=%s", url.PathEscape(reportId), url.QueryEscape(sarifReportFile)))
+	data, err := client.doRequest(&request)
+	if err != nil {
+		return err
+	}
+	var files reportFiles
+	if err := json.Unmarshal(data, &files); err != nil {
+		return fmt.Errorf("response '%s': %w", string(data), err)
+	}
+	for _, f := range files.Files {
+		if f.File == sarifReportFile && f.Url != "" {
+			return client.download(f.Url, path)
+		}
+	}
+	return fmt.Errorf("report %s has no %s", reportId, sarifReportFile)
+}
+
+func (client *QdClient) requestLatestReportId() (string, error) {
+	request := NewCloudRequest("/projects")
+	data, err := client.doRequest(&request)
+	if err != nil {
+		return "", err
+	}
+	var p project
+	if err := json.Unmarshal(data, &p); err != nil || p.Id == "" {
+		return "", fmt.Errorf("response '%s': no project id", string(data))
+	}
+
+	request = NewCloudRequest(fmt.Sprintf("/projects/%s/timeline?limit=1", url.PathEscape(p.Id)))
+	data, err = client.doRequest(&request)
+	if err != nil {
+		return "", err
+	}
+	var timeline projectTimeline
+	if err := json.Unmarshal(data, &timeline); err != nil {
+		return "", fmt.Errorf("response '%s': %w", string(data), err)
+	}
+	if len(timeline.Items) == 0 || timeline.Items[0].ReportId == "" {
+		return "", ErrNoReports
+	}
+	return timeline.Items[0].ReportId, nil
+}
+
+// download saves the file at the pre-signed fileUrl to path.
+func (client *QdClient) download(fileUrl string, path string) error {
+	resp, err := client.httpClient.Get(fileUrl)
+	if err != nil {
+		return err
+	}
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: %s", sarifReportFile, resp.Status)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(f, resp.Body); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
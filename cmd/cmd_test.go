@@ -19,6 +19,7 @@ package cmd
 // Provides simple CLI tests for all supported platforms.
 
 import (
+	"archive/zip"
 	"bytes"
 	"encoding/json"
 	"fmt"
@@ -132,6 +133,46 @@ func TestDeprecatedScanFlags(t *testing.T) {
 	}
 }
 
+func TestListProfilesCommand(t *testing.T) {
+	projectPath := createProject(t, "list_profiles")
+	defer func() {
+		_ = os.RemoveAll(projectPath)
+	}()
+	home := t.TempDir()
+	libDir := filepath.Join(home, "plugins", "qodana", "lib")
+	if err := os.MkdirAll(libDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	jar, err := os.Create(filepath.Join(libDir, "qodana.jar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := zip.NewWriter(jar)
+	if _, err = writer.Create("profiles/qodana.sanity.yaml"); err != nil {
+		t.Fatal(err)
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = jar.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	out := bytes.NewBufferString("")
+	command := newScanCommand()
+	command.SetOut(out)
+	command.SetArgs([]string{"-i", projectPath, "--ide", home, "--list-profiles"})
+	if err = command.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	profiles := strings.Split(strings.TrimSpace(out.String()), "\n")
+	for _, expected := range []string{"qodana.starter", "qodana.recommended", "qodana.sanity"} {
+		if !platform.Contains(profiles, expected) {
+			t.Errorf("expected %s in the listed profiles, got %v", expected, profiles)
+		}
+	}
+}
+
 func TestInitCommand(t *testing.T) {
 	projectPath := createProject(t, "qodana_init")
 	err := os.WriteFile(projectPath+"/qodana.yml", []byte("version: 1.0"), 0o755)
@@ -165,6 +206,21 @@ func TestInitCommand(t *testing.T) {
 	}
 }
 
+func TestInitCommandWithLinter(t *testing.T) {
+	projectPath := createProject(t, "qodana_init_linter")
+	defer func() { _ = os.RemoveAll(projectPath) }()
+	command := newInitCommand()
+	command.SetArgs([]string{"-i", projectPath, "--linter", "qodana-jvm-community"})
+	if err := command.Execute(); err != nil {
+		t.Fatal(err)
+	}
+
+	qodanaYaml := platform.LoadQodanaYaml(projectPath, platform.FindQodanaYaml(projectPath))
+	if qodanaYaml.Linter != platform.Image(platform.QDJVMC) {
+		t.Fatalf("expected \"%s\", but got %s", platform.Image(platform.QDJVMC), qodanaYaml.Linter)
+	}
+}
+
 func TestExclusiveFixesCommand(t *testing.T) {
 	if os.Getenv("GITHUB_ACTIONS") == "true" {
 		//goland:noinspection GoBoolExpressions
@@ -206,6 +262,58 @@ func TestContributorsCommand(t *testing.T) {
 	}
 }
 
+func TestDiffCommand(t *testing.T) {
+	dir := t.TempDir()
+	from := filepath.Join(dir, "from.sarif.json")
+	to := filepath.Join(dir, "to.sarif.json")
+	err := os.WriteFile(from, []byte(`{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "QDPY"}}, "results": [
+{"ruleId": "Fixed", "message": {"text": "fixed"}, "partialFingerprints": {"equalIndicator/v1": "1"}},
+{"ruleId": "Kept", "message": {"text": "kept"}, "locations": [{"physicalLocation": {"artifactLocation": {"uri": "hello.py"}, "region": {"startLine": 1}}}]}
+]}]}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(to, []byte(`{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "QDPY"}}, "results": [
+{"ruleId": "Kept", "message": {"text": "kept"}, "locations": [{"physicalLocation": {"artifactLocation": {"uri": "hello.py"}, "region": {"startLine": 1}}}]},
+{"ruleId": "New", "message": {"text": "new"}, "partialFingerprints": {"equalIndicator/v1": "2"}}
+]}]}`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	out := bytes.NewBufferString("")
+	command := newDiffCommand()
+	command.SetOut(out)
+	command.SetArgs([]string{"--from", from, "--to", to, "-o", "json"})
+	err = command.Execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	output, err := io.ReadAll(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var diff struct {
+		Added []struct {
+			RuleId string `json:"ruleId"`
+		} `json:"added"`
+		Total map[string]int `json:"total"`
+	}
+	err = json.Unmarshal(output, &diff)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]int{"added": 1, "removed": 1, "unchanged": 1}
+	for key, value := range expected {
+		if diff.Total[key] != value {
+			t.Errorf("expected %d %s problems, got %d", value, key, diff.Total[key])
+		}
+	}
+	if len(diff.Added) != 1 || diff.Added[0].RuleId != "New" {
+		t.Errorf("expected the added problem to be New, got %v", diff.Added)
+	}
+}
+
 func TestPullInNative(t *testing.T) {
 	projectPath := createProject(t, "qodana_scan_python_native")
 	yamlFile := filepath.Join(projectPath, "qodana.yaml")
@@ -220,6 +328,20 @@ func TestPullInNative(t *testing.T) {
 	}
 }
 
+func TestPullFilterImages(t *testing.T) {
+	images := []string{"jetbrains/qodana-jvm:2024.3", "jetbrains/qodana-python:2024.3", "jetbrains/qodana-jvm-community:2024.3"}
+	if filtered := filterImages(images, nil); len(filtered) != 3 {
+		t.Errorf("expected all images without filters, got %v", filtered)
+	}
+	filtered := filterImages(images, []string{"jvm"})
+	if strings.Join(filtered, ",") != "jetbrains/qodana-jvm:2024.3,jetbrains/qodana-jvm-community:2024.3" {
+		t.Errorf("unexpected images for the jvm filter: %v", filtered)
+	}
+	if filtered := filterImages(images, []string{"go"}); len(filtered) != 0 {
+		t.Errorf("expected no images for the go filter, got %v", filtered)
+	}
+}
+
 func TestAllCommandsWithContainer(t *testing.T) {
 	platform.Version = "0.1.0"
 	linter := "registry.jetbrains.team/p/sa/containers/qodana-dotnet:latest"
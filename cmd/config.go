@@ -0,0 +1,79 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/JetBrains/qodana-cli/v2024/platform"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// newConfigCommand returns a new instance of the config command.
+func newConfigCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "config",
+		Short: "Inspect Qodana configuration",
+	}
+	cmd.AddCommand(newConfigShowCommand())
+	return cmd
+}
+
+// newConfigShowCommand returns a new instance of the config show command.
+func newConfigShowCommand() *cobra.Command {
+	options := &platform.QodanaOptions{}
+	format := "yaml"
+	cmd := &cobra.Command{
+		Use:   "show",
+		Short: "Print the effective configuration",
+		Long: `Print the configuration the analysis would run with: qodana.yaml merged with the command-line options.
+
+Accepts the same options as the scan command, so it can be used to check why an option doesn't take effect.
+The analysis is not started.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			config, err := options.EffectiveConfig()
+			if err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
+			var out []byte
+			switch format {
+			case "yaml":
+				out, err = yaml.Marshal(config)
+			case "json":
+				out, err = json.MarshalIndent(config, "", "  ")
+				out = append(out, '\n')
+			default:
+				err = fmt.Errorf("unknown format %q, expected one of: yaml, json", format)
+			}
+			if err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
+			fmt.Print(string(out))
+		},
+	}
+	if err := platform.ComputeFlags(cmd, options); err != nil {
+		log.Fatal("Error while computing flags")
+	}
+	cmd.Flags().StringVar(&format, "format", format, "Output format: yaml or json")
+	return cmd
+}
@@ -28,6 +28,9 @@ import (
 type contributorsOptions struct {
 	ProjectDirs []string
 	Days        int
+	Since       string
+	Until       string
+	ExcludeBots bool
 	Output      string
 }
 
@@ -46,13 +49,20 @@ A command-line helper for Qodana pricing[1] to calculate active contributor(s)[2
 			if len(options.ProjectDirs) == 0 {
 				options.ProjectDirs = append(options.ProjectDirs, ".")
 			}
-			contributors := core.GetContributors(options.ProjectDirs, options.Days, false)
+			period := core.ContributorsPeriod{Days: options.Days, Since: options.Since, Until: options.Until}
+			if options.Since != "" || options.Until != "" {
+				period.Days = 0
+			}
+			if err := period.Validate(); err != nil {
+				log.Fatal(err)
+			}
+			contributors := core.GetContributorsInPeriod(options.ProjectDirs, period, options.ExcludeBots)
 			switch options.Output {
 			case "tabular":
-				core.PrintContributorsTable(contributors, options.Days, len(options.ProjectDirs))
+				core.PrintContributorsTable(contributors, period, len(options.ProjectDirs))
 				return
 			case "json":
-				out, err := core.ToJSON(contributors)
+				out, err := core.NewContributorsReport(contributors, period, options.ExcludeBots).ToJSON()
 				if err != nil {
 					log.Fatalf("Failed to convert to JSON: %s", err)
 				}
@@ -69,7 +79,12 @@ A command-line helper for Qodana pricing[1] to calculate active contributor(s)[2
 	flags := cmd.Flags()
 	flags.StringArrayVarP(&options.ProjectDirs, "project-dir", "i", []string{}, "Project directory, can be specified multiple times to check multiple projects, if not specified, current directory will be used")
 	flags.IntVarP(&options.Days, "days", "d", 90, "Number of days since when to calculate the number of active contributors")
+	flags.StringVar(&options.Since, "since", "", "Count the commits since the given date (YYYY-MM-DD, the day included) instead of the last --days days")
+	flags.StringVar(&options.Until, "until", "", "Count the commits until the given date (YYYY-MM-DD, the day included) instead of the last --days days, the whole history before it without --since")
+	flags.BoolVar(&options.ExcludeBots, "exclude-bots", false, "Don't count the bots (e.g. dependabot[bot]) as contributors")
 	flags.StringVarP(&options.Output, "output", "o", "tabular", "Output format, can be tabular or json")
+	cmd.MarkFlagsMutuallyExclusive("days", "since")
+	cmd.MarkFlagsMutuallyExclusive("days", "until")
 
 	return cmd
 }
@@ -0,0 +1,75 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"fmt"
+	"github.com/JetBrains/qodana-cli/v2024/platform"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// diffOptions represents diff command options.
+type diffOptions struct {
+	From          string
+	To            string
+	PrintProblems bool
+	Output        string
+}
+
+// newDiffCommand returns a new instance of the diff command.
+func newDiffCommand() *cobra.Command {
+	options := &diffOptions{}
+	cmd := &cobra.Command{
+		Use:   "diff",
+		Short: "Compare two SARIF reports",
+		Long:  `Show which problems appeared, disappeared or stayed unchanged between two SARIF reports.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			from, err := platform.ReadReport(options.From)
+			if err != nil {
+				log.Fatalf("Failed to read %s: %s", options.From, err)
+			}
+			to, err := platform.ReadReport(options.To)
+			if err != nil {
+				log.Fatalf("Failed to read %s: %s", options.To, err)
+			}
+			diff := platform.DiffReports(from, to)
+			switch options.Output {
+			case "tabular":
+				diff.Print(options.PrintProblems)
+			case "json":
+				out, err := diff.ToJSON()
+				if err != nil {
+					log.Fatalf("Failed to convert to JSON: %s", err)
+				}
+				_, err = fmt.Fprintln(cmd.OutOrStdout(), out)
+				if err != nil {
+					log.Fatalf("Failed to write to stdout: %s", err)
+				}
+			default:
+				log.Fatalf("Unknown output format: %s", options.Output)
+			}
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVar(&options.From, "from", "", "Path to the SARIF report to compare from (e.g. the previous scan)")
+	flags.StringVar(&options.To, "to", platform.QodanaSarifName, "Path to the SARIF report to compare to (e.g. the latest scan)")
+	flags.BoolVar(&options.PrintProblems, "print-problems", false, "Print the added and removed problems")
+	flags.StringVarP(&options.Output, "output", "o", "tabular", "Output format, can be tabular or json")
+	_ = cmd.MarkFlagRequired("from")
+	return cmd
+}
@@ -22,7 +22,9 @@ import (
 	"github.com/JetBrains/qodana-cli/v2024/platform"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"os"
 	"path/filepath"
+	"strings"
 )
 
 // newInitCommand returns a new instance of the show command.
@@ -34,6 +36,11 @@ func newInitCommand() *cobra.Command {
 		Short: "Configure a project for Qodana",
 		Long:  `Configure a project for Qodana: prepare Qodana configuration file by analyzing the project structure and generating a default configuration qodana.yaml file.`,
 		Run: func(cmd *cobra.Command, args []string) {
+			analyzer, err := initAnalyzer(options.Linter, options.Ide)
+			if err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
 			if options.ConfigName == "" {
 				options.ConfigName = platform.FindQodanaYaml(options.ProjectDir)
 			}
@@ -44,18 +51,22 @@ func newInitCommand() *cobra.Command {
 					log.Fatal(err)
 				}
 				options.ProjectDir = absPath
-				if platform.IsInteractive() && !platform.AskUserConfirm(fmt.Sprintf("Do you want to set up Qodana in %s", platform.PrimaryBold(options.ProjectDir))) {
+				if analyzer != "" {
+					platform.SetQodanaLinter(options.ProjectDir, analyzer, options.ConfigName)
+					platform.SuccessMessage("Selected %s", analyzer)
+					qodanaYaml = platform.LoadQodanaYaml(options.ProjectDir, options.ConfigName)
+				} else if platform.IsInteractive() && !platform.AskUserConfirm(fmt.Sprintf("Do you want to set up Qodana in %s", platform.PrimaryBold(options.ProjectDir))) {
 					return
-				}
-				analyzer := platform.GetAnalyzer(options.ProjectDir, options.ConfigName, options.GetToken(), true)
-				if platform.IsNativeAnalyzer(analyzer) {
-					options.Ide = analyzer
 				} else {
-					options.Linter = analyzer
+					analyzer = platform.GetAnalyzer(options.ProjectDir, options.ConfigName, options.GetToken(), true)
+					if platform.IsNativeAnalyzer(analyzer) {
+						options.Ide = analyzer
+					} else {
+						options.Linter = analyzer
+					}
 				}
 			} else {
 				platform.EmptyMessage()
-				var analyzer string
 				if qodanaYaml.Ide != "" {
 					analyzer = qodanaYaml.Ide
 				} else if qodanaYaml.Linter != "" {
@@ -83,6 +94,20 @@ func newInitCommand() *cobra.Command {
 	flags := cmd.Flags()
 	flags.StringVarP(&options.ProjectDir, "project-dir", "i", ".", "Root directory of the project to configure")
 	flags.BoolVarP(&force, "force", "f", false, "Force initialization (overwrite existing valid qodana.yaml)")
-	flags.StringVar(&options.ConfigName, "config", "", "Set a custom configuration file instead of 'qodana.yaml'. Relative paths in the configuration will be based on the project directory.")
+	flags.StringVarP(&options.Linter, "linter", "l", "", "Configure the given linter without detecting the project technologies and asking, e.g. qodana-jvm or jetbrains/qodana-jvm:"+platform.ReleaseVersion)
+	flags.StringVar(&options.Ide, "ide", "", "Configure the given native linter without detecting the project technologies and asking, available codes are "+strings.Join(platform.AllNativeCodes, ", "))
+	flags.StringVar(&options.ConfigName, "config", "", "Set a custom configuration file instead of 'qodana.yaml'. Relative paths in the configuration will be based on the project directory. Takes precedence over the "+platform.QodanaConfigNameEnv+" list of file names")
+	cmd.MarkFlagsMutuallyExclusive("linter", "ide")
 	return cmd
 }
+
+// initAnalyzer returns the analyzer passed to qodana init via --linter or --ide, empty if none is passed.
+func initAnalyzer(linter string, ide string) (string, error) {
+	if ide != "" {
+		return ide, platform.ValidateNativeIde(ide)
+	}
+	if linter != "" {
+		return platform.ResolveLinter(linter)
+	}
+	return "", nil
+}
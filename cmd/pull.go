@@ -22,16 +22,36 @@ import (
 	"github.com/docker/docker/client"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"os"
+	"strings"
 )
 
 // newPullCommand returns a new instance of the show command.
 func newPullCommand() *cobra.Command {
 	options := &platform.QodanaOptions{}
+	all := false
+	var filters []string
 	cmd := &cobra.Command{
 		Use:   "pull",
 		Short: "Pull latest version of linter",
 		Long:  `An alternative to pull an image.`,
 		Run: func(cmd *cobra.Command, args []string) {
+			if all {
+				core.PrepareContainerEnvSettings()
+				containerClient, err := client.NewClientWithOpts()
+				if err != nil {
+					log.Fatal("couldn't connect to container engine ", err)
+				}
+				images := filterImages(platform.AllImages, filters)
+				if len(images) == 0 {
+					platform.ErrorMessage("No linters match --filter %s", strings.Join(filters, ","))
+					os.Exit(1)
+				}
+				if failed := pullImages(containerClient, images); failed > 0 {
+					os.Exit(1)
+				}
+				return
+			}
 			if options.ConfigName == "" {
 				options.ConfigName = platform.FindQodanaYaml(options.ProjectDir)
 			}
@@ -52,5 +72,43 @@ func newPullCommand() *cobra.Command {
 	flags.StringVarP(&options.Linter, "linter", "l", "", "Override linter to use")
 	flags.StringVarP(&options.ProjectDir, "project-dir", "i", ".", "Root directory of the inspected project")
 	flags.StringVar(&options.ConfigName, "config", "", "Set a custom configuration file instead of 'qodana.yaml'. Relative paths in the configuration will be based on the project directory.")
+	flags.BoolVar(&all, "all", false, "Pull all the available linters, e.g. to warm up the images cache on the CI agents")
+	flags.StringSliceVar(&filters, "filter", []string{}, "Pull only the linters with the image name containing any of the given values (requires --all), e.g. --filter jvm,python")
+	cmd.MarkFlagsMutuallyExclusive("all", "linter")
 	return cmd
 }
+
+// filterImages returns the images containing any of the filters, all the images if there are no filters.
+func filterImages(images []string, filters []string) []string {
+	if len(filters) == 0 {
+		return images
+	}
+	var filtered []string
+	for _, image := range images {
+		for _, filter := range filters {
+			if strings.Contains(image, filter) {
+				filtered = append(filtered, image)
+				break
+			}
+		}
+	}
+	return filtered
+}
+
+// pullImages pulls the images one by one without stopping on failures, prints the summary
+// and returns the number of the images that failed to pull.
+func pullImages(containerClient *client.Client, images []string) int {
+	var failures []string
+	for _, image := range images {
+		if err := core.TryPullImage(containerClient, image); err != nil {
+			platform.ErrorMessage("Failed to pull %s: %s", image, err)
+			failures = append(failures, image)
+		}
+	}
+	if len(failures) == 0 {
+		platform.SuccessMessage("Pulled %d images", len(images))
+	} else {
+		platform.ErrorMessage("Pulled %d of %d images, failed: %s", len(images)-len(failures), len(images), strings.Join(failures, ", "))
+	}
+	return len(failures)
+}
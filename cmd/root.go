@@ -120,6 +120,9 @@ func InitCli() {
 		newSendCommand(),
 		newPullCommand(),
 		newViewCommand(),
+		newDiffCommand(),
+		newValidateConfigCommand(),
+		newConfigCommand(),
 		newContributorsCommand(),
 		newClocCommand(),
 	)
@@ -17,12 +17,15 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"github.com/JetBrains/qodana-cli/v2024/cloud"
 	"github.com/JetBrains/qodana-cli/v2024/platform"
 	log "github.com/sirupsen/logrus"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"syscall"
 
 	"github.com/JetBrains/qodana-cli/v2024/core"
 	"github.com/spf13/cobra"
@@ -44,8 +47,156 @@ But you can always override qodana.yaml options with the following command-line
 
 			ctx := cmd.Context()
 			checkProjectDir(options.ProjectDir)
+			if options.ListProfiles {
+				for _, profile := range core.ListProfiles(&core.QodanaOptions{QodanaOptions: options}) {
+					_, _ = fmt.Fprintln(cmd.OutOrStdout(), profile)
+				}
+				return
+			}
+			if err := platform.ValidateFailOnSeverity(options.FailOnSeverity); err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
+			if err := platform.ValidateBaselineMatch(options.BaselineMatch); err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
+			if err := platform.ValidateAbsentMinSeverity(options.AbsentMinSeverity); err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
+			if err := platform.ValidateSbomFormat(options.SbomFormat); err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
+			if _, err := platform.ParseUriRebases(options.SarifRebaseUris); err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
+			if _, err := platform.ParseExitCodeMap(options.ExitCodeMap); err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
+			severityExitCodes, err := platform.ParseSeverityExitCodes(options.SeverityExitCodes)
+			if err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
+			if options.ReportZipOnly && options.ReportZip == "" {
+				platform.ErrorMessage("--report-zip-only requires --report-zip")
+				os.Exit(1)
+			}
+			if options.BaselineCreateIfMissing && options.Baseline == "" {
+				platform.ErrorMessage("--baseline-create-if-missing requires --baseline")
+				os.Exit(1)
+			}
+			if err := platform.ValidateReportAuth(options.ReportUser, options.ReportPassword); err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
+			if err := options.DeriveAnalysisId(); err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
+			if err := options.ResolveDiffBaseBranch(); err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
+			if err := options.ValidateS3Upload(); err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
+			if err := platform.ValidateUriBase(options.UriBase); err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
+			if err := platform.ValidateScopeGlobs(options.ScopeGlobs); err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
+			if _, err := platform.ParseContainerLabels(options.ContainerLabels); err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
+			if err := platform.ValidateCaCerts(options.CaCerts); err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
+			if err := platform.ValidateUmask(options.ResultUmask); err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
+			if err := core.ValidateContainerLogLevel(options.ContainerLogLevel); err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
+			if _, err := platform.ParseCludes(append(options.Includes, options.Excludes...)); err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
+			if err := options.ValidateFailOnErrorNotification(); err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
+			if options.JavaHome != "" {
+				if _, err := platform.JavaExecutable(options.JavaHome); err != nil {
+					platform.ErrorMessage(err.Error())
+					os.Exit(1)
+				}
+			}
+			printFormat, err := parsePrintFormat(options.PrintFormat)
+			if err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
+			if err := platform.ValidateProblemsOutput(options.ProblemsGroupBy, options.ProblemsLimit); err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
+			if options.BaselineNetGate {
+				options.BaselineIncludeAbsent = true
+			}
 			options.FetchAnalyzerSettings()
+			if options.Exec != "" && options.Ide != "" {
+				platform.ErrorMessage("--exec is supported only for container runs, but %s is a native analyzer", options.Ide)
+				os.Exit(1)
+			}
+			if options.ReportZip != "" && options.Ide != "" && !platform.IsContainer() {
+				platform.ErrorMessage("--report-zip is supported only for container runs, the native analyzer %s doesn't generate the HTML report", options.Ide)
+				os.Exit(1)
+			}
+			if err := options.ValidateProjectReadOnly(); err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
+			if options.Watch && (options.Ide == "" || platform.IsContainer()) {
+				platform.ErrorMessage("--watch is supported only for native analyzers, restarting a container on every change is too slow")
+				os.Exit(1)
+			}
 			qodanaOptions := core.QodanaOptions{QodanaOptions: options}
+			if options.DryRun {
+				core.PrintDryRun(&qodanaOptions)
+				os.Exit(0)
+			}
+			if options.Watch {
+				// Ctrl+C cancels the watch context instead of the CLI interrupt handler exiting right away
+				ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+				defer stop()
+				if platform.InterruptChannel != nil {
+					signal.Stop(platform.InterruptChannel)
+				}
+				options.PrintProblems = true // there's no report to open between the runs
+				baseline := options.Baseline
+				analyze := func() {
+					options.Baseline = baseline // the baseline created by --baseline-create-if-missing is used by the next runs
+					watchAnalysisRun(ctx, &qodanaOptions, printFormat, severityExitCodes)
+				}
+				if err := core.WatchAnalysis(ctx, &qodanaOptions, analyze); err != nil {
+					platform.ErrorMessage(err.Error())
+					os.Exit(1)
+				}
+				return
+			}
 			exitCode := core.RunAnalysis(ctx, &qodanaOptions)
 			if platform.IsContainer() {
 				err := platform.ChangePermissionsRecursively(options.ResultsDir)
@@ -55,15 +206,8 @@ But you can always override qodana.yaml options with the following command-line
 			}
 			checkExitCode(exitCode, options.ResultsDir, &qodanaOptions)
 			newReportUrl := cloud.GetReportUrl(options.ResultsDir)
-			platform.ProcessSarif(
-				filepath.Join(options.ResultsDir, platform.QodanaSarifName),
-				options.AnalysisId,
-				newReportUrl,
-				options.PrintProblems,
-				options.GenerateCodeClimateReport,
-				options.SendBitBucketInsights,
-			)
-			if platform.IsInteractive() {
+			result := processScanResults(options, exitCode, newReportUrl, printFormat, severityExitCodes)
+			if platform.IsInteractive() && !options.ReportZipOnly { // with --report-zip-only the report is only in the archive
 				options.ShowReport = platform.AskUserConfirm("Do you want to open the latest report")
 			}
 
@@ -72,8 +216,8 @@ But you can always override qodana.yaml options with the following command-line
 			}
 
 			if options.ShowReport {
-				platform.ShowReport(options.ResultsDir, options.ReportDir, options.Port)
-			} else if !platform.IsContainer() && platform.IsInteractive() {
+				platform.ShowReport(options.ResultsDir, options.ReportDir, options.ReportHost, options.Port, options.PortAuto, options.ReportUser, options.ReportPassword)
+			} else if !platform.IsContainer() && platform.IsInteractive() && !options.ReportZipOnly {
 				platform.WarningMessage(
 					"To view the Qodana report later, run %s in the current directory or add %s flag to %s",
 					platform.PrimaryBold("qodana show"),
@@ -82,10 +226,13 @@ But you can always override qodana.yaml options with the following command-line
 				)
 			}
 
-			if exitCode == platform.QodanaFailThresholdExitCode {
+			if message := result.failureMessage(); message != "" {
 				platform.EmptyMessage()
-				platform.ErrorMessage("The number of problems exceeds the fail threshold")
-				os.Exit(exitCode)
+				platform.ErrorMessage(message)
+				if result.severity != "" {
+					os.Exit(result.exitCode)
+				}
+				os.Exit(options.MapExitCode(result.exitCode))
 			}
 		},
 	}
@@ -98,8 +245,141 @@ But you can always override qodana.yaml options with the following command-line
 	return cmd
 }
 
+// watchAnalysisRun runs the analysis for --watch and processes its results like a single scan does,
+// the failures are reported but don't stop watching.
+func watchAnalysisRun(
+	ctx context.Context,
+	options *core.QodanaOptions,
+	printFormat *platform.ProblemFormat,
+	severityExitCodes map[string]int,
+) {
+	exitCode := core.RunAnalysis(ctx, options)
+	if ctx.Err() != nil { // the watch is stopped, the interrupted run has nothing to report
+		return
+	}
+	if exitCode != platform.QodanaSuccessExitCode && exitCode != platform.QodanaFailThresholdExitCode {
+		platform.ErrorMessage("Qodana exited with code %d, check the logs in %s", exitCode, options.LogDirPath())
+		return
+	}
+	result := processScanResults(options.QodanaOptions, exitCode, cloud.GetReportUrl(options.ResultsDir), printFormat, severityExitCodes)
+	if message := result.failureMessage(); message != "" {
+		platform.EmptyMessage()
+		platform.ErrorMessage(message)
+	}
+}
+
+// scanResult is the outcome of a scan after its report is processed.
+type scanResult struct {
+	exitCode          int
+	severity          string // the severity --severity-exit-code is applied for, if any
+	licenseViolations int
+}
+
+// failureMessage explains why the scan fails on its results, it's empty if the scan doesn't.
+func (r scanResult) failureMessage() string {
+	switch {
+	case r.severity != "":
+		return fmt.Sprintf("New problems of %s severity are found", r.severity)
+	case r.exitCode == platform.QodanaLicenseViolationExitCode:
+		return fmt.Sprintf("%d new dependency license violations are found", r.licenseViolations)
+	case r.exitCode == platform.QodanaFailThresholdExitCode:
+		return "The number of problems exceeds the fail threshold"
+	}
+	return ""
+}
+
+// processScanResults produces the outputs from the report of the finished analysis and computes the final exit code,
+// the report itself is processed by the analysis, see core.processIdeResults. Both the single scan and every --watch
+// run go through it.
+func processScanResults(
+	options *platform.QodanaOptions,
+	exitCode int,
+	reportUrl string,
+	printFormat *platform.ProblemFormat,
+	severityExitCodes map[string]int,
+) scanResult {
+	sarifPath := filepath.Join(options.ResultsDir, platform.QodanaSarifName)
+	uriBase, err := platform.ResolveUriBase(options.UriBase, options.ProjectDir)
+	if err != nil {
+		log.Warnf("Problems linking the problems to the sources: %v", err)
+	}
+	platform.ProcessSarif(sarifPath, platform.ProcessSarifOptions{
+		ProjectDir:       options.ProjectDir,
+		AnalysisId:       options.AnalysisId,
+		ReportUrl:        reportUrl,
+		UriBase:          uriBase,
+		PrintProblems:    options.PrintProblems,
+		CodeClimate:      options.GenerateCodeClimateReport,
+		CodeInsights:     options.SendBitBucketInsights,
+		AzureAnnotations: options.AzureAnnotations,
+		OnlyNew:          options.OnlyNew,
+		PrintFormat:      printFormat,
+		CollapseRepeated: options.CollapseRepeated,
+		ProblemsGroupBy:  options.ProblemsGroupBy,
+		ProblemsLimit:    options.ProblemsLimit,
+		Thresholds:       options.FailureThresholds(),
+	})
+	if _, err := platform.SplitReport(sarifPath, options.SarifSplitSize); err != nil {
+		log.Fatal(err)
+	}
+	if err := options.PublishReportToS3(); err != nil {
+		log.Fatal(err)
+	}
+	if err := options.ArchiveReport(); err != nil {
+		log.Fatal(err)
+	}
+	if exitCode == platform.QodanaSuccessExitCode && len(options.FailOnSeverity) > 0 {
+		failed, err := platform.HasNewProblemsOfSeverity(sarifPath, options.FailOnSeverity)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if failed {
+			exitCode = platform.QodanaFailThresholdExitCode
+		}
+	}
+	licenseViolations := 0
+	if options.FailOnLicenseViolation && (exitCode == platform.QodanaSuccessExitCode || exitCode == platform.QodanaFailThresholdExitCode) {
+		licenseViolations, err = platform.CountNewLicenseViolations(sarifPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if licenseViolations > 0 {
+			exitCode = platform.QodanaLicenseViolationExitCode
+		}
+	}
+	if exitCode == platform.QodanaSuccessExitCode && options.BaselineNetGate && options.Baseline != "" {
+		exitCode, err = platform.CheckBaselineNetGate(sarifPath, exitCode)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := platform.PruneBaseline(options, sarifPath); err != nil {
+		log.Fatal(err)
+	}
+	severity := ""
+	if exitCode == platform.QodanaSuccessExitCode || exitCode == platform.QodanaFailThresholdExitCode {
+		var code int
+		severity, code, err = platform.SeverityExitCode(sarifPath, severityExitCodes)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if severity != "" {
+			exitCode = code
+		}
+	}
+	return scanResult{exitCode: exitCode, severity: severity, licenseViolations: licenseViolations}
+}
+
+// parsePrintFormat parses the --print-format template, returns nil if it's not set.
+func parsePrintFormat(format string) (*platform.ProblemFormat, error) {
+	if format == "" {
+		return nil, nil
+	}
+	return platform.ParseProblemFormat(format)
+}
+
 func checkProjectDir(projectDir string) {
-	if platform.IsInteractive() && core.IsHomeDirectory(projectDir) {
+	if platform.IsInteractive() && platform.IsHomeDirectory(projectDir) {
 		platform.WarningMessage(
 			fmt.Sprintf("Project directory (%s) is the $HOME directory", projectDir),
 		)
@@ -113,6 +393,24 @@ func checkProjectDir(projectDir string) {
 	}
 }
 
+// printRuntimeNotifications prints the internal errors collected during the analysis, the full report is preferred over the short one.
+func printRuntimeNotifications(resultsDir string) {
+	for _, name := range []string{platform.QodanaSarifName, platform.QodanaShortSarifName} {
+		notifications, err := platform.GetRuntimeNotifications(filepath.Join(resultsDir, name))
+		if err != nil {
+			continue
+		}
+		for _, notification := range notifications {
+			platform.ErrorMessage("  %s", notification)
+		}
+		if len(notifications) == 0 {
+			platform.WarningMessage("No runtime notifications found in the report, check ./logs/ in the results directory for more information")
+		}
+		return
+	}
+	platform.WarningMessage("Check ./logs/ in the results directory for more information")
+}
+
 func checkExitCode(exitCode int, resultsDir string, options *core.QodanaOptions) {
 	if exitCode == platform.QodanaEapLicenseExpiredExitCode && platform.IsInteractive() {
 		platform.EmptyMessage()
@@ -120,10 +418,18 @@ func checkExitCode(exitCode int, resultsDir string, options *core.QodanaOptions)
 			"Your license expired: update your license or token. If you are using EAP, make sure you are using the latest CLI version and update to the latest linter by running %s ",
 			platform.PrimaryBold("qodana init"),
 		)
-		os.Exit(exitCode)
+		os.Exit(options.MapExitCode(exitCode))
+	} else if exitCode == platform.QodanaInternalErrorExitCode {
+		platform.ErrorMessage("Qodana analysis failed because of internal errors (failOnErrorNotification is set in qodana.yaml or --fail-on-error-notification is passed)")
+		printRuntimeNotifications(resultsDir)
+		os.Exit(options.MapExitCode(exitCode))
 	} else if exitCode == platform.QodanaTimeoutExitCodePlaceholder {
 		platform.ErrorMessage("Qodana analysis reached timeout %s", options.GetAnalysisTimeout())
 		os.Exit(options.AnalysisTimeoutExitCode)
+	} else if exitCode == platform.QodanaStartupTimeoutExitCodePlaceholder {
+		platform.ErrorMessage("Qodana analysis hasn't started within the startup timeout %s", options.GetStartupTimeout())
+		platform.WarningMessage("Check ./logs/ in the results directory for more information")
+		os.Exit(options.AnalysisTimeoutExitCode)
 	} else if exitCode != platform.QodanaSuccessExitCode && exitCode != platform.QodanaFailThresholdExitCode {
 		platform.ErrorMessage("Qodana exited with code %d", exitCode)
 		platform.WarningMessage("Check ./logs/ in the results directory for more information")
@@ -135,6 +441,6 @@ func checkExitCode(exitCode int, resultsDir string, options *core.QodanaOptions)
 				log.Fatalf("Error while opening directory: %s", err)
 			}
 		}
-		os.Exit(exitCode)
+		os.Exit(options.MapExitCode(exitCode))
 	}
 }
@@ -23,6 +23,7 @@ import (
 	"github.com/JetBrains/qodana-cli/v2024/platform"
 	"github.com/google/uuid"
 	"github.com/spf13/cobra"
+	"os"
 	"path/filepath"
 )
 
@@ -39,6 +40,14 @@ If report directory is not specified, the latest report will be fetched from the
 If you are using other Qodana Cloud instance than https://qodana.cloud/, override it by declaring the %s environment variable.`, platform.PrimaryBold(cloud.QodanaEndpointEnv)),
 		Run: func(cmd *cobra.Command, args []string) {
 			options.FetchAnalyzerSettings()
+			java := core.Prod.JbrJava()
+			if options.JavaHome != "" {
+				var err error
+				if java, err = platform.JavaExecutable(options.JavaHome); err != nil {
+					platform.ErrorMessage(err.Error())
+					os.Exit(1)
+				}
+			}
 			var publisherPath string
 			if platform.IsContainer() {
 				publisherPath = filepath.Join(core.Prod.IdeBin(), platform.PublisherJarName) // TODO : what to do with PROD
@@ -49,7 +58,7 @@ If you are using other Qodana Cloud instance than https://qodana.cloud/, overrid
 				options,
 				options.ValidateToken(false),
 				publisherPath,
-				core.Prod.JbrJava(),
+				java,
 			)
 		},
 	}
@@ -59,6 +68,7 @@ If you are using other Qodana Cloud instance than https://qodana.cloud/, overrid
 	flags.StringVarP(&options.ResultsDir, "results-dir", "o", "", "Override directory to save Qodana inspection results to (default <userCacheDir>/JetBrains/<linter>/results)")
 	flags.StringVarP(&options.ReportDir, "report-dir", "r", "", "Override directory to save Qodana HTML report to (default <userCacheDir>/JetBrains/<linter>/results/report)")
 	flags.StringVar(&options.ConfigName, "config", "", "Set a custom configuration file instead of 'qodana.yaml'. Relative paths in the configuration will be based on the project directory.")
+	flags.StringVar(&options.JavaHome, "java-home", "", "Use the Java installation from the given directory instead of the bundled JBR to run the publisher")
 	flags.StringVarP(&options.AnalysisId, "analysis-id", "a", uuid.New().String(), "Unique report identifier (GUID) to be used by Qodana Cloud")
 	return cmd
 }
@@ -21,12 +21,14 @@ import (
 	"github.com/JetBrains/qodana-cli/v2024/platform"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"os"
 )
 
 // newShowCommand returns a new instance of the show command.
 func newShowCommand() *cobra.Command {
 	options := &platform.QodanaOptions{}
 	openDir := false
+	openCloud := false
 	cmd := &cobra.Command{
 		Use:   "show",
 		Short: "Show a Qodana report",
@@ -35,19 +37,33 @@ func newShowCommand() *cobra.Command {
 Due to JavaScript security restrictions, the generated report cannot
 be viewed via the file:// protocol (by double-clicking the index.html file).
 https://www.jetbrains.com/help/qodana/html-report.html
-This command serves the Qodana report locally and opens a browser to it.`,
+This command serves the Qodana report locally and opens a browser to it.
+With --cloud, the Qodana Cloud report of the latest run is opened instead.`,
 		Run: func(cmd *cobra.Command, args []string) {
+			if err := platform.ValidateReportAuth(options.ReportUser, options.ReportPassword); err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
 			options.FetchAnalyzerSettings()
 			if openDir {
 				err := core.OpenDir(options.ResultsDir)
 				if err != nil {
 					log.Fatal(err)
 				}
+			} else if openCloud {
+				if err := platform.ShowCloudReport(options.ResultsDir); err != nil {
+					platform.ErrorMessage(err.Error())
+					os.Exit(1)
+				}
 			} else {
 				platform.ShowReport(
 					options.ResultsDir,
 					options.ReportDir,
+					options.ReportHost,
 					options.Port,
+					options.PortAuto,
+					options.ReportUser,
+					options.ReportPassword,
 				)
 			}
 		},
@@ -58,7 +74,13 @@ This command serves the Qodana report locally and opens a browser to it.`,
 	flags.StringVarP(&options.ResultsDir, "results-dir", "o", "", "Override directory to save Qodana inspection results to (default <userCacheDir>/JetBrains/<linter>/results)")
 	flags.StringVarP(&options.ReportDir, "report-dir", "r", "", "Override directory to save Qodana HTML report to (default <userCacheDir>/JetBrains/<linter>/results/report)")
 	flags.IntVarP(&options.Port, "port", "p", 8080, "Specify port to serve report at")
+	flags.BoolVar(&options.PortAuto, "port-auto", false, "Serve the report on the next free port if --port is busy")
+	flags.StringVar(&options.ReportHost, "report-host", "", "Address to serve the report on, e.g. localhost (default all interfaces)")
+	flags.StringVar(&options.ReportUser, "report-user", "", "User name of the basic auth for the served report, used with --report-password")
+	flags.StringVar(&options.ReportPassword, "report-password", "", "Require the basic auth with --report-user and the given password to view the served report")
 	flags.BoolVarP(&openDir, "dir-only", "d", false, "Open report directory only, don't serve it")
+	flags.BoolVar(&openCloud, "cloud", false, "Open the Qodana Cloud report of the latest run instead of serving the local one")
 	flags.StringVar(&options.ConfigName, "config", "", "Set a custom configuration file instead of 'qodana.yaml'. Relative paths in the configuration will be based on the project directory.")
+	cmd.MarkFlagsMutuallyExclusive("cloud", "dir-only")
 	return cmd
 }
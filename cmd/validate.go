@@ -0,0 +1,73 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/JetBrains/qodana-cli/v2024/platform"
+	"github.com/spf13/cobra"
+)
+
+// validateConfigOptions represents validate-config command options.
+type validateConfigOptions struct {
+	ProjectDir string
+	ConfigName string
+}
+
+// newValidateConfigCommand returns a new instance of the validate-config command.
+func newValidateConfigCommand() *cobra.Command {
+	options := &validateConfigOptions{}
+	cmd := &cobra.Command{
+		Use:   "validate-config",
+		Short: "Validate qodana.yaml",
+		Long: `Check qodana.yaml (https://www.jetbrains.com/help/qodana/qodana-yaml.html) without running the analysis.
+
+Unknown keys are reported as warnings, wrong value types and inconsistent options (e.g. both linter and ide set) as errors.
+The command exits with a non-zero code if any error is found.`,
+		Run: func(cmd *cobra.Command, args []string) {
+			configName := options.ConfigName
+			if configName == "" {
+				configName = platform.FindQodanaYaml(options.ProjectDir)
+			}
+			qodanaYamlPath := configName
+			if !filepath.IsAbs(qodanaYamlPath) {
+				qodanaYamlPath = filepath.Join(options.ProjectDir, configName)
+			}
+			issues, err := platform.ValidateQodanaYaml(qodanaYamlPath)
+			if err != nil {
+				platform.ErrorMessage(err.Error())
+				os.Exit(1)
+			}
+			for _, warning := range issues.Warnings {
+				platform.WarningMessage("%s", warning)
+			}
+			for _, e := range issues.Errors {
+				platform.ErrorMessage("%s", e)
+			}
+			if issues.HasErrors() {
+				os.Exit(1)
+			}
+			platform.SuccessMessage("%s is valid", qodanaYamlPath)
+		},
+	}
+	flags := cmd.Flags()
+	flags.StringVarP(&options.ProjectDir, "project-dir", "i", ".", "Root directory of the inspected project")
+	flags.StringVar(&options.ConfigName, "config", "", "Set a custom configuration file instead of 'qodana.yaml'")
+	return cmd
+}
@@ -18,12 +18,19 @@ package cmd
 
 import (
 	"github.com/JetBrains/qodana-cli/v2024/platform"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"strings"
 )
 
 // viewOptions represents view command options.
 type viewOptions struct {
-	SarifFile string
+	SarifFile        string
+	PrintFormat      string
+	CollapseRepeated bool
+	ProblemsGroupBy  string
+	ProblemsLimit    int
+	OnlyNew          bool
 }
 
 // newViewCommand returns a new instance of the show command.
@@ -34,10 +41,29 @@ func newViewCommand() *cobra.Command {
 		Short: "View SARIF files in CLI",
 		Long:  `Preview all problems found in SARIF files in CLI.`,
 		Run: func(cmd *cobra.Command, args []string) {
-			platform.ProcessSarif(options.SarifFile, "", "", true, false, false)
+			printFormat, err := parsePrintFormat(options.PrintFormat)
+			if err != nil {
+				log.Fatal(err)
+			}
+			if err := platform.ValidateProblemsOutput(options.ProblemsGroupBy, options.ProblemsLimit); err != nil {
+				log.Fatal(err)
+			}
+			platform.ProcessSarif(options.SarifFile, platform.ProcessSarifOptions{
+				PrintProblems:    true,
+				OnlyNew:          options.OnlyNew,
+				PrintFormat:      printFormat,
+				CollapseRepeated: options.CollapseRepeated,
+				ProblemsGroupBy:  options.ProblemsGroupBy,
+				ProblemsLimit:    options.ProblemsLimit,
+			})
 		},
 	}
 	flags := cmd.Flags()
 	flags.StringVarP(&options.SarifFile, "sarif-file", "f", platform.QodanaSarifName, "Path to the SARIF file")
+	flags.StringVar(&options.PrintFormat, "print-format", "", "Print problems one per line using the given template, e.g. '{severity}\\t{file}:{line}\\t{ruleId}'")
+	flags.BoolVar(&options.CollapseRepeated, "collapse-repeated", false, "Print consecutive problems with the same rule and message as one line with the number of occurrences and the files")
+	flags.StringVar(&options.ProblemsGroupBy, "problems-group-by", "", "Print problems in groups by "+strings.Join(platform.ProblemsGroupByValues, ", ")+" with the number of problems in each group")
+	flags.IntVar(&options.ProblemsLimit, "problems-limit", 0, "Print at most the given number of problems, in each group with --problems-group-by")
+	flags.BoolVar(&options.OnlyNew, "only-new", false, "Print only the new problems, not the ones present in the baseline (unchanged or absent)")
 	return cmd
 }
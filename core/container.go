@@ -32,13 +32,17 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	cliconfig "github.com/docker/cli/cli/config"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/mount"
 	"github.com/docker/docker/client"
 	log "github.com/sirupsen/logrus"
@@ -47,8 +51,16 @@ import (
 const (
 	// officialImagePrefix is the prefix of official Qodana images.
 	officialImagePrefix      = "jetbrains/qodana"
+	resultsStreamInterval    = 30 * time.Second
 	dockerSpecialCharsLength = 8
 	containerJvmDebugPort    = "5005"
+	containerConfigOverride  = "/data/qodana-config-override.yaml"
+	// containerNameLabel is the label with the container name set on every Qodana container, used to find it for the cleanup.
+	containerNameLabel = "com.jetbrains.qodana.cli.container"
+	// containerStopTimeoutSeconds is the time given to the container to stop on SIGTERM before it's killed.
+	containerStopTimeoutSeconds = 3
+	// containerCleanupTimeout bounds every container engine call of the cleanup, so an interrupt never hangs.
+	containerCleanupTimeout = 10 * time.Second
 )
 
 var (
@@ -73,10 +85,16 @@ func runQodanaContainer(ctx context.Context, options *QodanaOptions) int {
 		platform.ErrorMessage("Container engine is not running a Linux platform, other platforms are not supported by Qodana")
 		return 1
 	}
+	if options.RequirePinnedImage && !hasExactVersionTag(options.Linter) {
+		platform.ErrorMessage(
+			"The linter image %s is not pinned to an exact version tag or digest, which is required by --require-pinned-image",
+			options.Linter,
+		)
+		return 1
+	}
 	fixDarwinCaches(options)
-
-	for i, stage := range scanStages {
-		scanStages[i] = platform.PrimaryBold("[%d/%d] ", i+1, len(scanStages)+1) + platform.Primary(stage)
+	if message := gitMetadataWarning(options); message != "" {
+		platform.WarningMessage(message)
 	}
 
 	if options.SkipPull {
@@ -84,17 +102,50 @@ func runQodanaContainer(ctx context.Context, options *QodanaOptions) int {
 	} else {
 		PullImage(docker, options.Linter)
 	}
-	progress, _ := platform.StartQodanaSpinner(scanStages[0])
+	if options.Exec != "" {
+		platform.SuccessMessage("Run the following command to start %s in the Qodana container:", options.Exec)
+		fmt.Println(generateDebugDockerRunCommand(getDockerOptions(options)))
+		return 0
+	}
+	progress, _ := platform.StartQodanaSpinner(formatScanStage(0))
+	reportScanStage(0)
 
+	if !options.SkipPreflight {
+		for _, message := range containerPreflight(options) {
+			platform.WarningMessage(message)
+		}
+	}
 	dockerConfig := getDockerOptions(options)
 	log.Debugf("docker command to run: %s", generateDebugDockerRunCommand(dockerConfig))
 
-	platform.UpdateText(progress, scanStages[1])
+	updateScanStage(progress, 1)
 
 	runContainer(ctx, docker, dockerConfig)
-	go followLinter(docker, dockerConfig.Name, progress)
-
-	exitCode := getContainerExitCode(ctx, docker, dockerConfig.Name)
+	started := make(chan struct{})
+	var startedOnce sync.Once
+	logFilter, err := newLinterLogFilter(options.ContainerLogLevel, options.LogDirPath())
+	if err != nil {
+		log.Fatal("Failed to set up the container log filtering: ", err)
+	}
+	go followLinter(docker, dockerConfig.Name, progress, func() { startedOnce.Do(func() { close(started) }) }, logFilter)
+	stopStreaming := startResultsStreaming(ctx, options)
+
+	waitCtx := ctx
+	if timeout := options.GetStartupTimeout(); timeout > 0 {
+		var cancel context.CancelCauseFunc
+		waitCtx, cancel = context.WithCancelCause(ctx)
+		defer cancel(nil)
+		go func() {
+			if !waitForAnalysisStart(waitCtx, started, timeout) {
+				cancel(errStartupTimeout)
+			}
+		}()
+	}
+	exitCode := getContainerExitCode(waitCtx, docker, dockerConfig.Name)
+	stopStreaming()
+	if exitCode == platform.QodanaTimeoutExitCodePlaceholder {
+		savePartialResults(options)
+	}
 
 	fixDarwinCaches(options)
 
@@ -105,14 +156,94 @@ func runQodanaContainer(ctx context.Context, options *QodanaOptions) int {
 	return int(exitCode)
 }
 
+// startResultsStreaming starts copying the partial short SARIF to --stream-results-dir,
+// the returned function stops the streaming after the last copy.
+func startResultsStreaming(ctx context.Context, options *QodanaOptions) func() {
+	if options.StreamResultsDir == "" {
+		return func() {}
+	}
+	if err := os.MkdirAll(options.StreamResultsDir, os.ModePerm); err != nil {
+		log.Fatal("couldn't create the directory for streamed results ", err)
+	}
+	streamCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		streamShortSarif(streamCtx, options.ResultsDir, options.StreamResultsDir, resultsStreamInterval)
+		close(done)
+	}()
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// streamShortSarif copies the short SARIF from resultsDir to targetDir every interval until ctx is done.
+func streamShortSarif(ctx context.Context, resultsDir string, targetDir string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var lastModified time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			if _, err := copyShortSarif(resultsDir, targetDir, lastModified); err != nil {
+				log.Warnf("Failed to copy partial results: %s", err)
+			}
+			return
+		case <-ticker.C:
+			modified, err := copyShortSarif(resultsDir, targetDir, lastModified)
+			if err != nil {
+				log.Warnf("Failed to copy partial results: %s", err)
+				continue
+			}
+			lastModified = modified
+		}
+	}
+}
+
+// copyShortSarif copies qodana-short.sarif.json from resultsDir to targetDir if it changed after lastModified.
+// If only the full SARIF is produced so far, the short one is made from it. Returns the modification time of the copied report.
+func copyShortSarif(resultsDir string, targetDir string, lastModified time.Time) (time.Time, error) {
+	target := filepath.Join(targetDir, platform.QodanaShortSarifName)
+	for _, name := range []string{platform.QodanaShortSarifName, platform.QodanaSarifName} {
+		source := filepath.Join(resultsDir, name)
+		info, err := os.Stat(source)
+		if err != nil {
+			continue
+		}
+		if !info.ModTime().After(lastModified) {
+			return lastModified, nil
+		}
+		if name == platform.QodanaShortSarifName {
+			err = platform.CopyFile(source, target)
+		} else {
+			err = platform.MakeShortSarif(source, target)
+		}
+		if err != nil {
+			return lastModified, err
+		}
+		log.Debugf("Partial results copied to %s", target)
+		return info.ModTime(), nil
+	}
+	return lastModified, nil
+}
+
 // isUnofficialLinter checks if the linter is unofficial.
 func isUnofficialLinter(linter string) bool {
 	return !strings.HasPrefix(linter, officialImagePrefix)
 }
 
-// hasExactVersionTag checks if the linter has an exact version tag.
+// hasExactVersionTag checks if the linter has an exact version tag or is pinned by a digest.
 func hasExactVersionTag(linter string) bool {
-	return strings.Contains(linter, ":") && !strings.Contains(linter, ":latest")
+	if isDigestPinned(linter) {
+		return true
+	}
+	name := linter[strings.LastIndex(linter, "/")+1:] // the registry host can contain a port
+	return strings.Contains(name, ":") && !strings.Contains(name, ":latest")
+}
+
+// isDigestPinned checks if the linter is pinned by a digest (image@sha256:...).
+func isDigestPinned(linter string) bool {
+	return strings.Contains(linter, "@sha256:")
 }
 
 // isCompatibleLinter checks if the linter is compatible with the current CLI.
@@ -136,7 +267,7 @@ func checkImage(linter string) {
 			linter,
 			strings.Join([]string{strings.Split(linter, ":")[0], platform.ReleaseVersion}, ":"),
 		)
-	} else if !isCompatibleLinter(linter) {
+	} else if !isDigestPinned(linter) && !isCompatibleLinter(linter) {
 		platform.WarningMessageCI(
 			"You are using a non-compatible Qodana linter %s with the current CLI (%s) \n   Consider updating CLI or using a compatible linter %s \n",
 			linter,
@@ -228,16 +359,88 @@ func PrepareContainerEnvSettings() {
 	CheckContainerEngineMemory()
 }
 
-// PullImage pulls docker image and prints the process.
+// PullImage pulls docker image and prints the process. The pull is skipped if the local image is current.
 func PullImage(client *client.Client, image string) {
+	if err := TryPullImage(client, image); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// TryPullImage pulls the image if the local one is not current, returns the error instead of exiting.
+func TryPullImage(client *client.Client, image string) error {
 	checkImage(image)
+	ctx := context.Background()
+	current := false
+	var err error
 	platform.PrintProcess(
-		func(_ *pterm.SpinnerPrinter) {
-			pullImage(context.Background(), client, image)
+		func(spinner *pterm.SpinnerPrinter) {
+			if current = isImageCurrent(ctx, client, image); current {
+				message := fmt.Sprintf("The image %s is current", platform.PrimaryBold(image))
+				if spinner != nil {
+					spinner.UpdateText(message)
+				} else {
+					fmt.Println(message)
+				}
+				return
+			}
+			err = pullImage(ctx, client, image)
 		},
 		fmt.Sprintf("Pulling the image %s", platform.PrimaryBold(image)),
-		"pulling the latest version of linter",
+		"",
 	)
+	if err != nil {
+		return err
+	}
+	if !current {
+		platform.SuccessMessage("Finished pulling the latest version of linter")
+	}
+	return nil
+}
+
+// isImageCurrent checks if the local image has the same digest as the one in the registry, so it doesn't need pulling.
+// Any failure (no local image, the registry isn't reachable) means the image has to be pulled.
+func isImageCurrent(ctx context.Context, client *client.Client, image string) bool {
+	local, _, err := client.ImageInspectWithRaw(ctx, image)
+	if err != nil || len(local.RepoDigests) == 0 {
+		return false
+	}
+	encodedAuth, err := getRegistryAuth(image)
+	if err != nil {
+		log.Debugf("Can't load the registry auth for %s: %s", image, err)
+	}
+	remote, err := client.DistributionInspect(ctx, image, encodedAuth)
+	if err != nil {
+		log.Debugf("Can't get the digest of %s from the registry: %s", image, err)
+		return false
+	}
+	return hasRepoDigest(local.RepoDigests, remote.Descriptor.Digest.String())
+}
+
+// hasRepoDigest checks if one of the local image repo digests (repository@sha256:...) has the given digest.
+func hasRepoDigest(repoDigests []string, digest string) bool {
+	if digest == "" {
+		return false
+	}
+	for _, repoDigest := range repoDigests {
+		if strings.HasSuffix(repoDigest, "@"+digest) {
+			return true
+		}
+	}
+	return false
+}
+
+// getRegistryAuth returns the encoded auth for the registry of the image from the docker CLI config.
+func getRegistryAuth(image string) (string, error) {
+	cfg, err := cliconfig.Load("")
+	if err != nil {
+		return "", err
+	}
+	registryHostname := strings.Split(image, "/")[0]
+	a, err := cfg.GetAuthConfig(registryHostname)
+	if err != nil {
+		return "", err
+	}
+	return encodeAuthToBase64(registry.AuthConfig(a))
 }
 
 func isDockerUnauthorizedError(errMsg string) bool {
@@ -246,60 +449,78 @@ func isDockerUnauthorizedError(errMsg string) bool {
 }
 
 // PullImage pulls docker image.
-func pullImage(ctx context.Context, client *client.Client, image string) {
+func pullImage(ctx context.Context, client *client.Client, image string) (err error) {
 	reader, err := client.ImagePull(ctx, image, types.ImagePullOptions{})
 	if err != nil && isDockerUnauthorizedError(err.Error()) {
-		cfg, err := cliconfig.Load("")
+		encodedAuth, err := getRegistryAuth(image)
 		if err != nil {
-			log.Fatal(err)
-		}
-		registryHostname := strings.Split(image, "/")[0]
-		a, err := cfg.GetAuthConfig(registryHostname)
-		if err != nil {
-			log.Fatal("can't load the auth config", err)
-		}
-		encodedAuth, err := encodeAuthToBase64(registry.AuthConfig(a))
-		if err != nil {
-			log.Fatal("can't encode auth to base64", err)
+			return fmt.Errorf("can't load the auth config: %w", err)
 		}
 		reader, err = client.ImagePull(ctx, image, types.ImagePullOptions{RegistryAuth: encodedAuth})
 		if err != nil {
-			log.Fatal("can't pull image from the private registry", err)
+			return fmt.Errorf("can't pull image %s from the private registry: %w", image, err)
 		}
 	} else if err != nil {
-		log.Fatal("can't pull image ", err)
+		return fmt.Errorf("can't pull image %s: %w", image, err)
 	}
 	defer func(pull io.ReadCloser) {
-		err := pull.Close()
-		if err != nil {
-			log.Fatal("can't pull image ", err)
+		if closeErr := pull.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("can't pull image %s: %w", image, closeErr)
 		}
 	}(reader)
 	if _, err = io.Copy(io.Discard, reader); err != nil {
-		log.Fatal("couldn't read the image pull logs ", err)
+		return fmt.Errorf("couldn't read the image pull logs: %w", err)
 	}
+	return nil
 }
 
 // ContainerCleanup cleans up Qodana containers.
 func ContainerCleanup() {
 	if containerName != "qodana-cli" { // if containerName is not set, it means that the container was not created!
 		docker := getContainerClient()
-		ctx := context.Background()
-		containers, err := docker.ContainerList(ctx, container.ListOptions{})
+		ctx, cancel := context.WithTimeout(context.Background(), containerCleanupTimeout)
+		containers, err := docker.ContainerList(ctx, container.ListOptions{
+			Filters: filters.NewArgs(filters.Arg("label", containerNameLabel+"="+containerName)),
+		})
+		cancel()
 		if err != nil {
-			log.Fatal("couldn't get the running containers ", err)
+			platform.ErrorMessage("Couldn't get the running containers: %s", err)
+			return
 		}
 		for _, c := range containers {
-			if c.Names[0] == fmt.Sprintf("/%s", containerName) {
-				err = docker.ContainerStop(context.Background(), c.Names[0], container.StopOptions{})
-				if err != nil {
-					log.Fatal("couldn't stop the container ", err)
-				}
-			}
+			stopContainer(docker, c.ID)
 		}
 	}
 }
 
+// stopContainer stops the container with a bounded timeout, the container is force-removed if it can't be stopped.
+func stopContainer(docker *client.Client, id string) {
+	ctx, cancel := context.WithTimeout(context.Background(), containerCleanupTimeout)
+	defer cancel()
+	timeout := containerStopTimeoutSeconds
+	err := docker.ContainerStop(ctx, id, container.StopOptions{Timeout: &timeout})
+	if err == nil {
+		platform.WarningMessage("Stopped container %s", shortContainerId(id))
+		return
+	}
+	platform.WarningMessage("Couldn't stop container %s: %s, removing it", shortContainerId(id), err)
+	ctx, cancel = context.WithTimeout(context.Background(), containerCleanupTimeout)
+	defer cancel()
+	if err = docker.ContainerRemove(ctx, id, container.RemoveOptions{Force: true}); err != nil {
+		platform.ErrorMessage("Couldn't remove container %s: %s", shortContainerId(id), err)
+		return
+	}
+	platform.WarningMessage("Removed container %s", shortContainerId(id))
+}
+
+// shortContainerId returns the short form of the container id, as printed by docker ps.
+func shortContainerId(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
 // CheckContainerEngineMemory applicable only for Docker Desktop,
 // (has the default limit of 2GB which can be not enough when Gradle runs inside a container).
 func CheckContainerEngineMemory() {
@@ -332,10 +553,101 @@ func CheckContainerEngineMemory() {
 	}
 }
 
+// outputRootMounts returns the mounts of the --output-root report and coverage directories: unlike the defaults
+// they are not inside the results directory, so the container has to get them separately.
+func outputRootMounts(opts *QodanaOptions) []mount.Mount {
+	if opts.OutputRoot == "" {
+		return nil
+	}
+	var mounts []mount.Mount
+	resultsPath, _ := filepath.Abs(opts.ResultsDir)
+	if reportPath, err := filepath.Abs(opts.ReportDir); err == nil && reportPath != filepath.Join(resultsPath, "report") {
+		mounts = append(mounts, mount.Mount{Type: mount.TypeBind, Source: reportPath, Target: "/data/results/report"})
+	}
+	if opts.CoverageDir == "" {
+		if coveragePath, err := filepath.Abs(filepath.Join(opts.OutputRoot, "coverage")); err == nil {
+			mounts = append(mounts, mount.Mount{Type: mount.TypeBind, Source: coveragePath, Target: "/data/coverage"})
+		}
+	}
+	return mounts
+}
+
+// containerPreflight checks that the directories mounted to the container exist and the container user can write to them,
+// and returns the problems found. The ownership is checked only on Linux: Docker Desktop maps it for the mounted directories.
+func containerPreflight(opts *QodanaOptions) []string {
+	var problems []string
+	uid, gid, ok := platform.ParseContainerUser(opts.User)
+	//goland:noinspection GoBoolExpressions
+	checkOwnership := ok && runtime.GOOS == "linux"
+	dirs := []struct {
+		name     string
+		path     string
+		writable bool
+	}{
+		{"cache", opts.CacheDir, true},
+		{"project", opts.ProjectDir, !opts.ProjectMountReadOnly()},
+		{"results", opts.ResultsDir, true},
+	}
+	for _, dir := range dirs {
+		info, err := os.Stat(dir.path)
+		if err != nil || !info.IsDir() {
+			problems = append(problems, fmt.Sprintf("The %s dir %s does not exist", dir.name, dir.path))
+			continue
+		}
+		if !dir.writable || !checkOwnership || dirWritableByContainerUser(info, uid, gid, opts.ContainerGroupAdd) {
+			continue
+		}
+		problems = append(problems, fmt.Sprintf(
+			"The %s dir %s is not writable by uid %d (gid %d) the container runs as: change its permissions or the user with --user",
+			dir.name,
+			dir.path,
+			uid,
+			gid,
+		))
+	}
+	return problems
+}
+
+// dirWritableByContainerUser checks the directory permissions for the container user and its supplementary groups (--container-group-add).
+func dirWritableByContainerUser(info os.FileInfo, uid int, gid int, groups []string) bool {
+	if writable, ok := platform.DirWritableBy(info, uid, gid); writable || !ok {
+		return true
+	}
+	for _, group := range groups {
+		if groupId, err := strconv.Atoi(group); err == nil {
+			if writable, _ := platform.DirWritableBy(info, uid, groupId); writable {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// gitMetadataWarning returns a warning when the run needs the git metadata in the container (diff and full history runs),
+// but the project directory has no .git and no git directory is given with --mount-git.
+func gitMetadataWarning(opts *QodanaOptions) string {
+	if opts.MountGit != "" {
+		return ""
+	}
+	if opts.DiffStart == "" && opts.Commit == "" && !opts.FullHistory && !opts.ForceLocalChangesScript {
+		return ""
+	}
+	if _, err := os.Stat(filepath.Join(opts.ProjectDir, ".git")); err == nil {
+		return ""
+	}
+	return fmt.Sprintf(
+		"%s has no .git, but the diff run needs the git metadata in the container. Mount the git directory with --mount-git <path>",
+		opts.ProjectDir,
+	)
+}
+
 // getDockerOptions returns qodana docker container options.
 func getDockerOptions(opts *QodanaOptions) *backend.ContainerCreateConfig {
 	cmdOpts := GetIdeArgs(opts)
 	platform.ExtractQodanaEnvironment(opts.Setenv)
+	if !opts.NoProxyPassthrough {
+		platform.ExtractProxyEnvironment(opts.Setenv)
+	}
 	cachePath, err := filepath.Abs(opts.CacheDir)
 	if err != nil {
 		log.Fatal("couldn't get abs path for cache", err)
@@ -352,6 +664,7 @@ func getDockerOptions(opts *QodanaOptions) *backend.ContainerCreateConfig {
 	if containerName == "" {
 		containerName = fmt.Sprintf("qodana-cli-%s", opts.Id())
 	}
+	containerName = opts.ContainerNamePrefix + containerName
 	volumes := []mount.Mount{
 		{
 			Type:   mount.TypeBind,
@@ -359,9 +672,10 @@ func getDockerOptions(opts *QodanaOptions) *backend.ContainerCreateConfig {
 			Target: "/data/cache",
 		},
 		{
-			Type:   mount.TypeBind,
-			Source: projectPath,
-			Target: "/data/project",
+			Type:     mount.TypeBind,
+			Source:   projectPath,
+			Target:   "/data/project",
+			ReadOnly: opts.ProjectMountReadOnly(),
 		},
 		{
 			Type:   mount.TypeBind,
@@ -370,16 +684,64 @@ func getDockerOptions(opts *QodanaOptions) *backend.ContainerCreateConfig {
 		},
 	}
 	for _, volume := range opts.Volumes {
-		source, target := extractDockerVolumes(volume)
-		if source != "" && target != "" {
-			volumes = append(volumes, mount.Mount{
-				Type:   mount.TypeBind,
-				Source: source,
-				Target: target,
-			})
-		} else {
-			log.Fatal("couldn't parse volume ", volume)
+		source, target, readOnly, err := extractDockerVolumes(volume)
+		if err != nil {
+			log.Fatal(err)
+		}
+		volumes = append(volumes, mount.Mount{
+			Type:     mount.TypeBind,
+			Source:   source,
+			Target:   target,
+			ReadOnly: readOnly,
+		})
+	}
+	volumes = append(volumes, outputRootMounts(opts)...)
+	if opts.ConfigOverride != "" {
+		configOverridePath, err := filepath.Abs(opts.ConfigOverride)
+		if err != nil {
+			log.Fatal("couldn't get abs path for config override", err)
+		}
+		volumes = append(volumes, mount.Mount{
+			Type:     mount.TypeBind,
+			Source:   configOverridePath,
+			Target:   containerConfigOverride,
+			ReadOnly: true,
+		})
+	}
+	if opts.MountGit != "" {
+		gitPath, err := filepath.Abs(opts.MountGit)
+		if err != nil {
+			log.Fatal("couldn't get abs path for git directory", err)
 		}
+		volumes = append(volumes, mount.Mount{
+			Type:   mount.TypeBind,
+			Source: gitPath,
+			Target: "/data/project/.git",
+		})
+	}
+	for i, plugin := range opts.PluginsFromFiles {
+		pluginPath, err := filepath.Abs(plugin)
+		if err != nil {
+			log.Fatal("couldn't get abs path for plugin", err)
+		}
+		volumes = append(volumes, mount.Mount{
+			Type:     mount.TypeBind,
+			Source:   pluginPath,
+			Target:   containerPluginPath(i, plugin),
+			ReadOnly: true,
+		})
+	}
+	for i, cert := range opts.CaCerts {
+		certPath, err := filepath.Abs(cert)
+		if err != nil {
+			log.Fatal("couldn't get abs path for CA certificate", err)
+		}
+		volumes = append(volumes, mount.Mount{
+			Type:     mount.TypeBind,
+			Source:   certPath,
+			Target:   containerCaCertPath(i, cert),
+			ReadOnly: true,
+		})
 	}
 	log.Debugf("image: %s", opts.Linter)
 	log.Debugf("container name: %s", containerName)
@@ -420,19 +782,44 @@ func getDockerOptions(opts *QodanaOptions) *backend.ContainerCreateConfig {
 			PortBindings: portBindings,
 		}
 	}
+	if opts.ContainerPrivileged {
+		platform.WarningMessageCI(
+			"The Qodana container is started in privileged mode: it gets full access to the host devices and all kernel capabilities. Use %s only for debugging and never with untrusted images or projects",
+			"--container-privileged",
+		)
+		hostConfig.Privileged = true
+	}
+	hostConfig.GroupAdd = opts.ContainerGroupAdd
+
+	labels, err := platform.ParseContainerLabels(opts.ContainerLabels)
+	if err != nil {
+		log.Fatal(err)
+	}
+	labels[containerNameLabel] = containerName
+
+	config := &container.Config{
+		Image:        opts.Linter,
+		Cmd:          cmdOpts,
+		Tty:          platform.IsInteractive(),
+		AttachStdout: true,
+		AttachStderr: true,
+		Env:          opts.Env,
+		User:         opts.User,
+		ExposedPorts: exposedPorts,
+		Labels:       labels,
+	}
+	if command := strings.Fields(opts.Exec); len(command) > 0 { // the analysis command is replaced with the interactive one
+		config.Entrypoint = command[:1]
+		config.Cmd = command[1:]
+		config.Tty = true
+		config.OpenStdin = true
+		config.AttachStdin = true
+		hostConfig.AutoRemove = false
+	}
 
 	return &backend.ContainerCreateConfig{
-		Name: containerName,
-		Config: &container.Config{
-			Image:        opts.Linter,
-			Cmd:          cmdOpts,
-			Tty:          platform.IsInteractive(),
-			AttachStdout: true,
-			AttachStderr: true,
-			Env:          opts.Env,
-			User:         opts.User,
-			ExposedPorts: exposedPorts,
-		},
+		Name:       containerName,
+		Config:     config,
 		HostConfig: hostConfig,
 	}
 }
@@ -462,7 +849,11 @@ func generateDebugDockerRunCommand(cfg *backend.ContainerCreateConfig) string {
 	}
 	if cfg.HostConfig != nil {
 		for _, m := range cfg.HostConfig.Mounts {
-			cmdBuilder.WriteString(fmt.Sprintf("-v %s:%s ", m.Source, m.Target))
+			if m.ReadOnly {
+				cmdBuilder.WriteString(fmt.Sprintf("-v %s:%s:ro ", m.Source, m.Target))
+			} else {
+				cmdBuilder.WriteString(fmt.Sprintf("-v %s:%s ", m.Source, m.Target))
+			}
 		}
 		for _, capAdd := range cfg.HostConfig.CapAdd {
 			cmdBuilder.WriteString(fmt.Sprintf("--cap-add %s ", capAdd))
@@ -470,6 +861,23 @@ func generateDebugDockerRunCommand(cfg *backend.ContainerCreateConfig) string {
 		for _, secOpt := range cfg.HostConfig.SecurityOpt {
 			cmdBuilder.WriteString(fmt.Sprintf("--security-opt %s ", secOpt))
 		}
+		if cfg.HostConfig.Privileged {
+			cmdBuilder.WriteString("--privileged ")
+		}
+		for _, group := range cfg.HostConfig.GroupAdd {
+			cmdBuilder.WriteString(fmt.Sprintf("--group-add %s ", group))
+		}
+	}
+	labels := make([]string, 0, len(cfg.Config.Labels))
+	for key, value := range cfg.Config.Labels {
+		labels = append(labels, key+"="+value)
+	}
+	sort.Strings(labels)
+	for _, label := range labels {
+		cmdBuilder.WriteString(fmt.Sprintf("--label %s ", platform.QuoteIfSpace(label)))
+	}
+	if len(cfg.Config.Entrypoint) > 0 {
+		cmdBuilder.WriteString(fmt.Sprintf("--entrypoint %s ", cfg.Config.Entrypoint[0]))
 	}
 	cmdBuilder.WriteString(cfg.Config.Image + " ")
 	for _, arg := range cfg.Config.Cmd {
@@ -479,11 +887,28 @@ func generateDebugDockerRunCommand(cfg *backend.ContainerCreateConfig) string {
 	return cmdBuilder.String()
 }
 
+// errStartupTimeout is the cause of the container wait cancellation when the analysis hasn't started in --startup-timeout.
+var errStartupTimeout = errors.New("analysis startup timeout")
+
 // getContainerExitCode returns the exit code of the docker container.
 func getContainerExitCode(ctx context.Context, client *client.Client, id string) int64 {
 	statusCh, errCh := client.ContainerWait(ctx, id, container.WaitConditionNextExit)
 	select {
 	case err := <-errCh:
+		if errors.Is(context.Cause(ctx), errStartupTimeout) {
+			log.Printf("Analysis hasn't started in time, stopping container %s", id)
+			if err := client.ContainerStop(context.Background(), id, container.StopOptions{}); err != nil {
+				log.Errorf("Failed to stop container %s: %s", id, err)
+			}
+			return platform.QodanaStartupTimeoutExitCodePlaceholder
+		}
+		if errors.Is(err, context.DeadlineExceeded) {
+			log.Printf("Analysis timeout reached, stopping container %s", id)
+			if err := client.ContainerStop(context.Background(), id, container.StopOptions{}); err != nil {
+				log.Errorf("Failed to stop container %s: %s", id, err)
+			}
+			return platform.QodanaTimeoutExitCodePlaceholder
+		}
 		if err != nil {
 			log.Fatal("container hasn't finished ", err)
 		}
@@ -520,14 +945,30 @@ func getContainerClient() *client.Client {
 	return docker
 }
 
-// extractDockerVolumes extracts the source and target of the volume to mount.
-func extractDockerVolumes(volume string) (string, string) {
+// extractDockerVolumes extracts the source, the target and the read-only mode of the volume to mount.
+func extractDockerVolumes(volume string) (string, string, bool, error) {
+	//goland:noinspection GoBoolExpressions
+	return parseDockerVolume(volume, runtime.GOOS == "windows")
+}
+
+// parseDockerVolume parses the src:dst[:ro|rw] volume, the src can start with a Windows drive letter (C:\...).
+func parseDockerVolume(volume string, windows bool) (string, string, bool, error) {
 	split := strings.Split(volume, ":")
-	if len(split) == 2 {
-		return split[0], split[1]
-	} else if //goland:noinspection GoBoolExpressions
-	runtime.GOOS == "windows" {
-		return fmt.Sprintf("%s:%s", split[0], split[1]), split[2]
+	if windows && len(split) > 2 && len(split[0]) == 1 {
+		split = append([]string{fmt.Sprintf("%s:%s", split[0], split[1])}, split[2:]...)
+	}
+	if len(split) < 2 || len(split) > 3 || split[0] == "" || split[1] == "" {
+		return "", "", false, fmt.Errorf("couldn't parse volume %s, expected src:dst[:ro|rw]", volume)
+	}
+	readOnly := false
+	if len(split) == 3 {
+		switch split[2] {
+		case "ro":
+			readOnly = true
+		case "rw":
+		default:
+			return "", "", false, fmt.Errorf("unknown mode %q of volume %s, expected ro or rw", split[2], volume)
+		}
 	}
-	return "", ""
+	return split[0], split[1], readOnly, nil
 }
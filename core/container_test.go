@@ -1,9 +1,16 @@
 package core
 
 import (
+	"bytes"
 	"fmt"
 	"github.com/JetBrains/qodana-cli/v2024/platform"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/pterm/pterm"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestImageChecks(t *testing.T) {
@@ -43,6 +50,18 @@ func TestImageChecks(t *testing.T) {
 			true,
 			true,
 		},
+		{
+			"jetbrains/qodana@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef",
+			false,
+			true,
+			false,
+		},
+		{
+			"registry.local:5000/jetbrains/qodana",
+			true,
+			false,
+			false,
+		},
 	}
 	for _, tc := range testCases {
 		t.Run(tc.linter, func(t *testing.T) {
@@ -58,3 +77,341 @@ func TestImageChecks(t *testing.T) {
 		})
 	}
 }
+
+func TestDockerOptionsPrivileged(t *testing.T) {
+	var out bytes.Buffer
+	pterm.SetDefaultOutput(&out)
+	t.Cleanup(func() {
+		pterm.SetDefaultOutput(os.Stdout)
+	})
+	dir := t.TempDir()
+	opts := &QodanaOptions{&platform.QodanaOptions{
+		ProjectDir: filepath.Join(dir, "project"),
+		CacheDir:   filepath.Join(dir, "cache"),
+		ResultsDir: filepath.Join(dir, "results"),
+		Linter:     "jetbrains/qodana-jvm",
+	}}
+
+	dockerOptions := getDockerOptions(opts)
+	assert.False(t, dockerOptions.HostConfig.Privileged)
+	assert.NotContains(t, out.String(), "privileged mode")
+
+	opts.ContainerPrivileged = true
+	dockerOptions = getDockerOptions(opts)
+	assert.True(t, dockerOptions.HostConfig.Privileged)
+	assert.Contains(t, out.String(), "privileged mode")
+	assert.Contains(t, generateDebugDockerRunCommand(dockerOptions), "--privileged ")
+}
+
+func TestDockerOptionsGroupAdd(t *testing.T) {
+	dir := t.TempDir()
+	opts := &QodanaOptions{&platform.QodanaOptions{
+		ProjectDir: filepath.Join(dir, "project"),
+		CacheDir:   filepath.Join(dir, "cache"),
+		ResultsDir: filepath.Join(dir, "results"),
+		Linter:     "jetbrains/qodana-jvm",
+	}}
+
+	dockerOptions := getDockerOptions(opts)
+	assert.Empty(t, dockerOptions.HostConfig.GroupAdd)
+
+	opts.ContainerGroupAdd = []string{"1001", "docker"}
+	dockerOptions = getDockerOptions(opts)
+	assert.Equal(t, []string{"1001", "docker"}, dockerOptions.HostConfig.GroupAdd)
+	assert.Contains(t, generateDebugDockerRunCommand(dockerOptions), "--group-add 1001 --group-add docker ")
+}
+
+func TestDockerOptionsUser(t *testing.T) {
+	dir := t.TempDir()
+	opts := &QodanaOptions{&platform.QodanaOptions{
+		ProjectDir: filepath.Join(dir, "project"),
+		CacheDir:   filepath.Join(dir, "cache"),
+		ResultsDir: filepath.Join(dir, "results"),
+		Linter:     "jetbrains/qodana-jvm",
+	}}
+
+	// --user defaults to platform.GetDefaultUser(), an explicit empty value leaves the image user
+	for _, user := range []string{platform.GetDefaultUser(), "root", "1001:1001", ""} {
+		opts.User = user
+		assert.Equal(t, user, getDockerOptions(opts).Config.User)
+	}
+}
+
+func TestDockerOptionsPluginsFromFiles(t *testing.T) {
+	dir := t.TempDir()
+	opts := &QodanaOptions{&platform.QodanaOptions{
+		ProjectDir:       filepath.Join(dir, "project"),
+		CacheDir:         filepath.Join(dir, "cache"),
+		ResultsDir:       filepath.Join(dir, "results"),
+		Linter:           "jetbrains/qodana-jvm",
+		PluginsFromFiles: []string{filepath.Join(dir, "a", "plugin.zip"), filepath.Join(dir, "b", "plugin.zip")},
+	}}
+	dockerOptions := getDockerOptions(opts)
+	for i, plugin := range opts.PluginsFromFiles {
+		target := fmt.Sprintf("/data/plugins/%d/plugin.zip", i)
+		assert.Contains(t, dockerOptions.HostConfig.Mounts, mount.Mount{Type: mount.TypeBind, Source: plugin, Target: target, ReadOnly: true})
+		assert.Contains(t, dockerOptions.Config.Cmd, target)
+	}
+}
+
+func TestDockerOptionsLabels(t *testing.T) {
+	dir := t.TempDir()
+	opts := &QodanaOptions{&platform.QodanaOptions{
+		ProjectDir:          filepath.Join(dir, "project"),
+		CacheDir:            filepath.Join(dir, "cache"),
+		ResultsDir:          filepath.Join(dir, "results"),
+		Linter:              "jetbrains/qodana-jvm",
+		ContainerLabels:     []string{"ci.job=42", "team=backend"},
+		ContainerNamePrefix: "ci-42-",
+	}}
+	t.Setenv(platform.QodanaCliContainerName, "qodana")
+
+	dockerOptions := getDockerOptions(opts)
+	assert.Equal(t, "ci-42-qodana", dockerOptions.Name)
+	assert.Equal(t, map[string]string{"ci.job": "42", "team": "backend", containerNameLabel: "ci-42-qodana"}, dockerOptions.Config.Labels)
+	assert.Contains(t, generateDebugDockerRunCommand(dockerOptions), "--label ci.job=42 --label com.jetbrains.qodana.cli.container=ci-42-qodana --label team=backend ")
+}
+
+func TestDockerOptionsProxyEnvironment(t *testing.T) {
+	dir := t.TempDir()
+	newOpts := func() *QodanaOptions {
+		return &QodanaOptions{&platform.QodanaOptions{
+			ProjectDir: filepath.Join(dir, "project"),
+			CacheDir:   filepath.Join(dir, "cache"),
+			ResultsDir: filepath.Join(dir, "results"),
+			Linter:     "jetbrains/qodana-jvm",
+			Env:        []string{"NO_PROXY=internal.example.com"},
+		}}
+	}
+	t.Setenv("HTTPS_PROXY", "http://proxy.example.com:3128")
+	t.Setenv("NO_PROXY", "localhost")
+
+	env := getDockerOptions(newOpts()).Config.Env
+	assert.Contains(t, env, "HTTPS_PROXY=http://proxy.example.com:3128")
+	assert.Contains(t, env, "NO_PROXY=internal.example.com", "--env takes precedence")
+	assert.NotContains(t, env, "NO_PROXY=localhost")
+
+	opts := newOpts()
+	opts.NoProxyPassthrough = true
+	assert.NotContains(t, getDockerOptions(opts).Config.Env, "HTTPS_PROXY=http://proxy.example.com:3128")
+}
+
+func TestDockerOptionsProjectReadOnly(t *testing.T) {
+	dir := t.TempDir()
+	for _, tc := range []struct {
+		name                   string
+		projectReadOnly        bool
+		projectReadOnlyNoFixes bool
+		applyFixes             bool
+		cleanup                bool
+		readOnly               bool
+	}{
+		{"read-only project", true, false, false, false, true},
+		{"writable project", false, false, false, false, false},
+		{"deprecated flag, analysis", false, true, false, false, true},
+		{"deprecated flag, apply fixes", false, true, true, false, false},
+		{"deprecated flag, cleanup", false, true, false, true, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := &QodanaOptions{&platform.QodanaOptions{
+				ProjectDir:             filepath.Join(dir, "project"),
+				CacheDir:               filepath.Join(dir, "cache"),
+				ResultsDir:             filepath.Join(dir, "results"),
+				Linter:                 "jetbrains/qodana-jvm",
+				ProjectReadOnly:        tc.projectReadOnly,
+				ProjectReadOnlyNoFixes: tc.projectReadOnlyNoFixes,
+				ApplyFixes:             tc.applyFixes,
+				Cleanup:                tc.cleanup,
+			}}
+			dockerOptions := getDockerOptions(opts)
+			var projectMount *mount.Mount
+			for i, m := range dockerOptions.HostConfig.Mounts {
+				if m.Target == "/data/project" {
+					projectMount = &dockerOptions.HostConfig.Mounts[i]
+				}
+			}
+			if assert.NotNil(t, projectMount) {
+				assert.Equal(t, tc.readOnly, projectMount.ReadOnly)
+			}
+			for _, m := range dockerOptions.HostConfig.Mounts {
+				if m.Target == "/data/cache" || m.Target == "/data/results" {
+					assert.False(t, m.ReadOnly, "%s must stay writable", m.Target)
+				}
+			}
+		})
+	}
+}
+
+func TestCopyShortSarif(t *testing.T) {
+	resultsDir := t.TempDir()
+	targetDir := t.TempDir()
+	target := filepath.Join(targetDir, platform.QodanaShortSarifName)
+
+	lastModified, err := copyShortSarif(resultsDir, targetDir, time.Time{})
+	assert.NoError(t, err)
+	assert.True(t, lastModified.IsZero())
+	assert.NoFileExists(t, target)
+
+	report := `{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "QDJVM"}}, "results": [{"ruleId": "ConstantValue", "message": {"text": "Condition is always true"}}]}]}`
+	err = os.WriteFile(filepath.Join(resultsDir, platform.QodanaSarifName), []byte(report), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lastModified, err = copyShortSarif(resultsDir, targetDir, lastModified)
+	assert.NoError(t, err)
+	assert.False(t, lastModified.IsZero())
+	short, err := platform.ReadReport(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, short.Runs[0].Results)
+
+	partial := `{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "partial"}}, "results": []}]}`
+	err = os.WriteFile(filepath.Join(resultsDir, platform.QodanaShortSarifName), []byte(partial), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	future := lastModified.Add(time.Minute)
+	if err = os.Chtimes(filepath.Join(resultsDir, platform.QodanaShortSarifName), future, future); err != nil {
+		t.Fatal(err)
+	}
+	lastModified, err = copyShortSarif(resultsDir, targetDir, lastModified)
+	assert.NoError(t, err)
+	assert.Equal(t, future.Unix(), lastModified.Unix())
+	copied, err := os.ReadFile(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, partial, string(copied))
+}
+
+func TestDockerOptionsExec(t *testing.T) {
+	dir := t.TempDir()
+	opts := &QodanaOptions{&platform.QodanaOptions{
+		ProjectDir: filepath.Join(dir, "project"),
+		CacheDir:   filepath.Join(dir, "cache"),
+		ResultsDir: filepath.Join(dir, "results"),
+		Linter:     "jetbrains/qodana-jvm",
+		Exec:       "bash -l",
+	}}
+
+	dockerOptions := getDockerOptions(opts)
+	assert.Equal(t, []string{"bash"}, []string(dockerOptions.Config.Entrypoint))
+	assert.Equal(t, []string{"-l"}, []string(dockerOptions.Config.Cmd))
+	assert.True(t, dockerOptions.Config.Tty)
+	assert.True(t, dockerOptions.Config.OpenStdin)
+	assert.False(t, dockerOptions.HostConfig.AutoRemove)
+	assert.Len(t, dockerOptions.HostConfig.Mounts, 3)
+
+	command := generateDebugDockerRunCommand(dockerOptions)
+	assert.Contains(t, command, "-it ")
+	assert.Contains(t, command, "--entrypoint bash jetbrains/qodana-jvm -l")
+}
+
+func TestHasRepoDigest(t *testing.T) {
+	digest := "sha256:5b0cd8f4b3f1b1b1c5e1d3a4b5c6d7e8f9a0b1c2d3e4f5a6b7c8d9e0f1a2b3c4"
+	repoDigests := []string{"jetbrains/qodana-jvm@" + digest}
+	for _, tc := range []struct {
+		name        string
+		repoDigests []string
+		digest      string
+		expected    bool
+	}{
+		{"same digest", repoDigests, digest, true},
+		{"updated image", repoDigests, "sha256:0000000000000000000000000000000000000000000000000000000000000000", false},
+		{"no local digests", nil, digest, false},
+		{"no remote digest", repoDigests, "", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := hasRepoDigest(tc.repoDigests, tc.digest); actual != tc.expected {
+				t.Errorf("expected %t, got %t", tc.expected, actual)
+			}
+		})
+	}
+}
+
+func TestParseDockerVolume(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		volume   string
+		windows  bool
+		source   string
+		target   string
+		readOnly bool
+		err      bool
+	}{
+		{"bind", "/a:/b", false, "/a", "/b", false, false},
+		{"read-only", "/a:/b:ro", false, "/a", "/b", true, false},
+		{"read-write", "/a:/b:rw", false, "/a", "/b", false, false},
+		{"unknown mode", "/a:/b:z", false, "", "", false, true},
+		{"no target", "/a", false, "", "", false, true},
+		{"empty source", ":/b", false, "", "", false, true},
+		{"windows", `C:\a:/b`, true, `C:\a`, "/b", false, false},
+		{"windows read-only", `C:\a:/b:ro`, true, `C:\a`, "/b", true, false},
+		{"windows unknown mode", `C:\a:/b:rx`, true, "", "", false, true},
+		{"drive letter on unix", `C:\a:/b`, false, "", "", false, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			source, target, readOnly, err := parseDockerVolume(tc.volume, tc.windows)
+			if tc.err {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.source, source)
+			assert.Equal(t, tc.target, target)
+			assert.Equal(t, tc.readOnly, readOnly)
+		})
+	}
+}
+
+func TestDockerOptionsReadOnlyVolume(t *testing.T) {
+	opts := &QodanaOptions{&platform.QodanaOptions{Volumes: []string{"/tmp/foo:/data/foo:ro"}}}
+	dockerOptions := getDockerOptions(opts)
+	assert.Contains(t, dockerOptions.HostConfig.Mounts, mount.Mount{Type: mount.TypeBind, Source: "/tmp/foo", Target: "/data/foo", ReadOnly: true})
+}
+
+func TestGitMetadataWarning(t *testing.T) {
+	dir := t.TempDir()
+	opts := &QodanaOptions{&platform.QodanaOptions{ProjectDir: dir}}
+	assert.Empty(t, gitMetadataWarning(opts), "a full analysis doesn't need git metadata")
+
+	opts.DiffStart = "HEAD~1"
+	assert.Contains(t, gitMetadataWarning(opts), "--mount-git")
+
+	opts.MountGit = filepath.Join(t.TempDir(), ".git")
+	assert.Empty(t, gitMetadataWarning(opts))
+	dockerOptions := getDockerOptions(opts)
+	assert.Contains(t, dockerOptions.HostConfig.Mounts, mount.Mount{Type: mount.TypeBind, Source: opts.MountGit, Target: "/data/project/.git"})
+
+	opts.MountGit = ""
+	if err := os.Mkdir(filepath.Join(dir, ".git"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, gitMetadataWarning(opts))
+}
+
+func TestContainerPreflight(t *testing.T) {
+	dir := t.TempDir()
+	opts := &QodanaOptions{&platform.QodanaOptions{
+		ProjectDir: dir,
+		CacheDir:   dir,
+		ResultsDir: filepath.Join(dir, "results"),
+		User:       "root",
+	}}
+	assert.Equal(t, []string{fmt.Sprintf("The results dir %s does not exist", opts.ResultsDir)}, containerPreflight(opts))
+
+	if err := os.Mkdir(opts.ResultsDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	assert.Empty(t, containerPreflight(opts))
+}
+
+func TestDryRunContainerProperties(t *testing.T) {
+	opts := &QodanaOptions{&platform.QodanaOptions{
+		Linter:   "jetbrains/qodana-jvm:latest",
+		Property: []string{"idea.log.level=DEBUG", "-Xmx4g"},
+		QdConfig: platform.QodanaYaml{Properties: map[string]string{"idea.log.level": "INFO", "qodana.format": "SARIF"}},
+	}}
+	assert.Equal(t, []string{"-Xmx4g", "idea.log.level=DEBUG", "qodana.format=SARIF"}, dryRunProperties(opts))
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/JetBrains/qodana-cli/v2024/platform"
 	"sort"
 	"strings"
+	"time"
 )
 
 // various variables for parsing git log output.
@@ -39,7 +40,11 @@ var (
 	)
 )
 
-const qodanaBotEmail = "qodana-support@jetbrains.com"
+const (
+	qodanaBotEmail = "qodana-support@jetbrains.com"
+	// gitDateLayout is the layout of the %ai author date.
+	gitDateLayout = "2006-01-02 15:04:05 -0700"
+)
 
 // author struct represents a git commit author.
 type author struct {
@@ -75,13 +80,158 @@ type contributor struct {
 	Commits  []commit `json:"commits"`
 }
 
-// ToJSON returns the JSON representation of the list of contributors.
-func ToJSON(contributors []contributor) (string, error) {
-	output := map[string]interface{}{
-		"total":        len(contributors),
-		"contributors": contributors,
+// ContributorsPeriod is the period the contributors are counted for: the last Days days
+// or the Since and Until dates (YYYY-MM-DD), the empty values don't limit the period.
+type ContributorsPeriod struct {
+	Days  int    `json:"days,omitempty"`
+	Since string `json:"since,omitempty"`
+	Until string `json:"until,omitempty"`
+}
+
+// contributorsDateLayout is the layout of the --since and --until dates.
+const contributorsDateLayout = "2006-01-02"
+
+// Validate checks the Since and Until dates.
+func (p ContributorsPeriod) Validate() error {
+	var since, until time.Time
+	var err error
+	if p.Since != "" {
+		if since, err = time.Parse(contributorsDateLayout, p.Since); err != nil {
+			return fmt.Errorf("invalid --since date %q, expected YYYY-MM-DD", p.Since)
+		}
+	}
+	if p.Until != "" {
+		if until, err = time.Parse(contributorsDateLayout, p.Until); err != nil {
+			return fmt.Errorf("invalid --until date %q, expected YYYY-MM-DD", p.Until)
+		}
+	}
+	if p.Since != "" && p.Until != "" && until.Before(since) {
+		return fmt.Errorf("--until %s is before --since %s", p.Until, p.Since)
+	}
+	return nil
+}
+
+// String describes the period for the messages.
+func (p ContributorsPeriod) String() string {
+	switch {
+	case p.Since != "" && p.Until != "":
+		return fmt.Sprintf("from %s to %s", p.Since, p.Until)
+	case p.Since != "":
+		return "since " + p.Since
+	case p.Until != "":
+		return "until " + p.Until
+	case p.Days > 0:
+		return fmt.Sprintf("for the last %d days", p.Days)
+	default:
+		return "for the whole history"
+	}
+}
+
+// gitLog returns the git log of the repository for the period.
+func (p ContributorsPeriod) gitLog(repoDir string) []string {
+	if p.Since == "" && p.Until == "" {
+		return platform.GitLog(repoDir, gitFormat, p.Days)
+	}
+	since, until := p.gitDates()
+	return platform.GitLogPeriod(repoDir, gitFormat, since, until)
+}
+
+// gitDates returns the Since and Until dates for git log, both days are included in the period:
+// git takes the current time of the day for a date without time.
+func (p ContributorsPeriod) gitDates() (string, string) {
+	since, until := p.Since, p.Until
+	if since != "" {
+		since += " 00:00:00"
+	}
+	if until != "" {
+		until += " 23:59:59"
+	}
+	return since, until
+}
+
+// ContributorsReport is the JSON output of qodana contributors, the stable schema for the metrics tools.
+type ContributorsReport struct {
+	Total        int                  `json:"total"`
+	Period       ContributorsPeriod   `json:"period"`
+	BotsExcluded bool                 `json:"botsExcluded"`
+	Contributors []ContributorSummary `json:"contributors"`
+}
+
+// ContributorSummary is a contributor in ContributorsReport, the commit dates are in RFC 3339 format.
+type ContributorSummary struct {
+	Username    string   `json:"username"`
+	Email       string   `json:"email"`
+	Bot         bool     `json:"bot"`
+	Commits     int      `json:"commits"`
+	FirstCommit string   `json:"firstCommit"`
+	LastCommit  string   `json:"lastCommit"`
+	Projects    []string `json:"projects"`
+}
+
+// NewContributorsReport returns the report of the contributors (see GetContributors), sorted by the number of commits
+// and then by the email or username, so the same history gives the same output.
+func NewContributorsReport(contributors []contributor, period ContributorsPeriod, excludeBots bool) ContributorsReport {
+	summaries := make([]ContributorSummary, 0, len(contributors))
+	for _, c := range contributors {
+		first, last := commitDatesRange(c.Commits)
+		projects := append([]string{}, c.Projects...)
+		sort.Strings(projects)
+		summaries = append(summaries, ContributorSummary{
+			Username:    c.Author.Username,
+			Email:       c.Author.Email,
+			Bot:         c.Author.isBot(),
+			Commits:     c.Count,
+			FirstCommit: first,
+			LastCommit:  last,
+			Projects:    projects,
+		})
+	}
+	sort.SliceStable(summaries, func(i, j int) bool {
+		if summaries[i].Commits != summaries[j].Commits {
+			return summaries[i].Commits > summaries[j].Commits
+		}
+		return summaryId(summaries[i]) < summaryId(summaries[j])
+	})
+	return ContributorsReport{
+		Total:        len(summaries),
+		Period:       period,
+		BotsExcluded: excludeBots,
+		Contributors: summaries,
+	}
+}
+
+func summaryId(s ContributorSummary) string {
+	if s.Email != "" {
+		return s.Email
+	}
+	return s.Username
+}
+
+// commitDatesRange returns the dates of the first and the last commits in RFC 3339 format,
+// the dates git printed in an unexpected format are skipped.
+func commitDatesRange(commits []commit) (string, string) {
+	var first, last time.Time
+	for _, c := range commits {
+		date, err := time.Parse(gitDateLayout, c.Date)
+		if err != nil {
+			continue
+		}
+		if first.IsZero() || date.Before(first) {
+			first = date
+		}
+		if last.IsZero() || date.After(last) {
+			last = date
+		}
 	}
-	out, err := json.MarshalIndent(output, "", "  ")
+	if first.IsZero() {
+		return "", ""
+	}
+	return first.Format(time.RFC3339), last.Format(time.RFC3339)
+}
+
+// ToJSON returns the indented JSON representation of the report.
+func (r ContributorsReport) ToJSON() (string, error) {
+	out, err := json.MarshalIndent(r, "", "  ")
 	if err != nil {
 		return "", fmt.Errorf("failed to marshal json: %w", err)
 	}
@@ -117,9 +267,14 @@ func parseCommits(gitLogOutput []string, excludeBots bool) []commit {
 
 // GetContributors returns the list of contributors of the git repository.
 func GetContributors(repoDirs []string, days int, excludeBots bool) []contributor {
+	return GetContributorsInPeriod(repoDirs, ContributorsPeriod{Days: days}, excludeBots)
+}
+
+// GetContributorsInPeriod returns the list of contributors of the git repositories for the given period.
+func GetContributorsInPeriod(repoDirs []string, period ContributorsPeriod, excludeBots bool) []contributor {
 	contributorMap := make(map[string]*contributor)
 	for _, repoDir := range repoDirs {
-		gLog := platform.GitLog(repoDir, gitFormat, days)
+		gLog := period.gitLog(repoDir)
 		for _, c := range parseCommits(gLog, excludeBots) {
 			authorId := c.Author.getId()
 			if i, ok := contributorMap[authorId]; ok {
@@ -16,7 +16,10 @@
 
 package core
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestGetContributors(t *testing.T) {
 	contributors := GetContributors([]string{"."}, -1, false)
@@ -63,6 +66,85 @@ func TestParseCommits(t *testing.T) {
 	}
 }
 
+func TestNewContributorsReport(t *testing.T) {
+	contributors := []contributor{
+		{
+			Author:   &author{Email: "bob@example.com", Username: "bob"},
+			Projects: []string{"b", "a"},
+			Count:    1,
+			Commits:  []commit{{Date: "2023-05-05 16:11:38 +0200"}},
+		},
+		{
+			Author:   &author{Email: "49699333+dependabot[bot]@users.noreply.github.com", Username: "dependabot[bot]"},
+			Projects: []string{"a"},
+			Count:    2,
+			Commits:  []commit{{Date: "2023-05-06 10:00:00 +0000"}, {Date: "2023-05-01 10:00:00 +0000"}},
+		},
+		{
+			Author:   &author{Email: "alice@example.com", Username: "alice"},
+			Projects: []string{"a"},
+			Count:    1,
+			Commits:  []commit{{Date: "2023-05-02 09:00:00 +0000"}},
+		},
+	}
+	period := ContributorsPeriod{Since: "2023-05-01"}
+
+	report := NewContributorsReport(contributors, period, false)
+	if report.Total != 3 || report.Period != period {
+		t.Fatalf("Unexpected report header: %+v", report)
+	}
+	var order []string
+	for _, c := range report.Contributors {
+		order = append(order, c.Username)
+	}
+	if strings.Join(order, ",") != "dependabot[bot],alice,bob" {
+		t.Errorf("Unexpected contributors order: %v", order)
+	}
+	bot := report.Contributors[0]
+	if !bot.Bot || bot.FirstCommit != "2023-05-01T10:00:00Z" || bot.LastCommit != "2023-05-06T10:00:00Z" {
+		t.Errorf("Unexpected bot summary: %+v", bot)
+	}
+	if strings.Join(report.Contributors[2].Projects, ",") != "a,b" {
+		t.Errorf("Expected sorted projects, got %v", report.Contributors[2].Projects)
+	}
+
+	first, err := report.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := NewContributorsReport(contributors, period, false).ToJSON()
+	if first != second {
+		t.Error("Expected the same JSON for the same contributors")
+	}
+}
+
+func TestContributorsPeriodValidate(t *testing.T) {
+	for _, tc := range []struct {
+		period ContributorsPeriod
+		valid  bool
+	}{
+		{ContributorsPeriod{Days: 30}, true},
+		{ContributorsPeriod{Since: "2024-01-01", Until: "2024-02-01"}, true},
+		{ContributorsPeriod{Since: "01/01/2024"}, false},
+		{ContributorsPeriod{Since: "2024-02-01", Until: "2024-01-01"}, false},
+	} {
+		if err := tc.period.Validate(); (err == nil) != tc.valid {
+			t.Errorf("%+v: expected valid=%v, got %v", tc.period, tc.valid, err)
+		}
+	}
+}
+
+func TestContributorsPeriodGitDates(t *testing.T) {
+	since, until := ContributorsPeriod{Since: "2024-01-01", Until: "2024-01-31"}.gitDates()
+	if since != "2024-01-01 00:00:00" || until != "2024-01-31 23:59:59" {
+		t.Errorf("Expected the whole days to be included, got %q - %q", since, until)
+	}
+	since, until = ContributorsPeriod{Until: "2024-01-31"}.gitDates()
+	if since != "" || until != "2024-01-31 23:59:59" {
+		t.Errorf("Expected only the until date, got %q - %q", since, until)
+	}
+}
+
 func countContributors(matches func(contributor) bool, contributors []contributor) int {
 	result := 0
 	for _, c := range contributors {
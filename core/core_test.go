@@ -17,11 +17,14 @@
 package core
 
 import (
+	"archive/zip"
+	"context"
 	"errors"
 	"fmt"
 	"github.com/JetBrains/qodana-cli/v2024/cloud"
 	"github.com/JetBrains/qodana-cli/v2024/platform"
 	log "github.com/sirupsen/logrus"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -30,6 +33,7 @@ import (
 	"reflect"
 	"runtime"
 	"sort"
+	"strings"
 	"testing"
 	"time"
 
@@ -65,6 +69,18 @@ func TestCliArgs(t *testing.T) {
 			opts:         &platform.QodanaOptions{ProjectDir: projectDir, CacheDir: cacheDir, ResultsDir: resultsDir, ProfileName: "separated words", Property: []string{"qodana.format=SARIF_AND_PROJECT_STRUCTURE", "qodana.variable.format=JSON"}, Ide: Prod.Home},
 			res:          []string{filepath.FromSlash("/opt/idea/bin/idea.sh"), "inspect", "qodana", "--profile-name", "\"separated words\"", projectDir, resultsDir},
 		},
+		{
+			name:         "extra linter arguments are forwarded to the container",
+			majorVersion: "2024.2",
+			opts:         &platform.QodanaOptions{ProjectDir: projectDir, CacheDir: cacheDir, ResultsDir: resultsDir, ProfileName: "Default", Property: []string{"foo.baz=bar"}, LinterArgs: []string{"-Dfoo=bar", "value with spaces"}},
+			res:          []string{filepath.FromSlash("/opt/idea/bin/idea.sh"), "qodana", "--profile-name", "Default", "--property=foo.baz=bar", "--linter-arg", "-Dfoo=bar", "--linter-arg", "value with spaces", projectDir, resultsDir},
+		},
+		{
+			name:         "extra linter arguments go last for local runs",
+			majorVersion: "2024.2",
+			opts:         &platform.QodanaOptions{ProjectDir: projectDir, CacheDir: cacheDir, ResultsDir: resultsDir, ProfileName: "Default", LinterArgs: []string{"--some-flag", "value with spaces"}, Ide: Prod.Home},
+			res:          []string{filepath.FromSlash("/opt/idea/bin/idea.sh"), "qodana", "--profile-name", "Default", "--some-flag", "\"value with spaces\"", projectDir, resultsDir},
+		},
 		{
 			name:         "deprecated --fixes-strategy=apply",
 			majorVersion: "2024.2",
@@ -121,6 +137,21 @@ func TestScanFlags_Script(t *testing.T) {
 	}
 }
 
+func TestScanFlags_ConfigNameFromEnv(t *testing.T) {
+	projectDir := t.TempDir()
+	t.Setenv(platform.QodanaConfigNameEnv, "qodana.ci.yaml")
+	if err := os.WriteFile(filepath.Join(projectDir, "qodana.ci.yaml"), []byte("ide: QDJVM\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	opts := &QodanaOptions{&platform.QodanaOptions{ProjectDir: projectDir}}
+	opts.FetchAnalyzerSettings()
+
+	args := GetIdeArgs(opts)
+	if len(args) < 2 || args[0] != "--config" || args[1] != "qodana.ci.yaml" {
+		t.Fatalf("expected --config qodana.ci.yaml, got %v", args)
+	}
+}
+
 func TestLegacyFixStrategies(t *testing.T) {
 	cases := []struct {
 		name     string
@@ -482,11 +513,97 @@ func Test_syncIdeaCache(t *testing.T) {
 		}
 	})
 
+	t.Run("PreservesModificationTimes", func(t *testing.T) {
+		srcDir := filepath.Join(tmpDir, "3", ".idea", "dir3")
+		err := os.MkdirAll(srcDir, os.FileMode(0o755))
+		if err != nil {
+			t.Fatal(err)
+		}
+		srcFile := filepath.Join(srcDir, "file3")
+		err = os.WriteFile(srcFile, []byte("test3"), os.FileMode(0o640))
+		if err != nil {
+			t.Fatal(err)
+		}
+		mtime := time.Now().Add(-48 * time.Hour)
+		if err = os.Chtimes(srcFile, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+		if err = os.Chtimes(srcDir, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+
+		err = syncIdeaCache(filepath.Join(tmpDir, "3"), filepath.Join(tmpDir, "4"), true)
+		if err != nil {
+			t.Fatalf("syncIdeaCache failed: %v", err)
+		}
+
+		for src, dst := range map[string]string{
+			srcFile: filepath.Join(tmpDir, "4", ".idea", "dir3", "file3"),
+			srcDir:  filepath.Join(tmpDir, "4", ".idea", "dir3"),
+		} {
+			srcInfo, err := os.Stat(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			dstInfo, err := os.Stat(dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			diff := dstInfo.ModTime().Sub(srcInfo.ModTime())
+			if diff < -time.Second || diff > time.Second {
+				t.Errorf("Modification time of %s not preserved: got %v, expected %v", dst, dstInfo.ModTime(), srcInfo.ModTime())
+			}
+			if dstInfo.Mode().Perm() != srcInfo.Mode().Perm() {
+				t.Errorf("Permissions of %s not preserved: got %v, expected %v", dst, dstInfo.Mode().Perm(), srcInfo.Mode().Perm())
+			}
+		}
+	})
+
 	if err := os.RemoveAll(tmpDir); err != nil {
 		t.Fatal(err)
 	}
 }
 
+func Test_installPluginsFromFiles(t *testing.T) {
+	tmpDir := t.TempDir()
+	home := Prod.Home
+	Prod.Home = filepath.Join(tmpDir, "ide")
+	t.Cleanup(func() {
+		Prod.Home = home
+	})
+
+	plugin := filepath.Join(tmpDir, "custom-inspections.zip")
+	archive, err := os.Create(plugin)
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := zip.NewWriter(archive)
+	jar, err := writer.Create("custom-inspections/lib/custom-inspections.jar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = jar.Write([]byte("jar")); err != nil {
+		t.Fatal(err)
+	}
+	if err = writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err = archive.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &QodanaOptions{&platform.QodanaOptions{Ide: "QDJVM", PluginsFromFiles: []string{plugin}}}
+	installPluginsFromFiles(opts)
+	assert.FileExists(t, filepath.Join(Prod.CustomPluginsPath(), "custom-inspections", "lib", "custom-inspections.jar"))
+
+	notPlugin := filepath.Join(tmpDir, "not-a-plugin.zip")
+	if err = os.WriteFile(notPlugin, []byte("not a zip"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	assert.Error(t, validatePluginArchive(notPlugin))
+	assert.Error(t, validatePluginArchive(filepath.Join(tmpDir, "plugin.jar")))
+}
+
 func Test_Bootstrap(t *testing.T) {
 	opts := &platform.QodanaOptions{}
 	tmpDir := filepath.Join(os.TempDir(), "bootstrap")
@@ -543,6 +660,12 @@ func Test_ideaExitCode(t *testing.T) {
 			sarif:  "{\"runs\": [{\"invocations\": [{\"exitCode\": 0}]}]}",
 			result: 1,
 		},
+		{
+			name:   "idea.sh exited successfully, SARIF has exitCode 70 for internal errors",
+			c:      0,
+			sarif:  "{\"runs\": [{\"invocations\": [{\"exitCode\": 70, \"toolExecutionNotifications\": [{\"level\": \"error\", \"message\": {\"text\": \"Inspection failed\"}}]}]}]}",
+			result: platform.QodanaInternalErrorExitCode,
+		},
 		{
 			name:   "SARIF exitCode too large, gets normalized to 1",
 			c:      0,
@@ -824,8 +947,24 @@ func Test_Properties(t *testing.T) {
 		cliProperties []string
 		qodanaYaml    string
 		isContainer   bool
+		sbomOutput    string
+		sbomFormat    string
+		frameworks    []string
+		noStatistics  bool
 		expected      []string
 	}{
+		{
+			name:          "SBOM output and format",
+			cliProperties: []string{},
+			qodanaYaml:    "",
+			isContainer:   false,
+			sbomOutput:    "sbom.json",
+			sbomFormat:    "SPDX",
+			expected: propertiesFixture(true, []string{
+				fmt.Sprintf("-Dqodana.sbom.output=%s", filepath.Join(os.TempDir(), "entrypoint", "sbom.json")),
+				"-Dqodana.sbom.format=spdx",
+			}),
+		},
 		{
 			name:          "no overrides, just defaults and .NET project",
 			cliProperties: []string{},
@@ -847,6 +986,14 @@ func Test_Properties(t *testing.T) {
 			isContainer:   true,
 			expected:      propertiesFixture(true, []string{"-Dqodana.net.targetFrameworks=net5.0;net6.0"}),
 		},
+		{
+			name:          "target frameworks set in CLI override YAML",
+			cliProperties: []string{},
+			qodanaYaml:    "dotnet:\n   frameworks: net5.0;net6.0",
+			isContainer:   true,
+			frameworks:    []string{"net8.0", "!net48;!net472"},
+			expected:      propertiesFixture(true, []string{"-Dqodana.net.targetFrameworks=net8.0;!net48;!net472"}),
+		},
 		{
 			name:          "target frameworks not set in container",
 			cliProperties: []string{},
@@ -864,6 +1011,16 @@ func Test_Properties(t *testing.T) {
 				"-xa",
 			),
 		},
+		{
+			name:          "CLI property without a value is a flag, the value can contain '='",
+			cliProperties: []string{"idea.is.internal", "qodana.filter=a=b"},
+			qodanaYaml:    "",
+			isContainer:   false,
+			expected: append(
+				propertiesFixture(true, []string{"-Dqodana.filter=a=b"}),
+				"-Didea.is.internal",
+			),
+		},
 		{
 			name:          "override options from CLI, YAML should be ignored",
 			cliProperties: []string{"idea.headless.enable.statistics=false"},
@@ -877,6 +1034,25 @@ func Test_Properties(t *testing.T) {
 				"-Didea.application.info.value=0",
 			}, propertiesFixture(false, []string{})...),
 		},
+		{
+			name:          "--no-statistics overrides YAML",
+			cliProperties: []string{},
+			qodanaYaml: "" +
+				"version: \"1.0\"\n" +
+				"properties:\n" +
+				"  idea.headless.enable.statistics: true\n",
+			isContainer:  false,
+			noStatistics: true,
+			expected:     propertiesFixture(false, []string{}),
+		},
+		{
+			name:          "CLI property overrides --no-statistics",
+			cliProperties: []string{"idea.headless.enable.statistics=true", "idea.some.custom.property=1"},
+			qodanaYaml:    "",
+			isContainer:   false,
+			noStatistics:  true,
+			expected:      propertiesFixture(true, []string{"-Didea.some.custom.property=1"}),
+		},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			err = os.WriteFile(filepath.Join(opts.ProjectDir, "qodana.yml"), []byte(tc.qodanaYaml), 0o600)
@@ -884,6 +1060,10 @@ func Test_Properties(t *testing.T) {
 				t.Fatal(err)
 			}
 			opts.Property = tc.cliProperties
+			opts.SbomOutput = tc.sbomOutput
+			opts.SbomFormat = tc.sbomFormat
+			opts.CdnetTargetFrameworks = tc.frameworks
+			opts.NoStatistics = tc.noStatistics
 			qConfig := platform.GetQodanaYamlOrDefault(opts.ProjectDir)
 			if tc.isContainer {
 				t.Setenv(platform.QodanaDockerEnv, "true")
@@ -902,3 +1082,258 @@ func Test_Properties(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+type stageRecorder struct {
+	stages []string
+}
+
+func (r *stageRecorder) Stage(index int, name string) {
+	r.stages = append(r.stages, fmt.Sprintf("%d:%s", index, name))
+}
+
+func Test_updateScanStage(t *testing.T) {
+	resetScanStages()
+	updateScanStage(nil, 0) // no reporter registered
+	recorder := &stageRecorder{}
+	Progress = recorder
+	t.Cleanup(func() {
+		Progress = nil
+	})
+	for i := range scanStages {
+		updateScanStage(nil, i)
+	}
+	expected := []string{
+		"0:Preparing Qodana Docker images",
+		"1:Starting the analysis engine",
+		"2:Opening the project",
+		"3:Configuring the project",
+		"4:Analyzing the project",
+		"5:Preparing the report",
+	}
+	assert.Equal(t, expected, recorder.stages)
+}
+
+func Test_savePartialResults(t *testing.T) {
+	resultsDir := t.TempDir()
+	opts := &QodanaOptions{&platform.QodanaOptions{ResultsDir: resultsDir}}
+
+	savePartialResults(opts)
+	_, err := os.Stat(opts.GetShortSarifPath())
+	assert.True(t, os.IsNotExist(err))
+
+	report := `{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "QDJVM"}}, "results": [{"ruleId": "ConstantValue", "message": {"text": "Condition is always true"}}]}]}`
+	err = os.WriteFile(opts.GetSarifPath(), []byte(report), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	savePartialResults(opts)
+	short, err := platform.ReadReport(opts.GetShortSarifPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, short.Runs, 1)
+	assert.Empty(t, short.Runs[0].Results)
+}
+
+func Test_saveCommitResults(t *testing.T) {
+	resultsDir := t.TempDir()
+	opts := &QodanaOptions{&platform.QodanaOptions{ResultsDir: resultsDir}}
+	for _, revision := range []string{"a1b2c3", "d4e5f6"} {
+		err := os.WriteFile(opts.GetSarifPath(), []byte(revision), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err = saveCommitResults(opts, revision); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, revision := range []string{"a1b2c3", "d4e5f6"} {
+		got, err := os.ReadFile(filepath.Join(resultsDir, "history", revision, platform.QodanaSarifName))
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, revision, string(got))
+		assert.NoFileExists(t, filepath.Join(resultsDir, "history", revision, platform.QodanaShortSarifName))
+	}
+}
+
+func Test_getCachedLatestVersion(t *testing.T) {
+	cachePath := filepath.Join(t.TempDir(), "JetBrains", "Qodana", updateCheckCacheName)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	fetches := 0
+	fetch := func(version string) func() string {
+		return func() string {
+			fetches++
+			return version
+		}
+	}
+
+	// no cache yet – the version is fetched and stored
+	assert.Equal(t, "2024.1.0", getCachedLatestVersion(cachePath, now, fetch("2024.1.0")))
+	assert.Equal(t, 1, fetches)
+
+	// within the interval – the cached version is used
+	assert.Equal(t, "2024.1.0", getCachedLatestVersion(cachePath, now.Add(23*time.Hour), fetch("2024.1.1")))
+	assert.Equal(t, 1, fetches)
+
+	// the interval has passed – the version is fetched again
+	assert.Equal(t, "2024.1.1", getCachedLatestVersion(cachePath, now.Add(25*time.Hour), fetch("2024.1.1")))
+	assert.Equal(t, 2, fetches)
+
+	// a failed check keeps the known version and isn't retried within the interval
+	assert.Equal(t, "2024.1.1", getCachedLatestVersion(cachePath, now.Add(50*time.Hour), fetch("")))
+	assert.Equal(t, "2024.1.1", getCachedLatestVersion(cachePath, now.Add(51*time.Hour), fetch("2024.1.2")))
+	assert.Equal(t, 3, fetches)
+
+	// a broken cache file is ignored
+	assert.NoError(t, os.WriteFile(cachePath, []byte("{"), 0o600))
+	assert.Equal(t, "2024.1.2", getCachedLatestVersion(cachePath, now.Add(52*time.Hour), fetch("2024.1.2")))
+	assert.Equal(t, 4, fetches)
+}
+
+func Test_waitForAnalysisStart(t *testing.T) {
+	resetScanStages()
+	// every line of a non-TTY container log stream starts with the 8-byte docker header
+	header := "\x01\x00\x00\x00\x00\x00\x00\x20"
+	for _, tc := range []struct {
+		name     string
+		log      string
+		expected bool
+	}{
+		{
+			name:     "hangs before the analysis",
+			log:      header + "Starting up\n" + header + "The Project opening stage completed in 1 s\n" + header + "Installing plugins\n",
+			expected: false,
+		},
+		{
+			name:     "reaches the analysis",
+			log:      header + "Starting up\n" + header + "The Project configuration stage completed in 1 s\n",
+			expected: true,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			reader, writer := io.Pipe()
+			defer func() { _ = writer.Close() }() // the stream stays open like a running container
+			started := make(chan struct{})
+			go scanLinterLog(reader, nil, func() { close(started) }, nil)
+			go func() { _, _ = writer.Write([]byte(tc.log)) }()
+
+			assert.Equal(t, tc.expected, waitForAnalysisStart(context.Background(), started, 200*time.Millisecond))
+		})
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.True(t, waitForAnalysisStart(ctx, make(chan struct{}), time.Hour), "a finished container is not a startup timeout")
+}
+
+func TestCopyWebAssets(t *testing.T) {
+	webDir := filepath.Join(t.TempDir(), "web")
+	if err := os.MkdirAll(webDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(webDir, "app.js"), []byte("app"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	reportDir := t.TempDir()
+	assert.NoError(t, copyWebAssets(webDir, reportDir, false))
+	assert.FileExists(t, filepath.Join(reportDir, "app.js"))
+
+	reportDir = t.TempDir()
+	assert.NoError(t, copyWebAssets(webDir, reportDir, true))
+	assert.NoFileExists(t, filepath.Join(reportDir, "app.js"))
+
+	reportDir = t.TempDir()
+	assert.NoError(t, copyWebAssets(filepath.Join(t.TempDir(), "missing"), reportDir, false))
+	entries, err := os.ReadDir(reportDir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestLinterLogFilter(t *testing.T) {
+	logDir := filepath.Join(t.TempDir(), "log")
+	filter, err := newLinterLogFilter("warn", logDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := []struct {
+		line  string
+		shown bool
+	}{
+		{"Qodana for JVM", true},
+		{"2024-05-01 10:00:00,000 [   1000]   INFO - #c.i.i.StartupUtil - JNU charset: UTF-8", false},
+		{"2024-05-01 10:00:01,000 [   2000]   WARN - #c.i.o.p.Project - Module is not found", true},
+		{"2024-05-01 10:00:02,000 [   3000]  DEBUG - #c.i.o.p.Project - Indexing", false},
+		{"\tat com.intellij.Project.open(Project.java:42)", false},
+		{"2024-05-01 10:00:03,000 [   4000]  ERROR - #c.i.o.p.Project - Failed to open", true},
+		{"\tat com.intellij.Project.open(Project.java:42)", true},
+		{`time="2024-05-01T10:00:04Z" level=info msg="Downloading plugins"`, false},
+		{"The Project configuration stage completed in 1 s", true},
+	}
+	for _, l := range lines {
+		assert.Equal(t, l.shown, filter.show(l.line), l.line)
+	}
+	filter.close()
+
+	content, err := os.ReadFile(filepath.Join(logDir, "linter-output.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, len(lines), strings.Count(string(content), "\n"), "all lines are written to the log")
+
+	var noFilter *linterLogFilter
+	assert.True(t, noFilter.show("2024-05-01 10:00:00,000 [   1000]  DEBUG - #c.i.i.StartupUtil - debug"))
+
+	assert.NoError(t, ValidateContainerLogLevel(""))
+	assert.NoError(t, ValidateContainerLogLevel("Info"))
+	assert.Error(t, ValidateContainerLogLevel("verbose"))
+}
+
+func TestCompareVersions(t *testing.T) {
+	for _, tc := range []struct {
+		a, b     string
+		expected int
+	}{
+		{"2024.1", "2024.1", 0},
+		{"2024.1", "2024.1.0", 0},
+		{"2024.1.2", "2024.1", 1},
+		{"2023.3", "2024.1", -1},
+		{"2025.3", "2026.1", -1},
+		{"2026.1", "2025.3", 1},
+		{"2030.1", "2029.3", 1},
+		{"2024.10", "2024.9", 1},
+		{"", "2024.1", -1},
+		{"2024.1", "latest", 1},
+		{"2024.x", "2024.1", -1},
+		{"master", "", 0},
+	} {
+		t.Run(tc.a+" vs "+tc.b, func(t *testing.T) {
+			assert.Equal(t, tc.expected, CompareVersions(tc.a, tc.b))
+		})
+	}
+}
+
+func TestProductVersionBranch(t *testing.T) {
+	for _, tc := range []struct {
+		version      string
+		branch       string
+		is233orNewer bool
+		is242orNewer bool
+	}{
+		{"2023.2", "232", false, false},
+		{"2023.3", "233", true, false},
+		{"2024.2.1", "242", true, true},
+		{"2026.1", "261", true, true},
+		{"2030.2", "302", true, true},
+		{"2024", "master", true, false},
+		{"24.1", "master", false, false},
+		{"2024.1-EAP", "master", false, false},
+		{"", "master", false, false},
+	} {
+		t.Run(tc.version, func(t *testing.T) {
+			p := product{Version: tc.version}
+			assert.Equal(t, tc.branch, p.getVersionBranch())
+			assert.Equal(t, tc.is233orNewer, p.is233orNewer())
+			assert.Equal(t, tc.is242orNewer, p.is242orNewer())
+		})
+	}
+}
@@ -7,6 +7,7 @@ require (
 	github.com/docker/cli v25.0.0+incompatible
 	github.com/docker/docker v25.0.6+incompatible // DO NOT UPDATE: breaking changes
 	github.com/docker/go-connections v0.5.0
+	github.com/fsnotify/fsnotify v1.7.0
 	github.com/otiai10/copy v1.14.1
 	github.com/pterm/pterm v0.12.80
 	github.com/shirou/gopsutil/v3 v3.24.5
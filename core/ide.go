@@ -17,6 +17,8 @@
 package core
 
 import (
+	"archive/zip"
+	"context"
 	"fmt"
 	"github.com/JetBrains/qodana-cli/v2024/cloud"
 	"github.com/JetBrains/qodana-cli/v2024/platform"
@@ -54,27 +56,134 @@ func getIdeExitCode(resultsDir string, c int) (res int) {
 	return c
 }
 
-func runQodanaLocal(opts *QodanaOptions) (int, error) {
+func runQodanaLocal(ctx context.Context, opts *QodanaOptions) (int, error) {
+	if ctx.Err() != nil {
+		return platform.QodanaTimeoutExitCodePlaceholder, nil
+	}
 	writeProperties(opts)
+	if message := opts.CoverageWarning(); message != "" {
+		platform.WarningMessage(message)
+	}
+	baselineSeed := opts.PrepareBaselineSeed()
 	args := getIdeRunCommand(opts)
+	timeout := opts.GetAnalysisTimeout()
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
 	ideProcess, err := platform.RunCmdWithTimeout(
 		"",
 		os.Stdout, os.Stderr,
-		opts.GetAnalysisTimeout(),
+		timeout,
 		platform.QodanaTimeoutExitCodePlaceholder,
 		args...,
 	)
 	res := getIdeExitCode(opts.ResultsDir, ideProcess)
+	if res == platform.QodanaTimeoutExitCodePlaceholder {
+		savePartialResults(opts)
+		postAnalysis(opts)
+		return res, err
+	}
 	if res > platform.QodanaSuccessExitCode && res != platform.QodanaFailThresholdExitCode {
 		postAnalysis(opts)
 		return res, err
 	}
 
+	res = processIdeResults(opts, res, baselineSeed)
 	saveReport(opts)
 	postAnalysis(opts)
 	return res, err
 }
 
+// processIdeResults applies the result changes configured for the run (--exclude-generated, severityOverrides,
+// ruleIgnores, the baseline options, --sarif-rebase-uris and --analysis-name) to the IDE report, before the HTML
+// report and the other outputs are generated from it. The passes go in the same order as for the third-party
+// linters in platform.RunAnalysis. If the report is changed, the failure thresholds are checked again and the new
+// exit code is written to the full and the short SARIF reports.
+// The report the IDE uploads to Qodana Cloud itself keeps the original results.
+func processIdeResults(opts *QodanaOptions, exitCode int, baselineSeed string) int {
+	sarifPath := opts.GetSarifPath()
+	excluded := 0
+	if opts.ExcludeGenerated {
+		var err error
+		if excluded, err = platform.ExcludeGeneratedResults(sarifPath, opts.QdConfig.GeneratedFileGlobs()); err != nil {
+			log.Fatal(err)
+		}
+		if excluded > 0 {
+			log.Printf("Excluded %d result(s) in generated files", excluded)
+		}
+	}
+	overridden, err := platform.ApplySeverityOverrides(sarifPath, opts.QdConfig.SeverityOverrides)
+	if err != nil {
+		log.Fatal(err)
+	}
+	dropped, err := platform.DropIgnoredRules(sarifPath, opts.IgnoredRules())
+	if err != nil {
+		log.Fatal(err)
+	}
+	rematched := opts.Baseline != "" && opts.BaselineMatch == platform.BaselineMatchContent
+	if rematched {
+		if err := platform.ApplyContentBaseline(sarifPath, opts.BaselinePath(), opts.BaselineIncludeAbsent); err != nil {
+			log.Fatal(err)
+		}
+	}
+	res := exitCode
+	changed := rematched || excluded+overridden+dropped > 0
+	if changed {
+		if res, err = platform.RecheckFailureThresholds(sarifPath, opts.FailureThresholds(), res); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if len(opts.BaselineIgnoreRules) > 0 {
+		if res, err = platform.ApplyBaselineIgnoreRules(sarifPath, opts.BaselineIgnoreRules, opts.FailureThresholds(), res); err != nil {
+			log.Fatal(err)
+		}
+		changed = true
+	}
+	if baselineSeed != "" {
+		if res, err = platform.SeedBaseline(sarifPath, baselineSeed, res); err != nil {
+			log.Fatal(err)
+		}
+		changed = true
+	}
+	if opts.BaselineIncludeAbsent && opts.AbsentMinSeverity != "" {
+		if err := platform.FilterAbsentResults(sarifPath, opts.AbsentMinSeverity); err != nil {
+			log.Fatal(err)
+		}
+		changed = true
+	}
+	if opts.AnnotateBaselineState {
+		if err := platform.AnnotateBaselineState(sarifPath); err != nil {
+			log.Fatal(err)
+		}
+		changed = true
+	}
+	if len(opts.SarifRebaseUris) > 0 {
+		if err := platform.RebaseSarifUris(sarifPath, opts.SarifRebaseUris); err != nil {
+			log.Fatal(err)
+		}
+		changed = true
+	}
+	if opts.AnalysisName != "" {
+		if err := platform.SetAnalysisName(sarifPath, opts.AnalysisName); err != nil {
+			log.Fatal(err)
+		}
+		changed = true
+	}
+	if !changed {
+		return exitCode
+	}
+	if res != exitCode {
+		log.Printf("Exit code after processing the results: %d", res)
+	}
+	if err := platform.SetInvocationExitCode(sarifPath, res); err != nil {
+		log.Fatal(err)
+	}
+	if err := platform.MakeShortSarif(sarifPath, opts.GetShortSarifPath()); err != nil {
+		log.Fatal(err)
+	}
+	return res
+}
+
 func getIdeRunCommand(opts *QodanaOptions) []string {
 	args := []string{platform.QuoteIfSpace(Prod.IdeScript)}
 	if !Prod.is242orNewer() {
@@ -151,11 +260,12 @@ func GetIdeArgs(opts *QodanaOptions) []string {
 	}
 
 	prod := opts.guessProduct() // TODO : think how it could be better handled in presence of random 3rd party linters
+	// the IDE gets --no-statistics as a property, the CLI in a container and third party linters – as is
+	if opts.NoStatistics && (opts.Ide == "" || prod == platform.QDNETC || prod == platform.QDCL) {
+		arguments = append(arguments, "--no-statistics")
+	}
 	if prod == platform.QDNETC || prod == platform.QDCL {
 		// third party common options
-		if opts.NoStatistics {
-			arguments = append(arguments, "--no-statistics")
-		}
 		if prod == platform.QDNETC {
 			// cdnet options
 			if opts.CdnetSolution != "" {
@@ -181,6 +291,9 @@ func GetIdeArgs(opts *QodanaOptions) []string {
 			if opts.ClangArgs != "" {
 				arguments = append(arguments, "--clang-args", opts.ClangArgs)
 			}
+			if opts.ClangCompileDbCommand != "" {
+				arguments = append(arguments, "--clang-db-generate", opts.ClangCompileDbCommand)
+			}
 		}
 	}
 
@@ -199,6 +312,94 @@ func GetIdeArgs(opts *QodanaOptions) []string {
 			arguments = append(arguments, "--analysis-id", opts.AnalysisId)
 		}
 
+		if opts.AnalysisName != "" {
+			arguments = append(arguments, "--analysis-name", opts.AnalysisName)
+		}
+
+		for _, framework := range opts.CdnetTargetFrameworks {
+			arguments = append(arguments, "--framework", framework)
+		}
+
+		if opts.SbomOutput != "" {
+			arguments = append(arguments, "--sbom-output", platform.QuoteIfSpace(opts.SbomOutput))
+		}
+
+		if opts.SbomFormat != "" {
+			arguments = append(arguments, "--sbom-format", opts.SbomFormat)
+		}
+
+		if opts.ResultUmask != "" {
+			arguments = append(arguments, "--result-umask", opts.ResultUmask)
+		}
+
+		if opts.BaselineAuto {
+			arguments = append(arguments, "--baseline-auto")
+		}
+
+		if opts.BaselineCreateIfMissing {
+			arguments = append(arguments, "--baseline-create-if-missing")
+		}
+
+		for _, rule := range opts.BaselineIgnoreRules {
+			arguments = append(arguments, "--baseline-ignore-rule", rule)
+		}
+
+		if opts.AbsentMinSeverity != "" {
+			arguments = append(arguments, "--absent-min-severity", opts.AbsentMinSeverity)
+		}
+
+		if opts.AnnotateBaselineState {
+			arguments = append(arguments, "--annotate-baseline-state")
+		}
+
+		for _, rebase := range opts.SarifRebaseUris {
+			arguments = append(arguments, "--sarif-rebase-uris", rebase)
+		}
+
+		if opts.BaselineMatch != "" && opts.BaselineMatch != platform.BaselineMatchFingerprint {
+			arguments = append(arguments, "--baseline-match", opts.BaselineMatch)
+		}
+
+		if opts.ExcludeGenerated {
+			arguments = append(arguments, "--exclude-generated")
+		}
+
+		for _, rule := range opts.IgnoreRules {
+			arguments = append(arguments, "--ignore-rule", rule)
+		}
+
+		for _, glob := range opts.ScopeGlobs {
+			arguments = append(arguments, "--scope-glob", glob)
+		}
+
+		for i, plugin := range opts.PluginsFromFiles {
+			arguments = append(arguments, "--plugin-from-file", containerPluginPath(i, plugin))
+		}
+
+		for i, cert := range opts.CaCerts {
+			arguments = append(arguments, "--ca-cert", containerCaCertPath(i, cert))
+		}
+
+		if opts.ConfigOverride != "" {
+			arguments = append(arguments, "--config-override", containerConfigOverride)
+		}
+
+		for _, include := range opts.Includes {
+			arguments = append(arguments, "--include", include)
+		}
+
+		for _, exclude := range opts.Excludes {
+			arguments = append(arguments, "--exclude", exclude)
+		}
+
+		if opts.MaxRuntimeNotifications > 0 {
+			arguments = append(arguments, "--max-runtime-notifications", strconv.Itoa(opts.MaxRuntimeNotifications))
+		}
+
+		if opts.FailOnErrorNotification != "" {
+			arguments = append(arguments, "--fail-on-error-notification="+opts.FailOnErrorNotification)
+		}
+
 		if opts.CoverageDir != "" {
 			arguments = append(arguments, "--coverage-dir", opts.CoverageDir)
 		}
@@ -210,6 +411,16 @@ func GetIdeArgs(opts *QodanaOptions) []string {
 		for _, property := range opts.Property {
 			arguments = append(arguments, "--property="+property)
 		}
+
+		// the CLI in the container passes them to the linter
+		for _, arg := range opts.LinterArgs {
+			arguments = append(arguments, "--linter-arg", arg)
+		}
+	} else {
+		// the native command line is run by the shell, see getIdeRunCommand
+		for _, arg := range opts.LinterArgs {
+			arguments = append(arguments, platform.QuoteIfSpace(arg))
+		}
 	}
 
 	return arguments
@@ -317,6 +528,61 @@ func prepareLocalIdeSettings(opts *QodanaOptions) {
 		syncConfigCache(opts, true)
 		createUser("/etc/passwd")
 	}
+	if opts.ResultUmask != "" {
+		if err := platform.SetUmask(opts.ResultUmask); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+// ListProfiles returns the profile names available for --profile-name, with the ones shipped with the distribution
+// of the native analyzer if it's known before the run: --ide pointing to the distribution directory or QODANA_DIST.
+func ListProfiles(opts *QodanaOptions) []string {
+	home := os.Getenv(platform.QodanaDistEnv)
+	if info, err := os.Stat(opts.Ide); opts.Ide != "" && err == nil && info.IsDir() {
+		home = opts.Ide
+	}
+	return platform.ListProfiles(opts.ProjectDir, home)
+}
+
+// checkProfileName reports if --profile-name is not among the known profiles of the project and the IDE configuration,
+// so a typo is noticed before the analysis falls back to the default profile. The list is not exhaustive, the caller only warns.
+func checkProfileName(opts *QodanaOptions) error {
+	if opts.ProfileName == "" || opts.ProfilePath != "" || Prod.Home == "" {
+		return nil
+	}
+	available := platform.ListProfiles(opts.ProjectDir, Prod.Home)
+	for _, profile := range platform.ProfilesInDir(filepath.Join(opts.ConfDirPath(), "inspection")) {
+		if !platform.Contains(available, profile) {
+			available = append(available, profile)
+		}
+	}
+	return platform.ValidateProfileName(opts.ProfileName, available)
+}
+
+// cloudBaselineName is the file in the cache directory the latest Qodana Cloud report is downloaded to,
+// every run replaces the one downloaded by the previous run.
+const cloudBaselineName = "qodana-cloud-baseline.sarif.json"
+
+// fetchCloudBaseline downloads the latest Qodana Cloud report of the project and uses it as the baseline,
+// without a token or a previous report the analysis runs without a baseline.
+func fetchCloudBaseline(opts *QodanaOptions) {
+	if opts.Baseline != "" {
+		return
+	}
+	if cloud.Token.Token == "" {
+		platform.WarningMessage("--baseline-auto requires %s, running without a baseline", platform.QodanaToken)
+		return
+	}
+	baselinePath := filepath.Join(opts.CacheDir, cloudBaselineName)
+	client := cloud.GetCloudApiEndpoints().NewCloudApiClient(cloud.Token.Token)
+	if err := client.DownloadLatestSarif(baselinePath); err != nil {
+		_ = os.Remove(baselinePath)
+		platform.WarningMessage("Could not get the baseline from Qodana Cloud, running without a baseline: %s", err)
+		return
+	}
+	log.Debugf("Using the latest Qodana Cloud report %s as the baseline", baselinePath)
+	opts.Baseline = baselinePath
 }
 
 func prepareDirectories(cacheDir string, logDir string, confDir string) {
@@ -418,6 +684,61 @@ func installPlugins(opts *QodanaOptions, plugins []platform.Plugin) {
 	}
 }
 
+// installPluginsFromFiles unpacks every plugin archive passed via --plugin-from-file to the custom plugins directory.
+func installPluginsFromFiles(opts *QodanaOptions) {
+	if !opts.IsNative() {
+		return
+	}
+	for _, plugin := range opts.PluginsFromFiles {
+		log.Printf("Installing plugin from %s", plugin)
+		if err := installPluginFromFile(plugin, Prod.CustomPluginsPath()); err != nil {
+			log.Fatalf("Failed to install plugin from %s: %s", plugin, err)
+		}
+	}
+}
+
+func installPluginFromFile(archive string, pluginsDir string) error {
+	if err := validatePluginArchive(archive); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(pluginsDir, 0o755); err != nil {
+		return err
+	}
+	return platform.Decompress(archive, pluginsDir)
+}
+
+// validatePluginArchive checks that the archive has the plugin distribution layout: <plugin>/lib/*.jar.
+func validatePluginArchive(archive string) error {
+	if !strings.HasSuffix(archive, ".zip") {
+		return fmt.Errorf("%s is not a zip archive", archive)
+	}
+	reader, err := zip.OpenReader(archive)
+	if err != nil {
+		return fmt.Errorf("%s is not a valid zip archive: %w", archive, err)
+	}
+	defer func(reader *zip.ReadCloser) {
+		_ = reader.Close()
+	}(reader)
+	for _, f := range reader.File {
+		parts := strings.Split(f.Name, "/")
+		if len(parts) == 3 && parts[1] == "lib" && strings.HasSuffix(parts[2], ".jar") {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is not a plugin archive: no <plugin>/lib/*.jar entries found", archive)
+}
+
+// containerPluginPath returns the path the plugin archive with the given index is mounted to in the Qodana container,
+// the archive keeps its file name.
+func containerPluginPath(index int, plugin string) string {
+	return fmt.Sprintf("/data/plugins/%d/%s", index, filepath.Base(plugin))
+}
+
+// containerCaCertPath returns the path the CA certificate with the given index is mounted to in the Qodana container.
+func containerCaCertPath(index int, cert string) string {
+	return fmt.Sprintf("/data/certs/%d-%s", index, filepath.Base(cert))
+}
+
 func syncConfigCache(opts *QodanaOptions, fromCache bool) {
 	if Prod.BaseScriptName == idea {
 		jdkTableFile := filepath.Join(opts.ConfDirPath(), "options", "jdk.table.xml")
@@ -457,6 +778,9 @@ func syncIdeaCache(from string, to string, overwrite bool) error {
 		OnSymlink: func(src string) cp.SymlinkAction {
 			return cp.Skip
 		},
+		// keep mtimes intact so the IDE does not treat the synced caches as stale
+		PreserveTimes:     true,
+		PermissionControl: cp.PerservePermission,
 	}
 	src := filepath.Join(from, ".idea")
 	if _, err := os.Stat(src); os.IsNotExist(err) {
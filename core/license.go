@@ -27,30 +27,43 @@ import (
 )
 
 func SetupLicenseAndProjectHash(endpoints *cloud.QdApiEndpoints, token string) {
+	if err := SetupLicense(endpoints, token); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// SetupLicense obtains the license for the current product and exports it together with the project hashes.
+// License failures wrap one of cloud.ErrTokenMissing, cloud.ErrTokenInvalid, cloud.ErrLicenseExpired
+// or cloud.ErrCloudUnreachable, so callers can tell them apart with errors.Is.
+func SetupLicense(endpoints *cloud.QdApiEndpoints, token string) error {
 	var licenseData cloud.LicenseData
 	if token != "" {
-		licenseData = endpoints.GetLicenseData(token)
+		var err error
+		licenseData, err = endpoints.RequestLicense(token)
+		if err != nil {
+			return err
+		}
 		if licenseData.ProjectIdHash != "" {
 			err := os.Setenv(platform.QodanaProjectIdHash, licenseData.ProjectIdHash)
 			if err != nil {
-				log.Fatal(err)
+				return err
 			}
 		}
 		if licenseData.OrganisationIdHash != "" {
 			err := os.Setenv(platform.QodanaOrganisationIdHash, licenseData.OrganisationIdHash)
 			if err != nil {
-				log.Fatal(err)
+				return err
 			}
 		}
 	}
 	_, exists := os.LookupEnv(platform.QodanaLicense)
 	if exists {
-		return
+		return nil
 	}
 
 	// community versions works without any license and can't check any license
 	if Prod.IsCommunity() {
-		return
+		return nil
 	}
 
 	// eap version works with eap's license dependent on build date
@@ -60,25 +73,20 @@ func SetupLicenseAndProjectHash(endpoints *cloud.QdApiEndpoints, token string) {
 			fmt.Println()
 			fmt.Println()
 		}
-		return
+		return nil
 	}
 
 	// usual builds should have token and LicenseData for execution
 	if token == "" {
-		log.Fatalf(cloud.EmptyTokenMessage, endpoints.RootEndpoint.GetCloudUrl())
+		return fmt.Errorf("%w\n"+cloud.EmptyTokenMessage, cloud.ErrTokenMissing, endpoints.RootEndpoint.GetCloudUrl())
 	}
 
-	licenseDataResponse, err := endpoints.RequestLicenseData(token)
-	if errors.Is(err, cloud.TokenDeclinedError) {
-		log.Fatalf("License request: %v\n%s", err, cloud.DeclinedTokenErrorMessage)
-	}
+	licenseData, err := endpoints.RequestLicense(token)
 	if err != nil {
-		errMessage := fmt.Sprintf(cloud.GeneralLicenseErrorMessage, endpoints.RootEndpoint.GetCloudUrl())
-		log.Fatalf("License request: %v\n%s", err, errMessage)
+		return err
 	}
-	licenseData = cloud.DeserializeLicenseData(licenseDataResponse)
 	if strings.ToLower(licenseData.LicensePlan) == "community" {
-		log.Fatalf("Your Qodana Cloud organization has Community license that doesn’t support \"%s\" linter, "+
+		return fmt.Errorf("Your Qodana Cloud organization has Community license that doesn’t support \"%s\" linter, "+
 			"please try one of the community linters instead: %s or obtain Ultimate "+
 			"or Ultimate Plus license. Read more about licenses and plans at "+
 			"https://www.jetbrains.com/help/qodana/pricing.html#pricing-linters-licenses.",
@@ -87,12 +95,9 @@ func SetupLicenseAndProjectHash(endpoints *cloud.QdApiEndpoints, token string) {
 		)
 	}
 	if licenseData.LicenseKey == "" {
-		log.Fatalf("License key should not be empty\n")
-	}
-	err = os.Setenv(platform.QodanaLicense, licenseData.LicenseKey)
-	if err != nil {
-		log.Fatal(err)
+		return errors.New("license key should not be empty")
 	}
+	return os.Setenv(platform.QodanaLicense, licenseData.LicenseKey)
 }
 
 func allCommunityNames() string {
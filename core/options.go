@@ -18,6 +18,7 @@ package core
 
 import (
 	"github.com/JetBrains/qodana-cli/v2024/platform"
+	log "github.com/sirupsen/logrus"
 	"path/filepath"
 )
 
@@ -29,6 +30,18 @@ func (o *QodanaOptions) fixesSupported() bool {
 	return o.guessProduct() != platform.QDNET && o.guessProduct() != platform.QDNETC && o.guessProduct() != platform.QDCL
 }
 
+// javaPath returns the java executable from --java-home if it's set, otherwise the bundled JBR one.
+func (o *QodanaOptions) javaPath() string {
+	if o.JavaHome == "" {
+		return Prod.JbrJava()
+	}
+	java, err := platform.JavaExecutable(o.JavaHome)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return java
+}
+
 func (o *QodanaOptions) vmOptionsPath() string {
 	return filepath.Join(o.ConfDirPath(), "ide.vmoptions")
 }
@@ -26,7 +26,7 @@ import (
 var PricingUrl = "https://www.jetbrains.com/qodana/buy/"
 
 // PrintContributorsTable prints the contributors table and helpful messages.
-func PrintContributorsTable(contributors []contributor, days int, dirs int) {
+func PrintContributorsTable(contributors []contributor, period ContributorsPeriod, dirs int) {
 	count := len(contributors)
 	contributorsTableData := pterm.TableData{
 		[]string{
@@ -53,9 +53,9 @@ func PrintContributorsTable(contributors []contributor, days int, dirs int) {
 	}
 	platform.EmptyMessage()
 	platform.SuccessMessage(
-		"There are %s active contributor(s)* for the last %s days in the provided %s project(s).",
+		"There are %s active contributor(s)* %s in the provided %s project(s).",
 		platform.PrimaryBold(strconv.Itoa(count)),
-		platform.PrimaryBold(strconv.Itoa(days)),
+		platform.PrimaryBold(period.String()),
 		platform.PrimaryBold(strconv.Itoa(dirs)),
 	)
 	platform.EmptyMessage()
@@ -189,30 +189,74 @@ func getProductNameFromCode(code string) string {
 
 // getVersionBranch returns the version branch of the current product, e.g. 2020.3 -> 203, 2021.1 -> 211, 2022.3 -> 223.
 func (p *product) getVersionBranch() string {
-	versions := strings.Split(p.Version, ".")
-	if len(versions) < 2 {
+	versions, ok := parseVersion(p.Version)
+	if !ok || len(versions) < 2 || versions[0] < 2000 {
 		return "master"
 	}
-	return fmt.Sprintf("%s%s", versions[0][2:], versions[1])
+	return fmt.Sprintf("%02d%d", versions[0]%100, versions[1])
 }
 
-// is233orNewer returns true if the current product is 233 or newer.
-func (p *product) is233orNewer() bool {
-	number, err := strconv.Atoi(p.getVersionBranch())
-	if err != nil {
-		platform.WarningMessage("Invalid version: ", err)
+// isNotOlderThan returns true if the current product is the given version (e.g. 2023.3) or newer.
+func (p *product) isNotOlderThan(version string) bool {
+	if _, ok := parseVersion(p.Version); !ok {
+		platform.WarningMessage("Invalid version: %q", p.Version)
 		return false
 	}
-	return number >= 233
+	return CompareVersions(p.Version, version) >= 0
+}
+
+// is233orNewer returns true if the current product is 233 or newer.
+func (p *product) is233orNewer() bool {
+	return p.isNotOlderThan("2023.3")
 }
 
 func (p *product) is242orNewer() bool {
-	number, err := strconv.Atoi(p.getVersionBranch())
-	if err != nil {
-		platform.WarningMessage("Invalid version: ", err)
-		return false
+	return p.isNotOlderThan("2024.2")
+}
+
+// parseVersion returns the numeric components of a version like 2024.1 or 2024.1.2, false if the version is malformed.
+func parseVersion(version string) ([]int, bool) {
+	parts := strings.Split(strings.TrimSpace(version), ".")
+	numbers := make([]int, 0, len(parts))
+	for _, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil || number < 0 {
+			return nil, false
+		}
+		numbers = append(numbers, number)
+	}
+	return numbers, true
+}
+
+// CompareVersions compares versions like 2024.1 or 2024.1.2 component by component, the missing components are zeros.
+// It returns -1 if a is older than b, 0 if they are equal and 1 if a is newer. A malformed version is older than any valid one.
+func CompareVersions(a, b string) int {
+	va, okA := parseVersion(a)
+	vb, okB := parseVersion(b)
+	switch {
+	case !okA && !okB:
+		return 0
+	case !okA:
+		return -1
+	case !okB:
+		return 1
+	}
+	for i := 0; i < len(va) || i < len(vb); i++ {
+		x, y := 0, 0
+		if i < len(va) {
+			x = va[i]
+		}
+		if i < len(vb) {
+			y = vb[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
 	}
-	return number >= 242
+	return 0
 }
 
 func (p *product) isRuby() bool {
@@ -131,6 +131,9 @@ func GetScanProperties(opts *QodanaOptions, yamlProps map[string]string, dotNetO
 		}
 	}
 
+	if frameworks := opts.TargetFrameworks(); frameworks != "" {
+		dotNetOptions.Frameworks = frameworks
+	}
 	props := getPropertiesMap(
 		Prod.parentPrefix(),
 		dotNetOptions,
@@ -139,12 +142,21 @@ func GetScanProperties(opts *QodanaOptions, yamlProps map[string]string, dotNetO
 		opts.AnalysisId,
 		opts.CoverageDirPath(),
 	)
+	if opts.SbomOutput != "" {
+		props["-Dqodana.sbom.output"] = opts.SbomOutputPath()
+	}
+	if opts.SbomFormat != "" {
+		props["-Dqodana.sbom.format"] = platform.Lower(opts.SbomFormat)
+	}
 	for k, v := range yamlProps { // qodana.yaml – overrides vmoptions
 		if !strings.HasPrefix(k, "-") {
 			k = fmt.Sprintf("-D%s", k)
 		}
 		props[k] = v
 	}
+	if opts.NoStatistics { // --no-statistics – overrides qodana.yaml, but not an explicit --property
+		props["-Didea.headless.enable.statistics"] = "false"
+	}
 	for k, v := range cliProps { // CLI – overrides anything
 		if !strings.HasPrefix(k, "-") {
 			k = fmt.Sprintf("-D%s", k)
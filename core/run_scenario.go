@@ -23,6 +23,7 @@ const (
 	runScenarioFullHistory  = "full-history"
 	runScenarioLocalChanges = "local-changes"
 	runScenarioScoped       = "scope"
+	runScenarioScopeGlob    = "scope-glob"
 )
 
 type RunScenario = string
@@ -34,6 +35,8 @@ func (o *QodanaOptions) determineRunScenario(hasStartHash bool) RunScenario {
 	switch {
 	case o.FullHistory:
 		return runScenarioFullHistory
+	case !hasStartHash && len(o.ScopeGlobs) > 0:
+		return runScenarioScopeGlob
 	case !hasStartHash:
 		return runScenarioDefault
 	case o.ForceLocalChangesScript:
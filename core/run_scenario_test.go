@@ -102,6 +102,28 @@ func TestQodanaOptions_determineRunScenario(t *testing.T) {
 			},
 			want: runScenarioLocalChanges,
 		},
+		{
+			name: "scope glob",
+			args: args{
+				qodanaOptions: &platform.QodanaOptions{
+					ScopeGlobs: []string{"src/**/*.kt"},
+				},
+				hasStartHash: false,
+				productCode:  platform.QDJVM,
+			},
+			want: runScenarioScopeGlob,
+		},
+		{
+			name: "scope glob with start hash",
+			args: args{
+				qodanaOptions: &platform.QodanaOptions{
+					ScopeGlobs: []string{"src/**/*.kt"},
+				},
+				hasStartHash: true,
+				productCode:  platform.QDJVM,
+			},
+			want: runScenarioScoped,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -29,8 +29,11 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/pterm/pterm"
 
@@ -42,15 +45,29 @@ var (
 	DisableCheckUpdates = false
 
 	scanStages []string
+	// Progress receives the scan stage transitions if set, see ProgressReporter
+	Progress   ProgressReporter
 	releaseUrl = "https://api.github.com/repos/JetBrains/qodana-cli/releases/latest"
 )
 
+const (
+	updateCheckInterval  = 24 * time.Hour
+	updateCheckTimeout   = 5 * time.Second
+	updateCheckCacheName = "update-check.json"
+)
+
+// updateCheck is the result of the last update check stored in the user cache directory.
+type updateCheck struct {
+	Version   string    `json:"version"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
 // CheckForUpdates check GitHub https://github.com/JetBrains/qodana-cli/ for the latest version of CLI release.
 func CheckForUpdates(currentVersion string) {
 	if currentVersion == "dev" || strings.HasSuffix(currentVersion, "nightly") || platform.IsContainer() || cienvironment.DetectCIEnvironment() != nil || DisableCheckUpdates {
 		return
 	}
-	latestVersion := getLatestVersion()
+	latestVersion := getCachedLatestVersion(updateCheckCachePath(), time.Now(), getLatestVersion)
 	if latestVersion != "" && latestVersion != currentVersion {
 		platform.WarningMessage(
 			"New version of %s CLI is available: %s. See https://jb.gg/qodana-cli/update\n",
@@ -61,9 +78,47 @@ func CheckForUpdates(currentVersion string) {
 	}
 }
 
+// updateCheckCachePath returns the path of the update check cache file, empty if there is no user cache directory.
+func updateCheckCachePath() string {
+	userCacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(userCacheDir, "JetBrains", "Qodana", updateCheckCacheName)
+}
+
+// getCachedLatestVersion returns the latest version from the cache at cachePath if it was checked less than
+// updateCheckInterval ago, otherwise calls fetch and stores the result. A failed check keeps the previously
+// known version, but is recorded too, so an offline machine doesn't retry on every run.
+func getCachedLatestVersion(cachePath string, now time.Time, fetch func() string) string {
+	if cachePath == "" {
+		return fetch()
+	}
+	var cached updateCheck
+	if data, err := os.ReadFile(cachePath); err == nil && json.Unmarshal(data, &cached) == nil {
+		if age := now.Sub(cached.CheckedAt); age >= 0 && age < updateCheckInterval {
+			return cached.Version
+		}
+	}
+	checked := updateCheck{Version: cached.Version, CheckedAt: now}
+	if latestVersion := fetch(); latestVersion != "" {
+		checked.Version = latestVersion
+	}
+	if data, err := json.Marshal(checked); err == nil {
+		if err = os.MkdirAll(filepath.Dir(cachePath), os.ModePerm); err == nil {
+			err = os.WriteFile(cachePath, data, 0o600)
+		}
+		if err != nil {
+			log.Debugf("Failed to save the update check result: %s", err)
+		}
+	}
+	return checked.Version
+}
+
 // getLatestVersion returns the latest published version of the CLI.
 func getLatestVersion() string {
-	resp, err := http.Get(releaseUrl)
+	httpClient := &http.Client{Timeout: updateCheckTimeout}
+	resp, err := httpClient.Get(releaseUrl)
 	if err != nil {
 		return ""
 	}
@@ -113,6 +168,9 @@ func prepareHost(opts *QodanaOptions) {
 			log.Errorf("Could not clear local Qodana cache: %s", err)
 		}
 	}
+	if err := opts.CleanResultsDir(); err != nil {
+		log.Fatal(err)
+	}
 	platform.WarnIfPrivateFeedDetected(opts.Linter, opts.ProjectDir)
 	if platform.IsNugetConfigNeeded() {
 		platform.PrepareNugetConfig(os.Getenv("HOME"))
@@ -123,6 +181,13 @@ func prepareHost(opts *QodanaOptions) {
 	if err := os.MkdirAll(opts.ResultsDir, os.ModePerm); err != nil {
 		log.Fatal("couldn't create a directory ", err.Error())
 	}
+	if opts.OutputRoot != "" && opts.Linter != "" {
+		for _, dir := range outputRootMounts(opts) {
+			if err := os.MkdirAll(dir.Source, os.ModePerm); err != nil {
+				log.Fatal("couldn't create a directory ", err.Error())
+			}
+		}
+	}
 	if opts.Linter != "" {
 		PrepareContainerEnvSettings()
 	}
@@ -144,6 +209,12 @@ func prepareHost(opts *QodanaOptions) {
 			}
 		}
 		prepareLocalIdeSettings(opts)
+		if err := checkProfileName(opts); err != nil {
+			platform.WarningMessage("%s, the distribution may still provide it", err)
+		}
+		if opts.BaselineAuto {
+			fetchCloudBaseline(opts)
+		}
 	}
 	if opts.RequiresToken(Prod.IsCommunity() || Prod.EAP) {
 		opts.ValidateToken(false)
@@ -163,24 +234,47 @@ func fixWindowsPlugins(ideDir string) {
 	}
 }
 
-// IsHomeDirectory returns true if the given path is the user's home directory.
-func IsHomeDirectory(path string) bool {
-	absPath, err := filepath.Abs(path)
-	if err != nil {
-		return false
-	}
-	home, err := os.UserHomeDir()
-	if err != nil {
-		return false
-	}
-	return absPath == home
-}
-
 // RunAnalysis runs the linter with the given options.
 func RunAnalysis(ctx context.Context, options *QodanaOptions) int {
 	log.Debug("Running analysis with options")
 	options.LogOptions()
+	cleanupCaCerts, err := options.SetupCaCerts()
+	if err != nil {
+		if options.Linter == "" {
+			platform.ErrorMessage(err.Error())
+			return 1
+		}
+		log.Warnf("%v, the certificates are passed only to the container", err) // the container has its own bundle
+	}
+	defer cleanupCaCerts()
+	cacheLock, err := options.LockCacheDir()
+	if err != nil {
+		platform.ErrorMessage(err.Error())
+		return 1
+	}
+	if cacheLock != nil {
+		defer func() {
+			if err := cacheLock.Unlock(); err != nil {
+				log.Warnf("Failed to release the cache directory lock: %v", err)
+			}
+		}()
+	}
 	prepareHost(options)
+	if err := options.InitGitSubmodules(); err != nil {
+		log.Fatal(err)
+	}
+
+	if options.AnalysisTimeoutMs > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.GetAnalysisTimeout())
+		defer cancel()
+	}
+
+	if options.SbomOutput != "" || options.SbomFormat != "" {
+		if prod := options.guessProduct(); prod != "" && !platform.SbomSupported(prod, options.SbomFormat) {
+			platform.WarningMessage("%s does not support the requested SBOM format, the SBOM options will be ignored", prod)
+		}
+	}
 
 	if !isInstalled("git") && (options.FullHistory || options.Commit != "" || options.DiffStart != "" || options.DiffEnd != "") {
 		log.Fatal("Cannot use git related functionality without a git executable")
@@ -195,13 +289,14 @@ func RunAnalysis(ctx context.Context, options *QodanaOptions) int {
 	}
 
 	scenario := options.determineRunScenario(startHash != "")
-	if scenario != runScenarioDefault && !platform.GitRevisionExists(options.ProjectDir, startHash, options.LogDirPath()) {
+	if scenario != runScenarioDefault && scenario != runScenarioScopeGlob && !platform.GitRevisionExists(options.ProjectDir, startHash, options.LogDirPath()) {
 		platform.WarningMessageCI("Cannot run analysis for commit %s because it doesn't exist in the repository. Check that you retrieve the full git history before running Qodana.", startHash)
-		scenario = runScenarioDefault
 		options.ResetScanScenarioOptions()
+		scenario = options.determineRunScenario(false)
 	}
 
 	installPlugins(options, options.QdConfig.Plugins)
+	installPluginsFromFiles(options)
 	// this way of running needs to do bootstrap twice on different commits and will do it internally
 	if scenario != runScenarioScoped && options.Ide != "" {
 		platform.Bootstrap(options.QdConfig.Bootstrap, options.ProjectDir)
@@ -213,6 +308,8 @@ func RunAnalysis(ctx context.Context, options *QodanaOptions) int {
 		return runLocalChanges(ctx, options, startHash)
 	case runScenarioScoped:
 		return runScopeScript(ctx, options, startHash)
+	case runScenarioScopeGlob:
+		return runScopeGlob(ctx, options)
 	case runScenarioDefault:
 		return runQodana(ctx, options)
 	default:
@@ -221,6 +318,49 @@ func RunAnalysis(ctx context.Context, options *QodanaOptions) int {
 	}
 }
 
+// PrintDryRun prints the command and the properties the analysis would run with,
+// without preparing the host, starting the linter, or writing any files.
+func PrintDryRun(options *QodanaOptions) {
+	if options.Linter != "" {
+		platform.SuccessMessage("The analysis would run the following container:")
+		fmt.Println(generateDebugDockerRunCommand(getDockerOptions(options)))
+	} else {
+		if platform.Contains(platform.AllNativeCodes, strings.TrimSuffix(options.Ide, EapSuffix)) {
+			platform.WarningMessage("%s is not downloaded in a dry run, the IDE path is not resolved", options.Ide)
+		} else {
+			guessProduct(options)
+		}
+		platform.SuccessMessage("The analysis would run the following command:")
+		fmt.Println(strings.Join(getIdeRunCommand(options), " "))
+	}
+	platform.SuccessMessage("Resolved properties:")
+	for _, property := range dryRunProperties(options) {
+		fmt.Println(property)
+	}
+}
+
+// dryRunProperties returns the properties of the analysis: for a container, the ones from qodana.yaml and --property,
+// the rest is resolved inside the container.
+func dryRunProperties(options *QodanaOptions) []string {
+	if options.Linter == "" {
+		return GetScanProperties(options, options.QdConfig.Properties, options.QdConfig.DotNet, getPluginIds(options.QdConfig.Plugins))
+	}
+	props := map[string]string{}
+	for k, v := range options.QdConfig.Properties {
+		props[k] = v
+	}
+	cliProps, flags := options.Properties()
+	for k, v := range cliProps {
+		props[k] = v
+	}
+	lines := flags
+	for k, v := range props {
+		lines = append(lines, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
 func runLocalChanges(ctx context.Context, options *QodanaOptions, startHash string) int {
 	var exitCode int
 	gitReset := false
@@ -283,6 +423,10 @@ func runWithFullHistory(ctx context.Context, options *QodanaOptions, startHash s
 	}
 
 	for _, revision := range revisions {
+		if ctx.Err() != nil {
+			exitCode = platform.QodanaTimeoutExitCodePlaceholder
+			break
+		}
 		counter++
 		options.Setenv(platform.QodanaRevision, revision)
 		platform.WarningMessage("[%d/%d] Running analysis for revision %s", counter+1, allCommits, revision)
@@ -293,6 +437,11 @@ func runWithFullHistory(ctx context.Context, options *QodanaOptions, startHash s
 		platform.EmptyMessage()
 
 		exitCode = runQodana(ctx, options)
+		if options.ResultsDirPerCommit {
+			if err := saveCommitResults(options, revision); err != nil {
+				log.Fatal(err)
+			}
+		}
 		options.Unsetenv(platform.QodanaRevision)
 	}
 	err = platform.GitCheckout(options.ProjectDir, branch, true, options.LogDirPath())
@@ -302,6 +451,50 @@ func runWithFullHistory(ctx context.Context, options *QodanaOptions, startHash s
 	return exitCode
 }
 
+// saveCommitResults copies the SARIF reports of the analyzed revision to <results>/history/<revision>/.
+func saveCommitResults(options *QodanaOptions, revision string) error {
+	historyDir := filepath.Join(options.ResultsDir, "history", revision)
+	if err := os.MkdirAll(historyDir, os.ModePerm); err != nil {
+		return fmt.Errorf("failed to create %s: %w", historyDir, err)
+	}
+	for _, name := range []string{platform.QodanaSarifName, platform.QodanaShortSarifName} {
+		source := filepath.Join(options.ResultsDir, name)
+		if _, err := os.Stat(source); os.IsNotExist(err) {
+			continue
+		}
+		if err := platform.CopyFile(source, filepath.Join(historyDir, name)); err != nil {
+			return fmt.Errorf("failed to save %s for revision %s: %w", name, revision, err)
+		}
+	}
+	return nil
+}
+
+// runScopeGlob runs the analysis of the files matching --scope-glob with the scoped script.
+func runScopeGlob(ctx context.Context, options *QodanaOptions) int {
+	// the container runs this logic itself
+	if options.Ide == "" {
+		return runQodana(ctx, options)
+	}
+	scope, err := platform.GlobScopeFiles(options.ProjectDir, options.ScopeGlobs)
+	if err != nil {
+		log.Fatal(err)
+	}
+	scopeFile, err := writeScopeFile(options, scope)
+	if err != nil {
+		log.Fatal("Failed to prepare scoped run ", err)
+	}
+	defer func() {
+		_ = os.Remove(scopeFile)
+	}()
+
+	script := options.Script
+	options.Script = platform.QuoteForWindows("scoped:" + scopeFile)
+	defer func() {
+		options.Script = script
+	}()
+	return runQodana(ctx, options)
+}
+
 func runScopeScript(ctx context.Context, options *QodanaOptions, startHash string) int {
 	// don't run this logic when we're about to launch a container - it's just double work
 	if options.Ide == "" {
@@ -423,10 +616,21 @@ func writeChangesFile(options *QodanaOptions, start string, end string) (string,
 	if err != nil {
 		return "", err
 	}
+	if len(options.ScopeGlobs) > 0 {
+		changedFiles, err = platform.FilterChangedFiles(changedFiles, options.ProjectDir, options.ScopeGlobs)
+		if err != nil {
+			return "", err
+		}
+	}
 
 	if len(changedFiles.Files) == 0 {
 		return "", fmt.Errorf("nothing to compare between %s and %s", start, end)
 	}
+	return writeScopeFile(options, changedFiles)
+}
+
+// writeScopeFile creates a temp file containing the given changes in the format of the scoped script
+func writeScopeFile(options *QodanaOptions, changedFiles platform.ChangedFiles) (string, error) {
 	file, err := os.CreateTemp("", "diff-scope.txt")
 	if err != nil {
 		return "", err
@@ -462,7 +666,7 @@ func runQodana(ctx context.Context, options *QodanaOptions) int {
 		exitCode = runQodanaContainer(ctx, options)
 	} else if options.Ide != "" {
 		platform.UnsetNugetVariables() // TODO: get rid of it from 241 release
-		exitCode, err = runQodanaLocal(options)
+		exitCode, err = runQodanaLocal(ctx, options)
 		if err != nil {
 			log.Fatal(err)
 		}
@@ -473,8 +677,10 @@ func runQodana(ctx context.Context, options *QodanaOptions) int {
 	return exitCode
 }
 
-// followLinter follows the linter logs and prints the progress.
-func followLinter(client *client.Client, containerName string, progress *pterm.SpinnerPrinter) {
+// followLinter follows the linter logs and prints the progress, analysisStarted is called when the analysis stage begins.
+// The printed lines are filtered with filter if it's not nil, it's closed when the logs end.
+func followLinter(client *client.Client, containerName string, progress *pterm.SpinnerPrinter, analysisStarted func(), filter *linterLogFilter) {
+	defer filter.close()
 	reader, err := client.ContainerLogs(context.Background(), containerName, containerLogsOptions)
 	if err != nil {
 		log.Fatal(err.Error())
@@ -485,6 +691,111 @@ func followLinter(client *client.Client, containerName string, progress *pterm.S
 			log.Fatal(err.Error())
 		}
 	}(reader)
+	scanLinterLog(reader, progress, analysisStarted, filter)
+}
+
+// ContainerLogLevels are the values of --container-log-level from the least to the most verbose.
+var ContainerLogLevels = []string{"error", "warn", "info", "debug"}
+
+// ValidateContainerLogLevel checks the value of --container-log-level, empty value is allowed.
+func ValidateContainerLogLevel(level string) error {
+	if level != "" && !platform.Contains(ContainerLogLevels, platform.Lower(level)) {
+		return fmt.Errorf("unknown container log level %q, expected one of: %s", level, strings.Join(ContainerLogLevels, ", "))
+	}
+	return nil
+}
+
+var (
+	linterLogLevelPattern  = regexp.MustCompile(`\blevel=(\w+)|\b(ERROR|SEVERE|FATAL|WARN|WARNING|INFO|DEBUG|FINE|TRACE)\b`)
+	linterLogLevelsByLabel = map[string]log.Level{
+		"error":   log.ErrorLevel,
+		"severe":  log.ErrorLevel,
+		"fatal":   log.ErrorLevel,
+		"warn":    log.WarnLevel,
+		"warning": log.WarnLevel,
+		"info":    log.InfoLevel,
+		"debug":   log.DebugLevel,
+		"fine":    log.DebugLevel,
+		"trace":   log.DebugLevel,
+	}
+)
+
+// linterLogFilter hides the linter log lines more verbose than level from the output, while all lines are written to file.
+type linterLogFilter struct {
+	level    log.Level
+	previous log.Level
+	file     *os.File
+}
+
+// newLinterLogFilter creates a filter of the linter log by --container-log-level, the full log is written to logDir.
+// It returns nil if the level is not set, so the log is printed as is.
+func newLinterLogFilter(level string, logDir string) (*linterLogFilter, error) {
+	if level == "" {
+		return nil, nil
+	}
+	parsed, err := log.ParseLevel(level)
+	if err != nil {
+		return nil, err
+	}
+	if err = os.MkdirAll(logDir, 0o755); err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(filepath.Join(logDir, "linter-output.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &linterLogFilter{level: parsed, previous: log.ErrorLevel, file: file}, nil
+}
+
+// show writes line to the full log and reports whether it should be printed.
+// Lines without a detected level continue the previous line (e.g. stack traces) if they are indented,
+// other ones (banners, progress, summary) are always printed.
+func (f *linterLogFilter) show(line string) bool {
+	if f == nil {
+		return true
+	}
+	if f.file != nil {
+		if _, err := fmt.Fprintln(f.file, line); err != nil {
+			log.Debugf("Failed to write the linter log: %s", err)
+		}
+	}
+	level, found := linterLogLineLevel(line)
+	if !found {
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") && !strings.HasPrefix(line, "Caused by") {
+			return true
+		}
+		level = f.previous
+	}
+	f.previous = level
+	return level <= f.level
+}
+
+func (f *linterLogFilter) close() {
+	if f == nil || f.file == nil {
+		return
+	}
+	if err := f.file.Close(); err != nil {
+		log.Debugf("Failed to close the linter log: %s", err)
+	}
+}
+
+// linterLogLineLevel detects the level of the linter log line, both IDE (`INFO - ...`) and logrus (`level=info`) formats are supported.
+func linterLogLineLevel(line string) (log.Level, bool) {
+	match := linterLogLevelPattern.FindStringSubmatch(line)
+	if match == nil {
+		return 0, false
+	}
+	label := match[1]
+	if label == "" {
+		label = match[2]
+	}
+	level, ok := linterLogLevelsByLabel[strings.ToLower(label)]
+	return level, ok
+}
+
+// scanLinterLog prints the linter log lines from reader and updates the scan stages.
+func scanLinterLog(reader io.Reader, progress *pterm.SpinnerPrinter, analysisStarted func(), filter *linterLogFilter) {
+	var err error
 	scanner := bufio.NewScanner(reader)
 	interactive := platform.IsInteractive()
 	for scanner.Scan() {
@@ -496,21 +807,24 @@ func followLinter(client *client.Client, containerName string, progress *pterm.S
 		line = strings.TrimSuffix(line, "\n")
 		if err == nil || len(line) > 0 {
 			if strings.Contains(line, "Starting up") {
-				platform.UpdateText(progress, scanStages[2])
+				updateScanStage(progress, 2)
 			}
 			if strings.Contains(line, "The Project opening stage completed in") {
-				platform.UpdateText(progress, scanStages[3])
+				updateScanStage(progress, 3)
 			}
 			if strings.Contains(line, "The Project configuration stage completed in") {
-				platform.UpdateText(progress, scanStages[4])
+				updateScanStage(progress, 4)
+				analysisStarted()
 			}
 			if strings.Contains(line, "Detailed summary") {
-				platform.UpdateText(progress, scanStages[5])
+				updateScanStage(progress, 5)
 				if !platform.IsInteractive() {
 					platform.EmptyMessage()
 				}
 			}
-			platform.PrintLinterLog(line)
+			if filter.show(line) {
+				platform.PrintLinterLog(line)
+			}
 		}
 		if err != nil {
 			if err != io.EOF {
@@ -521,6 +835,45 @@ func followLinter(client *client.Client, containerName string, progress *pterm.S
 	}
 }
 
+// waitForAnalysisStart waits until started is closed and returns false if it didn't happen within timeout.
+// It returns true if ctx is done first: the container has finished and the startup isn't a concern anymore.
+func waitForAnalysisStart(ctx context.Context, started <-chan struct{}, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-started:
+		return true
+	case <-ctx.Done():
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// ProgressReporter receives machine-readable scan stage transitions alongside the spinner,
+// so the tools embedding the CLI don't have to parse the terminal output.
+type ProgressReporter interface {
+	// Stage is called when the scan enters the stage with the given zero-based index.
+	Stage(index int, name string)
+}
+
+// formatScanStage returns the spinner text for the scan stage with the given index.
+func formatScanStage(index int) string {
+	return platform.PrimaryBold("[%d/%d] ", index+1, len(scanStages)+1) + platform.Primary(scanStages[index])
+}
+
+// updateScanStage updates the spinner text and notifies Progress about the stage transition.
+func updateScanStage(progress *pterm.SpinnerPrinter, index int) {
+	platform.UpdateText(progress, formatScanStage(index))
+	reportScanStage(index)
+}
+
+func reportScanStage(index int) {
+	if Progress != nil {
+		Progress.Stage(index, scanStages[index])
+	}
+}
+
 func resetScanStages() {
 	scanStages = []string{
 		"Preparing Qodana Docker images",
@@ -537,6 +890,20 @@ const (
 	nuget = "nuget"
 )
 
+// savePartialResults keeps the results produced before the analysis was stopped by the timeout.
+func savePartialResults(opts *QodanaOptions) {
+	if _, err := os.Stat(opts.GetSarifPath()); err != nil {
+		log.Warnf("No results were produced before the analysis timeout")
+		return
+	}
+	if err := platform.MakeShortSarif(opts.GetSarifPath(), opts.GetShortSarifPath()); err != nil {
+		log.Warnf("Failed to save partial results: %s", err)
+		return
+	}
+	saveReport(opts)
+	log.Printf("Partial results are saved to %s", opts.ResultsDir)
+}
+
 // saveReport saves web files to expect, and generates json.
 func saveReport(opts *QodanaOptions) {
 	if !(platform.IsContainer() && (opts.SaveReport || opts.ShowReport)) {
@@ -549,12 +916,25 @@ func saveReport(opts *QodanaOptions) {
 		return
 	}
 	log.Println("Generating HTML report ...")
-	if res, err := platform.RunCmd("", platform.QuoteForWindows(Prod.JbrJava()), "-jar", platform.QuoteForWindows(reportConverter), "-s", platform.QuoteForWindows(opts.ProjectDir), "-d", platform.QuoteForWindows(opts.ResultsDir), "-o", platform.QuoteForWindows(opts.ReportResultsPath()), "-n", "result-allProblems.json", "-f"); res > 0 || err != nil {
+	if res, err := platform.RunCmd("", platform.QuoteForWindows(opts.javaPath()), "-jar", platform.QuoteForWindows(reportConverter), "-s", platform.QuoteForWindows(opts.ProjectDir), "-d", platform.QuoteForWindows(opts.ResultsDir), "-o", platform.QuoteForWindows(opts.ReportResultsPath()), "-n", "result-allProblems.json", "-f"); res > 0 || err != nil {
 		os.Exit(res)
 	}
-	err := platform.CopyDir(filepath.Join(Prod.Home, "web"), opts.ReportDir)
+	err := copyWebAssets(filepath.Join(Prod.Home, "web"), opts.ReportDir, opts.NoWebAssets)
 	if err != nil {
 		log.Fatal("Not able to save the report: ", err)
 		return
 	}
 }
+
+// copyWebAssets copies the report web assets to reportDir, the copy is skipped if they are missing or not wanted.
+func copyWebAssets(webDir string, reportDir string, noWebAssets bool) error {
+	if noWebAssets {
+		log.Debug("Skipping the report web assets copy because of --no-web-assets")
+		return nil
+	}
+	if _, err := os.Stat(webDir); os.IsNotExist(err) {
+		platform.WarningMessage("Report web assets are not found at %s, the report will contain only the converted results", webDir)
+		return nil
+	}
+	return platform.CopyDir(webDir, reportDir)
+}
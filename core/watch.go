@@ -0,0 +1,181 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package core
+
+import (
+	"context"
+	"fmt"
+	"github.com/JetBrains/qodana-cli/v2024/platform"
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// watchDebounce is the time without changes in the project after which the analysis is re-run.
+	watchDebounce = 2 * time.Second
+	// watchDrainQuiet is the time without events after which the events received during a run are considered drained.
+	watchDrainQuiet = 100 * time.Millisecond
+	// allChecksName is the name of the exclude section of qodana.yaml that applies to all checks.
+	allChecksName = "All"
+)
+
+// watchSkippedDirs are the common build output directories, they aren't watched at any depth of the project.
+var watchSkippedDirs = []string{"bin", "build", "obj", "out", "target"}
+
+// WatchAnalysis calls analyze and calls it again on every change in the project directory, until ctx is done.
+// The paths excluded for all checks in qodana.yaml, the hidden directories, the common build output directories
+// and the output directories aren't watched, and the changes made during a run are dropped, so the files written
+// by the build or the analysis itself don't trigger a new run.
+func WatchAnalysis(ctx context.Context, options *QodanaOptions, analyze func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to watch %s: %w", options.ProjectDir, err)
+	}
+	defer func() {
+		_ = watcher.Close()
+	}()
+	skipped := options.watchSkippedPaths()
+	if err = addWatchDirs(watcher, options.ProjectDir, options.ProjectDir, skipped); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", options.ProjectDir, err)
+	}
+	for {
+		analyze()
+		drainEvents(watcher, options.ProjectDir, skipped)
+		platform.SuccessMessage("Watching %s for changes, press Ctrl+C to stop", options.ProjectDir)
+		if !waitForChanges(ctx, watcher, options.ProjectDir, skipped) {
+			return nil
+		}
+		platform.EmptyMessage()
+		platform.WarningMessage("Changes detected, re-running the analysis")
+	}
+}
+
+// watchSkippedPaths returns the absolute paths not watched for changes.
+func (o *QodanaOptions) watchSkippedPaths() []string {
+	var paths []string
+	for _, exclude := range o.QdConfig.Excludes {
+		if exclude.Name != allChecksName {
+			continue
+		}
+		for _, path := range exclude.Paths {
+			paths = append(paths, filepath.Join(o.ProjectDir, path))
+		}
+	}
+	for _, dir := range []string{o.ResultsDir, o.ReportDir, o.CacheDir} {
+		if dir == "" {
+			continue
+		}
+		if abs, err := filepath.Abs(dir); err == nil {
+			paths = append(paths, abs)
+		}
+	}
+	return paths
+}
+
+// isWatchSkipped reports whether the path is hidden or in a build output directory (relative to the project),
+// in one of the skipped paths or matches one of them as a glob.
+func isWatchSkipped(project string, path string, skipped []string) bool {
+	if rel, err := filepath.Rel(project, path); err == nil && rel != "." {
+		for _, part := range strings.Split(rel, string(filepath.Separator)) {
+			if strings.HasPrefix(part, ".") || platform.Contains(watchSkippedDirs, part) {
+				return true
+			}
+		}
+	}
+	for _, skip := range skipped {
+		if path == skip || strings.HasPrefix(path, skip+string(filepath.Separator)) {
+			return true
+		}
+		if matched, _ := filepath.Match(skip, path); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// addWatchDirs adds root and its subdirectories to the watcher, fsnotify doesn't watch recursively.
+func addWatchDirs(watcher *fsnotify.Watcher, project string, root string, skipped []string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if isWatchSkipped(project, path, skipped) {
+			return filepath.SkipDir
+		}
+		return watcher.Add(path)
+	})
+}
+
+// drainEvents drops the events received during the analysis run, they are mostly caused by the run itself.
+// The directories created meanwhile are still watched.
+func drainEvents(watcher *fsnotify.Watcher, project string, skipped []string) {
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op.Has(fsnotify.Create) && !isWatchSkipped(project, event.Name, skipped) {
+				if err := addWatchDirs(watcher, project, event.Name, skipped); err != nil {
+					log.Debugf("Failed to watch %s: %v", event.Name, err)
+				}
+			}
+		case <-time.After(watchDrainQuiet):
+			return
+		}
+	}
+}
+
+// waitForChanges waits for a change in the watched directories followed by watchDebounce without changes.
+// It returns false if ctx is done or the watcher is closed.
+func waitForChanges(ctx context.Context, watcher *fsnotify.Watcher, project string, skipped []string) bool {
+	var debounce <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-debounce:
+			return true
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return false
+			}
+			log.Warnf("Problems watching the project: %v", err)
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return false
+			}
+			if isWatchSkipped(project, event.Name, skipped) || event.Op == fsnotify.Chmod {
+				continue
+			}
+			log.Debugf("Watch event: %s", event)
+			if event.Op.Has(fsnotify.Create) {
+				if err := addWatchDirs(watcher, project, event.Name, skipped); err != nil {
+					log.Debugf("Failed to watch %s: %v", event.Name, err)
+				}
+			}
+			debounce = time.After(watchDebounce)
+		}
+	}
+}
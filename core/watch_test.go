@@ -0,0 +1,108 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package core
+
+import (
+	"context"
+	"github.com/JetBrains/qodana-cli/v2024/platform"
+	"github.com/fsnotify/fsnotify"
+	"github.com/stretchr/testify/assert"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchSkippedPaths(t *testing.T) {
+	project := t.TempDir()
+	opts := &QodanaOptions{&platform.QodanaOptions{
+		ProjectDir: project,
+		ResultsDir: filepath.Join(project, "results"),
+		QdConfig: platform.QodanaYaml{Excludes: []platform.Clude{
+			{Name: "All", Paths: []string{"build", "generated/*.java"}},
+			{Name: "ConstantValue", Paths: []string{"src"}},
+		}},
+	}}
+	skipped := opts.watchSkippedPaths()
+
+	for path, expected := range map[string]bool{
+		"src/Main.java":             false,
+		"build/classes/Main.class":  true,
+		"generated/Parser.java":     true,
+		".idea/workspace.xml":       true,
+		"results/qodana.sarif.json": true,
+		"buildSrc/Plugin.java":      false,
+		"app/target/Main.class":     true,
+		"web/bin/Debug/app.dll":     true,
+		"src/output/Main.java":      false,
+	} {
+		assert.Equal(t, expected, isWatchSkipped(project, filepath.Join(project, path), skipped), path)
+	}
+}
+
+func TestWaitForChanges(t *testing.T) {
+	project := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(project, "build"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = watcher.Close() }()
+	skipped := []string{filepath.Join(project, "build")}
+	if err = addWatchDirs(watcher, project, project, skipped); err != nil {
+		t.Fatal(err)
+	}
+	assert.NotContains(t, watcher.WatchList(), filepath.Join(project, "build"))
+
+	go func() {
+		_ = os.WriteFile(filepath.Join(project, "build", "Main.class"), []byte{}, 0o644)
+		_ = os.WriteFile(filepath.Join(project, "Main.java"), []byte("class Main {}"), 0o644)
+	}()
+	assert.True(t, waitForChanges(context.Background(), watcher, project, skipped))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.False(t, waitForChanges(ctx, watcher, project, skipped), "no changes until the context is done")
+}
+
+func TestDrainEvents(t *testing.T) {
+	project := t.TempDir()
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = watcher.Close() }()
+	if err = addWatchDirs(watcher, project, project, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// the changes made during a run
+	if err = os.MkdirAll(filepath.Join(project, "src"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(filepath.Join(project, "Main.java"), []byte("class Main {}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	drainEvents(watcher, project, nil)
+	assert.Contains(t, watcher.WatchList(), filepath.Join(project, "src"), "the new directory is watched")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.False(t, waitForChanges(ctx, watcher, project, nil), "the changes made during the run are dropped")
+}
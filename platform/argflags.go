@@ -34,74 +34,173 @@ func ComputeFlags(cmd *cobra.Command, options *QodanaOptions) error {
 	flags.StringVar(&options.Ide, "ide", os.Getenv(QodanaDistEnv), fmt.Sprintf("Use to run Qodana without a container. Not compatible with --linter option. Available codes are %s, add -EAP part to obtain EAP versions", strings.Join(AllNativeCodes, ", ")))
 
 	flags.StringVarP(&options.ProjectDir, "project-dir", "i", ".", "Root directory of the inspected project")
-	flags.StringVarP(&options.ResultsDir, "results-dir", "o", "", "Override directory to save Qodana inspection results to (default <userCacheDir>/JetBrains/<linter>/results)")
+	flags.StringVarP(&options.ResultsDir, "results-dir", "o", "", "Override directory to save Qodana inspection results to (default <userCacheDir>/JetBrains/<linter>/results). Can contain the tokens {"+strings.Join(OutputDirTokens, "}, {")+"}, e.g. results/{linter}/{date}")
 	flags.StringVar(&options.CacheDir, "cache-dir", "", "Override cache directory (default <userCacheDir>/JetBrains/<linter>/cache)")
-	flags.StringVarP(&options.ReportDir, "report-dir", "r", "", "Override directory to save Qodana HTML report to (default <userCacheDir>/JetBrains/<linter>/results/report)")
+	flags.BoolVar(&options.CacheDirShared, "cache-dir-shared", false, "Lock the cache directory for the run, so concurrent runs sharing the same --cache-dir (e.g. on a build agent) wait for each other instead of corrupting the caches")
+	flags.IntVar(&options.CacheLockTimeoutMs, "cache-lock-timeout", -1, "Time limit in milliseconds to wait for another run to release the shared cache directory (requires --cache-dir-shared), 0 – fail immediately. Negative – no timeout")
+	flags.StringVar(&options.JavaHome, "java-home", "", "Use the Java installation from the given directory instead of the bundled JBR for the native runs (report conversion, publishing, third-party linters)")
+	flags.StringVar(&options.OutputRoot, "output-root", "", "Put the results, report, coverage and cache directories under the given directory (<root>/results, <root>/report, <root>/coverage, <root>/cache) unless they are set individually")
+	flags.StringVarP(&options.ReportDir, "report-dir", "r", "", "Override directory to save Qodana HTML report to (default <userCacheDir>/JetBrains/<linter>/results/report). Can contain the same tokens as --results-dir")
+	flags.StringVar(&options.ReportZip, "report-zip", "", "Additionally pack the HTML report into the given zip archive (e.g. report.zip) for uploading as a single artifact. The archive is reproducible: same report, same archive")
+	flags.BoolVar(&options.ReportZipOnly, "report-zip-only", false, "Remove the HTML report directory after packing it with --report-zip")
+	flags.StringVar(&options.S3Upload, "s3-upload", "", "Upload the SARIF, short SARIF and HTML reports to S3 or an S3-compatible storage in the [s3://]bucket[/prefix] format. The credentials are read from AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, AWS_REGION, and AWS_ENDPOINT_URL for other storages")
 
 	flags.BoolVar(&options.PrintProblems, "print-problems", false, "Print all found problems by Qodana in the CLI output")
+	flags.StringVar(&options.PrintFormat, "print-format", "", "Print problems one per line using the given template instead of the default output (requires --print-problems), e.g. '{severity}\\t{file}:{line}\\t{ruleId}'. Available tokens: {"+strings.Join(ProblemFormatTokens, "}, {")+"}")
+	flags.BoolVar(&options.CollapseRepeated, "collapse-repeated", false, "Print consecutive problems with the same rule and message as one line with the number of occurrences and the files (requires --print-problems). The report stays complete")
+	flags.StringVar(&options.ProblemsGroupBy, "problems-group-by", "", "Print problems in groups by "+strings.Join(ProblemsGroupByValues, ", ")+" with the number of problems in each group (requires --print-problems)")
+	flags.IntVar(&options.ProblemsLimit, "problems-limit", 0, "Print at most the given number of problems, in each group with --problems-group-by (requires --print-problems). The report stays complete")
 	flags.BoolVar(&options.GenerateCodeClimateReport, "code-climate", isGitLab(), "Generate a Code Climate report in SARIF format (compatible with GitLab Code Quality), will be saved to the results directory (default true if Qodana is executed on GitLab CI)")
 	flags.BoolVar(&options.SendBitBucketInsights, "bitbucket-insights", isBitBucket(), "Send the results BitBucket Code Insights, no additional configuration required if ran in BitBucket Pipelines (default true if Qodana is executed on BitBucket Pipelines)")
+	flags.BoolVar(&options.AzureAnnotations, "azure-annotations", isAzure(), "Print the new problems as Azure Pipelines logging commands to show them in the build summary (default true if Qodana is executed on Azure Pipelines)")
+	flags.StringVar(&options.UriBase, "uri-base", "", "Link the printed problems and BitBucket Code Insights annotations to the files under the given URL, e.g. 'https://github.com/org/repo/blob/{revision}'. {revision} and {branch} are replaced with the analyzed revision and branch")
 	flags.BoolVar(&options.ClearCache, "clear-cache", false, "Clear the local Qodana cache before running the analysis")
+	flags.BoolVar(&options.CleanResults, "clean-results", false, "Remove the contents of the results directory before running the analysis, so the reports of the previous runs don't mix with the new ones. The root, home and project directories are never cleaned")
 	flags.BoolVarP(&options.ShowReport, "show-report", "w", false, "Serve HTML report on port")
+	flags.BoolVar(&options.Watch, "watch", false, "Only for native analyzers. Watch the project directory and re-run the analysis on changes, printing the problems found, until Ctrl+C. The paths excluded for All checks in qodana.yaml and the hidden directories are not watched")
 	flags.IntVar(&options.Port, "port", 8080, "Port to serve the report on")
-	flags.StringVar(&options.ConfigName, "config", "", "Set a custom configuration file instead of 'qodana.yaml'. Relative paths in the configuration will be based on the project directory.")
+	flags.BoolVar(&options.PortAuto, "port-auto", false, "Serve the report on the next free port if --port is busy")
+	flags.StringVar(&options.ReportHost, "report-host", "", "Address to serve the report on, e.g. localhost (default all interfaces)")
+	flags.StringVar(&options.ReportUser, "report-user", "", "User name of the basic auth for the served report, used with --report-password")
+	flags.StringVar(&options.ReportPassword, "report-password", "", "Require the basic auth with --report-user and the given password to view the served report")
+	flags.StringVar(&options.ConfigName, "config", "", "Set a custom configuration file instead of 'qodana.yaml'. Relative paths in the configuration will be based on the project directory, except profile.path which is based on the directory of the configuration file. Takes precedence over the "+QodanaConfigNameEnv+" list of file names")
+	flags.StringVar(&options.ConfigOverride, "config-override", "", "Merge the given configuration file onto qodana.yaml (or --config): mappings are merged, scalar values are overridden and lists are appended")
+	flags.IntVar(&options.MaxRuntimeNotifications, "max-runtime-notifications", 0, "Override maxRuntimeNotifications from qodana.yaml: the maximum number of internal errors to collect in the report")
+	flags.StringVar(&options.FailOnErrorNotification, "fail-on-error-notification", "", "Override failOnErrorNotification from qodana.yaml: fail the run with exit code 70 if any internal error is encountered. Use --fail-on-error-notification=false to disable it")
+	flags.Lookup("fail-on-error-notification").NoOptDefVal = "true"
+	flags.StringArrayVar(&options.Includes, "include", []string{}, "Include the check in the analysis, in the name:path format like the 'include' section of qodana.yaml, e.g. --include JavaDocReference:src/main. Omit the path to include the check for the whole project. Can be repeated, appended to qodana.yaml")
+	flags.StringArrayVar(&options.Excludes, "exclude", []string{}, "Exclude the check from the analysis, in the name:path format like the 'exclude' section of qodana.yaml, e.g. --exclude All:src/generated. Omit the path to exclude the check for the whole project. Can be repeated, appended to qodana.yaml")
 
 	flags.StringVarP(&options.AnalysisId, "analysis-id", "a", uuid.New().String(), "Unique report identifier (GUID) to be used by Qodana Cloud")
+	flags.BoolVar(&options.AnalysisIdFromCommit, "analysis-id-from-commit", false, "Derive the report identifier (GUID) from the repository remote url and the analyzed commit instead of a random one, so the runs of the same commit are grouped in Qodana Cloud")
+	flags.StringVar(&options.AnalysisName, "analysis-name", "", "Human-friendly name of the analysis (e.g. 'nightly main') stored in the report next to the analysis id")
 	flags.StringVarP(&options.Baseline, "baseline", "b", "", "Provide the path to an existing SARIF report to be used in the baseline state calculation")
+	flags.BoolVar(&options.BaselineAuto, "baseline-auto", false, "Use the SARIF report of the latest analysis of the project on Qodana Cloud as the baseline. Requires "+QodanaToken+", the analysis runs without a baseline if the project has no reports yet")
 	flags.BoolVar(&options.BaselineIncludeAbsent, "baseline-include-absent", false, "Include in the output report the results from the baseline run that are absent in the current run")
+	flags.StringVar(&options.AbsentMinSeverity, "absent-min-severity", "", "Include only the absent results of the given or higher severity (critical, high, moderate, low, info) when --baseline-include-absent is set. By default, absent results of all severities are included")
+	flags.StringVar(&options.BaselineMatch, "baseline-match", BaselineMatchFingerprint, "Strategy to match the results with the baseline: 'fingerprint' (default) or 'content' to match by rule, message and code snippet, so problems in renamed files stay unchanged")
+	flags.BoolVar(&options.OnlyNew, "only-new", false, "Consider only the new problems (not present in the baseline) in the printed problems, the CodeClimate report, BitBucket Code Insights and Azure Pipelines annotations, by default only the unchanged ones are skipped")
+	flags.BoolVar(&options.AnnotateBaselineState, "annotate-baseline-state", false, "Prefix the messages of the results in the SARIF report with their baseline state ([NEW], [UNCHANGED], [ABSENT]) for the tools that don't support SARIF baselineState")
+	flags.BoolVar(&options.BaselinePruneWhenClean, "baseline-prune-when-clean", false, "Empty the baseline report (--baseline) when all its problems are fixed and there are no new ones. Ignored for the runs on changed files only (--diff-start, --commit)")
+	flags.BoolVar(&options.BaselineCreateIfMissing, "baseline-create-if-missing", false, "If the baseline report (--baseline) doesn't exist, save the report of this run there and treat all its problems as unchanged, so the next runs are compared with it. This intentionally weakens the quality gate of the first run. Ignored for the runs on changed files only (--diff-start, --commit)")
+	flags.BoolVar(&options.BaselineNetGate, "baseline-net-gate", false, "Report both new and fixed problems compared to the baseline and fail the run (exit code 255) if there are more new problems than fixed ones. Implies --baseline-include-absent")
+	flags.StringArrayVar(&options.IgnoreRules, "ignore-rule", []string{}, "Drop the results of the given rule id from the reports after the analysis, like 'ruleIgnores' in qodana.yaml. Unlike disabling the inspection in the profile, the inspection still runs, but its results are not printed, exported or counted for the quality gate. Can be repeated")
+	flags.StringArrayVar(&options.BaselineIgnoreRules, "baseline-ignore-rule", []string{}, "Treat the new problems of the given rule id as baselined: they stay in the report but are not counted as new and don't fail the run. Can be repeated")
 	flags.BoolVar(&options.FullHistory, "full-history", false, "Go through the full commit history and run the analysis on each commit. If combined with `--commit`, analysis will be started from the given commit. Could take a long time.")
+	flags.BoolVar(&options.ResultsDirPerCommit, "results-dir-per-commit", false, "With `--full-history`, keep the SARIF reports of every analyzed commit in <results-dir>/history/<commit>/, the results directory itself contains the reports of the last commit. Requires disk space for a pair of reports per commit")
 	flags.StringVar(&options.Commit, "commit", "", "Base changes commit to reset to, resets git and starts a diff run: analysis will be run only on changed files since the given commit. If combined with `--full-history`, full history analysis will be started from the given commit.")
+	flags.BoolVar(&options.ExcludeGenerated, "exclude-generated", false, "Drop the problems found in generated files (protobuf, *.g.dart, *.Designer.cs, etc.) from the results. Additional patterns can be set with 'generatedFiles' in qodana.yaml")
 	flags.StringVar(&options.FailThreshold, "fail-threshold", "", "Set the number of problems that will serve as a quality gate. If this number is reached, the inspection run is terminated with a non-zero exit code")
+	flags.StringSliceVar(&options.FailOnSeverity, "fail-on-severity", []string{}, "Fail the run (exit code 255) if at least one new problem of the given severities is found, e.g. --fail-on-severity critical,high. Problems present in the baseline are not counted. Overrides the thresholds for these severities from qodana.yaml")
+	flags.BoolVar(&options.FailOnLicenseViolation, "fail-on-license-violation", false, fmt.Sprintf("Fail the run (exit code %d) if at least one new dependency license violation is found, i.e. a new problem of the license audit rules: %s. Problems present in the baseline are not counted", QodanaLicenseViolationExitCode, strings.Join(LicenseRuleIds, ", ")))
+	flags.StringSliceVar(&options.SeverityExitCodes, "severity-exit-codes", []string{}, "Exit with the code of the most severe new problem found, in the severity=code format, e.g. --severity-exit-codes critical=10,high=11. Takes precedence over --fail-threshold and --fail-on-severity, the codes are not changed by --exit-code-map")
 	flags.BoolVar(&options.DisableSanity, "disable-sanity", false, "Skip running the inspections configured by the sanity profile")
 	flags.StringVarP(&options.SourceDirectory, "source-directory", "d", "", "Directory inside the project-dir directory must be inspected. If not specified, the whole project is inspected")
+	flags.StringArrayVar(&options.ScopeGlobs, "scope-glob", []string{}, "Inspect only the files matching the glob relative to the project-dir directory, e.g. 'src/**/*.kt' (you can use the flag multiple times). With --source-directory, only the matching files inside the source directory are inspected. With --diff-start or --commit, only the matching changed files are inspected")
 	flags.StringVarP(&options.ProfileName, "profile-name", "n", "", "Profile name defined in the project")
+	flags.BoolVar(&options.ListProfiles, "list-profiles", false, "Print the profile names available for --profile-name (bundled, shipped with the --ide distribution and project ones) and exit")
 	flags.StringVarP(&options.ProfilePath, "profile-path", "p", "", "Path to the profile file")
 	flags.StringVar(&options.RunPromo, "run-promo", "", "Set to 'true' to have the application run the inspections configured by the promo profile; set to 'false' otherwise (default: 'true' only if Qodana is executed with the default profile)")
 	flags.StringVar(&options.Script, "script", "default", "Override the run scenario")
 	flags.StringVar(&options.StubProfile, "stub-profile", "", "Absolute path to the fallback profile file. This option is applied in case the profile was not specified using any available options")
-	flags.StringVar(&options.CoverageDir, "coverage-dir", "", "Directory with coverage data to process")
+	flags.StringVar(&options.SbomOutput, "sbom-output", "", "Save the software bill of materials (SBOM) to the given path, relative paths are resolved against the results directory")
+	flags.StringVar(&options.SbomFormat, "sbom-format", "", "Format of the software bill of materials: spdx or cyclonedx (SPDX is supported by JVM, JS, Python, PHP and Go linters, CycloneDX additionally by .NET linters)")
+	flags.StringVar(&options.CoverageDir, "coverage-dir", "", "Directory with coverage data to process. If not set, the default coverage directory is used if it has any data, otherwise the directory of the first jacoco.xml, coverage.xml or lcov.info found in the project")
 
 	flags.BoolVar(&options.ApplyFixes, "apply-fixes", false, "Apply all available quick-fixes, including cleanup")
 	flags.BoolVar(&options.Cleanup, "cleanup", false, "Run project cleanup")
 	flags.StringVar(&options.FixesStrategy, "fixes-strategy", "", "Set the strategy for applying quick-fixes. Available values: 'apply', 'cleanup', 'none'")
 
-	flags.StringArrayVar(&options.Property, "property", []string{}, "Set a JVM property to be used while running Qodana using the --property property.name=value1,value2,...,valueN notation")
+	flags.StringArrayVar(&options.PluginsFromFiles, "plugin-from-file", []string{}, "Install a plugin from the given local zip archive before the analysis (you can use the flag multiple times)")
+	flags.StringArrayVar(&options.CaCerts, "ca-cert", []string{}, "Trust the CA certificates from the given PEM file, e.g. of a corporate proxy (you can use the flag multiple times). They are added to the system ones and passed to the analysis via SSL_CERT_FILE, NODE_EXTRA_CA_CERTS and a Java trust store in JAVA_TOOL_OPTIONS, container runs get the files mounted")
+	flags.BoolVar(&options.DryRun, "dry-run", false, "Print the resolved linter command and properties without running the analysis or writing any files")
+	flags.StringArrayVar(&options.LinterArgs, "linter-arg", []string{}, "Pass an extra argument to the linter verbatim, after the arguments generated by the CLI (you can use the flag multiple times). The arguments are not validated and may conflict with the options set by other flags")
+	flags.StringArrayVar(&options.Property, "property", []string{}, "Set a JVM property to be used while running Qodana using the --property property.name=value1,value2,...,valueN notation. A property without a value (--property property.name) is passed as the -Dproperty.name flag, JVM options starting with '-' (e.g. --property -Xmx4g) are passed as is")
+	flags.StringArrayVar(&options.SarifRebaseUris, "sarif-rebase-uris", []string{}, "Rewrite the artifact URIs in the SARIF report in the from=to format, e.g. '/data/project=.' makes the paths of a container run relative. Can be specified multiple times, the first matching prefix is used")
+	flags.BoolVar(&options.InitSubmodules, "init-submodules", false, "Run 'git submodule update --init --recursive' in the project directory before the analysis, so the sources of the submodules are analyzed too. Requires git")
+	flags.BoolVar(&options.DedupHighestSeverity, "dedup-highest-severity", false, "When merging the SARIF reports of third-party linters, keep the most severe of the problems with the same fingerprint instead of the first one")
+	flags.Int64Var(&options.SarifSplitSize, "sarif-split-size", 0, "If the SARIF report is larger than the given size in bytes, additionally write its results into several qodana.part-N.sarif.json files not exceeding this size. 0 – don't split")
 	flags.BoolVarP(&options.SaveReport, "save-report", "s", true, "Generate HTML report")
+	flags.BoolVar(&options.NoWebAssets, "no-web-assets", false, "Don't copy the web assets to the HTML report, useful for minimal images without them")
 
 	flags.IntVar(&options.AnalysisTimeoutMs, "timeout", -1, "Qodana analysis time limit in milliseconds. If reached, the analysis is terminated, process exits with code timeout-exit-code. Negative – no timeout")
 	flags.IntVar(&options.AnalysisTimeoutExitCode, "timeout-exit-code", 1, "See timeout option")
+	flags.StringSliceVar(&options.ExitCodeMap, "exit-code-map", []string{}, "Map Qodana exit codes to custom ones in the from=to format, e.g. --exit-code-map 255=10,70=11. Unmapped codes are returned unchanged. The timeout exit code (--timeout-exit-code) is not mapped")
 
 	flags.StringVar(&options.DiffStart, "diff-start", "", "Commit to start a diff run from. Only files changed between --diff-start and --diff-end will be analysed.")
 	flags.StringVar(&options.DiffEnd, "diff-end", "", "Commit to end a diff run on. Only files changed between --diff-start and --diff-end will be analysed.")
+	flags.StringVar(&options.DiffBaseBranch, "diff-base-branch", "", "Branch to compare the current one with in a diff run, e.g. main: --diff-start is set to the merge base of HEAD and the branch, --diff-end to HEAD")
 	flags.BoolVar(&options.ForceLocalChangesScript, "force-local-changes-script", false, "Override the default run-scenario for diff runs to always use the local-changes script")
 
+	flags.StringVar(&options.ResultUmask, "result-umask", "", "Octal umask (e.g. 0002) to apply to the files written by the analysis, so the results are readable by other users of the group")
+
 	flags.IntVar(&options.JvmDebugPort, "jvm-debug-port", -1, "Enable JVM remote debug under given port")
 
-	flags.BoolVar(&options.NoStatistics, "no-statistics", false, "[qodana-clang/qodana-dotner]Disable sending anonymous statistics")
+	flags.BoolVar(&options.NoStatistics, "no-statistics", false, "Disable sending anonymous statistics")
 	flags.StringVar(&options.ClangCompileCommands, "compile-commands", "./build/compile_commands.json", "[qodana-clang specific] Path to compile_commands.json")
 	flags.StringVar(&options.ClangArgs, "clang-args", "", "[qodana-clang specific] Additional arguments for clang")
+	flags.StringVar(&options.ClangCompileDbCommand, "clang-db-generate", "", "[qodana-clang specific] Command to run before the analysis to generate compile_commands.json (e.g. 'cmake -B build -DCMAKE_EXPORT_COMPILE_COMMANDS=ON')")
 	flags.StringVar(&options.CdnetSolution, "solution", "", "[qodana-cdnet specific] Relative path to solution file")
 	flags.StringVar(&options.CdnetProject, "project", "", "[qodana-cdnet specific] Relative path to project file")
 	flags.StringVar(&options.CdnetConfiguration, "configuration", "", "[qodana-cdnet specific] Build configuration")
 	flags.StringVar(&options.CdnetPlatform, "platform", "", "[qodana-cdnet specific] Build platform")
 	flags.BoolVar(&options.CdnetNoBuild, "no-build", false, "[qodana-cdnet specific] Do not build the project before analysis")
+	flags.StringArrayVar(&options.CdnetTargetFrameworks, "framework", []string{}, "[qodana-dotnet specific] Target framework monikers to analyze, e.g. --framework net8.0 (can be repeated or semicolon-separated, '!' excludes a framework). Overrides dotnet.frameworks from qodana.yaml")
 
 	if !IsContainer() {
 		flags.StringArrayVarP(&options.Env, "env", "e", []string{}, "Only for container runs. Define additional environment variables for the Qodana container (you can use the flag multiple times). CLI is not reading full host environment variables and does not pass it to the Qodana container for security reasons")
-		flags.StringArrayVarP(&options.Volumes, "volume", "v", []string{}, "Only for container runs. Define additional volumes for the Qodana container (you can use the flag multiple times)")
+		flags.StringArrayVarP(&options.Volumes, "volume", "v", []string{}, "Only for container runs. Define additional volumes for the Qodana container as src:dst[:ro|rw] (you can use the flag multiple times)")
 		flags.StringVarP(&options.User, "user", "u", GetDefaultUser(), "Only for container runs. User to run Qodana container as. Please specify user id – '$UID' or user id and group id $(id -u):$(id -g). Use 'root' to run as the root user (default: the current user)")
 		flags.BoolVar(&options.SkipPull, "skip-pull", false, "Only for container runs. Skip pulling the latest Qodana container")
+		flags.BoolVar(&options.SkipPreflight, "skip-preflight", false, "Only for container runs. Skip checking that the cache, project and results directories exist and are writable by the container user (--user)")
+		flags.BoolVar(&options.ContainerPrivileged, "container-privileged", false, "Only for container runs. Run the Qodana container in privileged mode (docker run --privileged). Security risk: the container gets full access to the host, use only for debugging linters")
+		flags.StringArrayVar(&options.ContainerGroupAdd, "container-group-add", []string{}, "Only for container runs. Add the container user to the given supplementary group (docker run --group-add), e.g. to write to the group-owned cache or results directories. Can be specified multiple times")
+		flags.StringArrayVar(&options.ContainerLabels, "container-label", []string{}, "Only for container runs. Set a key=value label on the Qodana container (docker run --label), e.g. to find the containers of a CI job. Can be specified multiple times")
+		flags.StringVar(&options.ContainerNamePrefix, "container-name-prefix", "", "Only for container runs. Prepend the prefix to the container name, qodana-cli-<id> or the "+QodanaCliContainerName+" value, e.g. 'ci-job-42-'")
+		flags.StringVar(&options.MountGit, "mount-git", "", "Only for container runs. Mount the given git directory as the .git of the project in the container, e.g. when the project directory is a copy without the git metadata required by the diff runs (--diff-start, --commit, --full-history)")
+		flags.BoolVar(&options.ProjectReadOnly, "readonly-project", false, "Only for container runs. Mount the project directory read-only, so the analysis can't modify the sources. Can't be used with the fixes (--apply-fixes, --cleanup)")
+		flags.BoolVar(&options.ProjectReadOnlyNoFixes, "project-readonly", false, "Only for container runs. Mount the project directory read-only, so the analysis can't modify the sources. Ignored when the fixes are applied (--apply-fixes, --cleanup)")
+		flags.StringVar(&options.StreamResultsDir, "stream-results-dir", "", "Only for container runs. Periodically copy the partial qodana-short.sarif.json from the results directory to the given directory while the analysis is running")
+		flags.StringVar(&options.Exec, "exec", "", "Only for container runs. Instead of running the analysis, print the 'docker run' command that starts the given command (e.g. 'bash') in the Qodana container with the same mounts and environment")
+		flags.IntVar(&options.StartupTimeoutMs, "startup-timeout", -1, "Only for container runs. Time limit in milliseconds for the linter to start and open the project (e.g. plugin installation hangs). If reached before the analysis begins, the container is stopped and the process exits with code timeout-exit-code. Negative – no timeout")
+		flags.StringVar(&options.ContainerLogLevel, "container-log-level", "", "Only for container runs. Print only the linter log lines of the given level (error, warn, info, debug) or more severe, the lines without a level are always printed. The full log is written to linter-output.log in the results log directory. By default, the whole log is printed")
+		flags.BoolVar(&options.RequirePinnedImage, "require-pinned-image", false, "Only for container runs. Fail if the linter image is not pinned to an exact version tag or a digest (image@sha256:...)")
+		flags.BoolVar(&options.NoProxyPassthrough, "no-proxy-passthrough", false, "Only for container runs. Don't pass the HTTP_PROXY, HTTPS_PROXY and NO_PROXY variables of the host to the container")
 		cmd.MarkFlagsMutuallyExclusive("linter", "ide")
 		cmd.MarkFlagsMutuallyExclusive("skip-pull", "ide")
+		cmd.MarkFlagsMutuallyExclusive("skip-preflight", "ide")
+		cmd.MarkFlagsMutuallyExclusive("require-pinned-image", "ide")
+		cmd.MarkFlagsMutuallyExclusive("container-log-level", "ide")
+		cmd.MarkFlagsMutuallyExclusive("no-proxy-passthrough", "ide")
+		cmd.MarkFlagsMutuallyExclusive("container-privileged", "ide")
+		cmd.MarkFlagsMutuallyExclusive("container-group-add", "ide")
+		cmd.MarkFlagsMutuallyExclusive("container-label", "ide")
+		cmd.MarkFlagsMutuallyExclusive("container-name-prefix", "ide")
+		cmd.MarkFlagsMutuallyExclusive("mount-git", "ide")
+		cmd.MarkFlagsMutuallyExclusive("readonly-project", "ide")
+		cmd.MarkFlagsMutuallyExclusive("project-readonly", "ide")
+		cmd.MarkFlagsMutuallyExclusive("readonly-project", "project-readonly")
+		cmd.MarkFlagsMutuallyExclusive("stream-results-dir", "ide")
+		cmd.MarkFlagsMutuallyExclusive("exec", "ide")
+		cmd.MarkFlagsMutuallyExclusive("startup-timeout", "ide")
 		cmd.MarkFlagsMutuallyExclusive("volume", "ide")
 		cmd.MarkFlagsMutuallyExclusive("user", "ide")
 		cmd.MarkFlagsMutuallyExclusive("env", "ide")
+		if err := cmd.Flags().MarkDeprecated("project-readonly", "use --readonly-project instead, it fails with the fixes instead of mounting the project writable"); err != nil {
+			return err
+		}
 	}
 
 	cmd.MarkFlagsMutuallyExclusive("script", "force-local-changes-script", "full-history")
 	cmd.MarkFlagsMutuallyExclusive("commit", "script", "diff-start")
+	cmd.MarkFlagsMutuallyExclusive("diff-base-branch", "diff-start")
+	cmd.MarkFlagsMutuallyExclusive("diff-base-branch", "diff-end")
 	cmd.MarkFlagsMutuallyExclusive("profile-name", "profile-path")
 	cmd.MarkFlagsMutuallyExclusive("apply-fixes", "cleanup")
+	cmd.MarkFlagsMutuallyExclusive("report-zip-only", "show-report")
+	cmd.MarkFlagsMutuallyExclusive("baseline", "baseline-auto")
+	cmd.MarkFlagsMutuallyExclusive("baseline-create-if-missing", "baseline-auto")
+	cmd.MarkFlagsMutuallyExclusive("analysis-id", "analysis-id-from-commit")
 
 	err := cmd.Flags().MarkDeprecated("fixes-strategy", "use --apply-fixes / --cleanup instead")
 	if err != nil {
@@ -18,6 +18,15 @@ package platform
 
 import (
 	"fmt"
+	"github.com/JetBrains/qodana-cli/v2024/sarif"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	BaselineMatchFingerprint = "fingerprint" // BaselineMatchFingerprint matches results by their fingerprints (default)
+	BaselineMatchContent     = "content"     // BaselineMatchContent matches results by rule, message and code snippet
 )
 
 // computeBaselinePrintResults runs SARIF analysis (compares with baseline and prints the result)=
@@ -27,11 +36,17 @@ func computeBaselinePrintResults(options *QodanaOptions, mountInfo *MountInfo, t
 	for _, sev := range severities {
 		args = append(args, sev)
 	}
-	if options.Baseline != "" {
-		args = append(args, "-b", QuoteForWindows(options.Baseline))
-	}
-	if options.BaselineIncludeAbsent {
-		args = append(args, "-i")
+	if options.Baseline != "" && options.BaselineMatch == BaselineMatchContent {
+		if err := ApplyContentBaseline(options.GetSarifPath(), options.BaselinePath(), options.BaselineIncludeAbsent); err != nil {
+			return -1, err
+		}
+	} else {
+		if options.Baseline != "" {
+			args = append(args, "-b", QuoteForWindows(options.Baseline))
+		}
+		if options.BaselineIncludeAbsent {
+			args = append(args, "-i")
+		}
 	}
 	_, _, ret, err := LaunchAndLog(options, "baseline", args...)
 	if err != nil {
@@ -45,3 +60,327 @@ func computeBaselinePrintResults(options *QodanaOptions, mountInfo *MountInfo, t
 	}
 	return ret, nil
 }
+
+// BaselineSummary holds the number of new and fixed (absent) problems compared to the baseline.
+type BaselineSummary struct {
+	New   int
+	Fixed int
+}
+
+// Net returns the difference between new and fixed problems, a positive value means the run is worse than the baseline.
+func (s BaselineSummary) Net() int {
+	return s.New - s.Fixed
+}
+
+// CountBaselineStates counts new and absent results in the report at sarifPath.
+func CountBaselineStates(sarifPath string) (BaselineSummary, error) {
+	summary := BaselineSummary{}
+	report, err := ReadReport(sarifPath)
+	if err != nil {
+		return summary, fmt.Errorf("error reading SARIF %s: %w", sarifPath, err)
+	}
+	for _, run := range report.Runs {
+		for _, r := range run.Results {
+			switch r.BaselineState {
+			case baselineStateNew:
+				summary.New++
+			case baselineStateAbsent:
+				summary.Fixed++
+			}
+		}
+	}
+	return summary, nil
+}
+
+// CheckBaselineNetGate prints the baseline summary of the report at sarifPath and returns
+// QodanaFailThresholdExitCode if there are more new problems than fixed ones, otherwise exitCode is returned as is.
+func CheckBaselineNetGate(sarifPath string, exitCode int) (int, error) {
+	summary, err := CountBaselineStates(sarifPath)
+	if err != nil {
+		return exitCode, err
+	}
+	message := fmt.Sprintf("Compared to the baseline: %d new, %d fixed (net %+d)", summary.New, summary.Fixed, summary.Net())
+	if summary.Net() > 0 {
+		ErrorMessage(message)
+		return QodanaFailThresholdExitCode, nil
+	}
+	SuccessMessage(message)
+	return exitCode, nil
+}
+
+// IgnoreBaselineRules marks the new results of the given rules in the SARIF report as unchanged,
+// so they stay in the report but are treated as baselined. It returns the number of the ignored results.
+func IgnoreBaselineRules(sarifPath string, ruleIds []string) (int, error) {
+	if len(ruleIds) == 0 {
+		return 0, nil
+	}
+	report, err := ReadReport(sarifPath)
+	if err != nil {
+		return 0, err
+	}
+	ignored := 0
+	for i := range report.Runs {
+		for j := range report.Runs[i].Results {
+			r := &report.Runs[i].Results[j]
+			if isNewResult(r) && Contains(ruleIds, r.RuleId) {
+				r.BaselineState = baselineStateUnchanged
+				ignored++
+			}
+		}
+	}
+	if ignored == 0 {
+		return 0, nil
+	}
+	return ignored, WriteReport(sarifPath, report)
+}
+
+// ApplyBaselineIgnoreRules applies --baseline-ignore-rule to the report at sarifPath. If the run failed
+// with QodanaFailThresholdExitCode, the thresholds are checked again without the ignored results.
+func ApplyBaselineIgnoreRules(sarifPath string, ruleIds []string, thresholds map[string]string, exitCode int) (int, error) {
+	ignored, err := IgnoreBaselineRules(sarifPath, ruleIds)
+	if err != nil || ignored == 0 || exitCode != QodanaFailThresholdExitCode {
+		return exitCode, err
+	}
+	exceeded, err := ExceedsFailureThresholds(sarifPath, thresholds)
+	if err != nil {
+		return exitCode, err
+	}
+	if exceeded {
+		return exitCode, nil
+	}
+	return QodanaSuccessExitCode, nil
+}
+
+// PruneBaseline applies --baseline-prune-when-clean to the baseline of the run with the report at sarifPath.
+// The runs on changed files only are skipped: their clean report doesn't mean the whole baseline is fixed.
+func PruneBaseline(options *QodanaOptions, sarifPath string) error {
+	if !options.BaselinePruneWhenClean || options.Baseline == "" || options.DiffStart != "" || options.Commit != "" {
+		return nil
+	}
+	pruned, err := PruneBaselineWhenClean(sarifPath, options.BaselinePath())
+	if err != nil {
+		return err
+	}
+	if pruned {
+		SuccessMessage("All problems from the baseline are fixed, %s is emptied", options.Baseline)
+	}
+	return nil
+}
+
+// PrepareBaselineSeed applies --baseline-create-if-missing before the run: if the baseline report doesn't exist,
+// the run goes without a baseline and the returned path is where SeedBaseline saves the report of the run.
+// Returns an empty path if there is nothing to seed.
+func (o *QodanaOptions) PrepareBaselineSeed() string {
+	if !o.BaselineCreateIfMissing || o.Baseline == "" || o.DiffStart != "" || o.Commit != "" {
+		return ""
+	}
+	baselinePath := o.BaselinePath()
+	if _, err := os.Stat(baselinePath); err == nil {
+		return ""
+	}
+	WarningMessage("The baseline %s doesn't exist, it will be created from the report of this run and this run won't fail on new problems", o.Baseline)
+	o.Baseline = ""
+	return baselinePath
+}
+
+// SeedBaseline marks all results of the report at sarifPath as unchanged and saves the report as the baseline
+// at baselinePath. As the run has no new problems now, QodanaFailThresholdExitCode becomes QodanaSuccessExitCode,
+// other exit codes are returned as is. Nothing is done if baselinePath is empty.
+func SeedBaseline(sarifPath string, baselinePath string, exitCode int) (int, error) {
+	if baselinePath == "" {
+		return exitCode, nil
+	}
+	report, err := ReadReport(sarifPath)
+	if err != nil {
+		return exitCode, fmt.Errorf("error reading SARIF %s: %w", sarifPath, err)
+	}
+	for i := range report.Runs {
+		for j := range report.Runs[i].Results {
+			report.Runs[i].Results[j].BaselineState = baselineStateUnchanged
+		}
+	}
+	if err := WriteReport(sarifPath, report); err != nil {
+		return exitCode, err
+	}
+	if err := os.MkdirAll(filepath.Dir(baselinePath), os.ModePerm); err != nil {
+		return exitCode, fmt.Errorf("failed to create the baseline directory: %w", err)
+	}
+	if err := WriteReport(baselinePath, report); err != nil {
+		return exitCode, err
+	}
+	SuccessMessage("The baseline %s is created from the report of this run", baselinePath)
+	if exitCode == QodanaFailThresholdExitCode {
+		return QodanaSuccessExitCode, nil
+	}
+	return exitCode, nil
+}
+
+// PruneBaselineWhenClean empties the baseline report at baselinePath if the report at sarifPath has no new or
+// unchanged problems, i.e. everything from the baseline is fixed. The runs of the baseline are kept, so it stays
+// a valid baseline for the next runs. Returns true if the baseline was pruned.
+func PruneBaselineWhenClean(sarifPath string, baselinePath string) (bool, error) {
+	report, err := ReadReport(sarifPath)
+	if err != nil {
+		return false, fmt.Errorf("error reading SARIF %s: %w", sarifPath, err)
+	}
+	for _, run := range report.Runs {
+		for _, r := range run.Results {
+			if r.BaselineState != baselineStateAbsent {
+				return false, nil
+			}
+		}
+	}
+	baseline, err := ReadReport(baselinePath)
+	if err != nil {
+		return false, fmt.Errorf("error reading baseline %s: %w", baselinePath, err)
+	}
+	pruned := false
+	for i := range baseline.Runs {
+		if len(baseline.Runs[i].Results) > 0 {
+			baseline.Runs[i].Results = []sarif.Result{}
+			pruned = true
+		}
+	}
+	if !pruned {
+		return false, nil
+	}
+	return true, WriteReport(baselinePath, baseline)
+}
+
+// AnnotateBaselineState prefixes the messages of the results in the report at sarifPath with their baseline state,
+// e.g. [NEW], for the consumers that don't support SARIF baselineState. Results without a state are kept as is.
+func AnnotateBaselineState(sarifPath string) error {
+	report, err := ReadReport(sarifPath)
+	if err != nil {
+		return fmt.Errorf("error reading SARIF %s: %w", sarifPath, err)
+	}
+	for _, run := range report.Runs {
+		for _, r := range run.Results {
+			state, ok := r.BaselineState.(string)
+			if !ok || state == baselineStateEmpty || r.Message == nil {
+				continue
+			}
+			prefix := "[" + strings.ToUpper(state) + "] "
+			if !strings.HasPrefix(r.Message.Text, prefix) {
+				r.Message.Text = prefix + r.Message.Text
+			}
+		}
+	}
+	return WriteReport(sarifPath, report)
+}
+
+// severityRanks orders Qodana severities and SARIF levels from the least to the most severe.
+var severityRanks = map[string]int{
+	severityInfo:     0,
+	severityLow:      1,
+	sarifNote:        1,
+	severityModerate: 2,
+	sarifWarning:     2,
+	severityHigh:     3,
+	sarifError:       3,
+	severityCritical: 4,
+}
+
+// FilterAbsentResults removes the absent results with a severity lower than minSeverity from the report at sarifPath.
+func FilterAbsentResults(sarifPath string, minSeverity string) error {
+	report, err := ReadReport(sarifPath)
+	if err != nil {
+		return fmt.Errorf("error reading SARIF %s: %w", sarifPath, err)
+	}
+	minRank := severityRanks[Lower(minSeverity)]
+	for i := range report.Runs {
+		results := make([]sarif.Result, 0, len(report.Runs[i].Results))
+		for _, r := range report.Runs[i].Results {
+			if r.BaselineState == baselineStateAbsent && severityRanks[Lower(getSeverity(&r))] < minRank {
+				continue
+			}
+			results = append(results, r)
+		}
+		report.Runs[i].Results = results
+	}
+	return WriteReport(sarifPath, report)
+}
+
+// ValidateBaselineMatch checks the value of --baseline-match.
+func ValidateBaselineMatch(mode string) error {
+	if mode != "" && mode != BaselineMatchFingerprint && mode != BaselineMatchContent {
+		return fmt.Errorf("unknown baseline match mode %q, expected one of: %s, %s", mode, BaselineMatchFingerprint, BaselineMatchContent)
+	}
+	return nil
+}
+
+// ApplyContentBaseline recomputes the baseline state of the results in the report at sarifPath by matching them
+// with the baseline report by rule id, message and code snippet instead of the path-dependent fingerprints,
+// so a problem in a renamed file stays unchanged. Absent results are kept only if includeAbsent is set.
+func ApplyContentBaseline(sarifPath string, baselinePath string, includeAbsent bool) error {
+	report, err := ReadReport(sarifPath)
+	if err != nil {
+		return fmt.Errorf("error reading SARIF %s: %w", sarifPath, err)
+	}
+	baseline, err := ReadReport(baselinePath)
+	if err != nil {
+		return fmt.Errorf("error reading baseline %s: %w", baselinePath, err)
+	}
+	if len(report.Runs) == 0 {
+		return fmt.Errorf("error reading SARIF %s: no runs found", sarifPath)
+	}
+
+	baselineResults := make(map[string][]sarif.Result)
+	var keys []string
+	for _, run := range baseline.Runs {
+		for _, r := range run.Results {
+			if r.BaselineState == baselineStateAbsent {
+				continue
+			}
+			key := contentKey(&r)
+			if _, ok := baselineResults[key]; !ok {
+				keys = append(keys, key)
+			}
+			baselineResults[key] = append(baselineResults[key], r)
+		}
+	}
+
+	for i := range report.Runs {
+		results := make([]sarif.Result, 0, len(report.Runs[i].Results))
+		for _, r := range report.Runs[i].Results {
+			if r.BaselineState == baselineStateAbsent {
+				continue
+			}
+			key := contentKey(&r)
+			if matched := baselineResults[key]; len(matched) > 0 {
+				r.BaselineState = baselineStateUnchanged
+				baselineResults[key] = matched[1:]
+			} else {
+				r.BaselineState = baselineStateNew
+			}
+			results = append(results, r)
+		}
+		report.Runs[i].Results = results
+	}
+
+	if includeAbsent {
+		for _, key := range keys {
+			for _, r := range baselineResults[key] {
+				r.BaselineState = baselineStateAbsent
+				report.Runs[0].Results = append(report.Runs[0].Results, r)
+			}
+		}
+	}
+	return WriteReport(sarifPath, report)
+}
+
+// contentKey identifies a result by its rule id, message and the code snippet of its first location.
+func contentKey(r *sarif.Result) string {
+	snippet := ""
+	if len(r.Locations) > 0 {
+		location := r.Locations[0].PhysicalLocation
+		if location != nil && location.Region != nil && location.Region.Snippet != nil {
+			snippet = location.Region.Snippet.Text
+		}
+	}
+	message := ""
+	if r.Message != nil {
+		message = r.Message.Text
+	}
+	return r.RuleId + "\x00" + message + "\x00" + snippet
+}
@@ -0,0 +1,317 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyContentBaseline(t *testing.T) {
+	dir := t.TempDir()
+	baselinePath := filepath.Join(dir, "baseline.sarif.json")
+	sarifPath := filepath.Join(dir, QodanaSarifName)
+	baseline := `{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "QDJVM"}}, "results": [
+{"ruleId": "UnusedImport", "message": {"text": "Unused import"}, "partialFingerprints": {"equalIndicator/v1": "old"},
+ "locations": [{"physicalLocation": {"artifactLocation": {"uri": "src/Old.java"}, "region": {"startLine": 3, "snippet": {"text": "import java.util.List;"}}}}]},
+{"ruleId": "ConstantValue", "message": {"text": "Condition is always true"}, "partialFingerprints": {"equalIndicator/v1": "fixed"},
+ "locations": [{"physicalLocation": {"artifactLocation": {"uri": "src/Old.java"}, "region": {"startLine": 10, "snippet": {"text": "if (true)"}}}}]}
+]}]}`
+	report := `{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "QDJVM"}}, "results": [
+{"ruleId": "UnusedImport", "message": {"text": "Unused import"}, "partialFingerprints": {"equalIndicator/v1": "renamed"}, "baselineState": "new",
+ "locations": [{"physicalLocation": {"artifactLocation": {"uri": "src/New.java"}, "region": {"startLine": 3, "snippet": {"text": "import java.util.List;"}}}}]},
+{"ruleId": "UnusedImport", "message": {"text": "Unused import"}, "partialFingerprints": {"equalIndicator/v1": "added"},
+ "locations": [{"physicalLocation": {"artifactLocation": {"uri": "src/New.java"}, "region": {"startLine": 4, "snippet": {"text": "import java.util.Map;"}}}}]}
+]}]}`
+	if err := os.WriteFile(baselinePath, []byte(baseline), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, testData := range []struct {
+		name          string
+		includeAbsent bool
+		expected      []string
+	}{
+		{"renamed file keeps the problem unchanged", false, []string{baselineStateUnchanged, baselineStateNew}},
+		{"fixed problems are reported as absent", true, []string{baselineStateUnchanged, baselineStateNew, baselineStateAbsent}},
+	} {
+		t.Run(testData.name, func(t *testing.T) {
+			if err := os.WriteFile(sarifPath, []byte(report), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := ApplyContentBaseline(sarifPath, baselinePath, testData.includeAbsent); err != nil {
+				t.Fatal(err)
+			}
+			actual, err := ReadReport(sarifPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			results := actual.Runs[0].Results
+			if len(results) != len(testData.expected) {
+				t.Fatalf("expected %d results, got %d", len(testData.expected), len(results))
+			}
+			for i, state := range testData.expected {
+				if results[i].BaselineState != state {
+					t.Errorf("result %d: expected baseline state %s, got %v", i, state, results[i].BaselineState)
+				}
+			}
+		})
+	}
+}
+
+func TestCountBaselineStates(t *testing.T) {
+	sarifPath := filepath.Join(t.TempDir(), QodanaSarifName)
+	for _, testData := range []struct {
+		name     string
+		states   []string
+		expected BaselineSummary
+		exitCode int
+	}{
+		{"more fixed than new", []string{"new", "absent", "absent", "unchanged"}, BaselineSummary{New: 1, Fixed: 2}, QodanaSuccessExitCode},
+		{"as many fixed as new", []string{"new", "absent"}, BaselineSummary{New: 1, Fixed: 1}, QodanaSuccessExitCode},
+		{"more new than fixed", []string{"new", "new", "absent", "unchanged"}, BaselineSummary{New: 2, Fixed: 1}, QodanaFailThresholdExitCode},
+	} {
+		t.Run(testData.name, func(t *testing.T) {
+			results := ""
+			for i, state := range testData.states {
+				if i > 0 {
+					results += ","
+				}
+				results += `{"ruleId": "ConstantValue", "message": {"text": "Condition is always true"}, "baselineState": "` + state + `"}`
+			}
+			report := `{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "QDJVM"}}, "results": [` + results + `]}]}`
+			if err := os.WriteFile(sarifPath, []byte(report), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			summary, err := CountBaselineStates(sarifPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if summary != testData.expected {
+				t.Errorf("expected %+v, got %+v", testData.expected, summary)
+			}
+			exitCode, err := CheckBaselineNetGate(sarifPath, QodanaSuccessExitCode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exitCode != testData.exitCode {
+				t.Errorf("expected exit code %d, got %d", testData.exitCode, exitCode)
+			}
+		})
+	}
+}
+
+func TestFilterAbsentResults(t *testing.T) {
+	sarifPath := filepath.Join(t.TempDir(), QodanaSarifName)
+	report := `{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "QDJVM"}}, "results": [
+{"ruleId": "Critical", "message": {"text": "absent critical"}, "baselineState": "absent", "properties": {"qodanaSeverity": "Critical"}},
+{"ruleId": "Low", "message": {"text": "absent low"}, "baselineState": "absent", "properties": {"qodanaSeverity": "Low"}},
+{"ruleId": "Warning", "message": {"text": "absent warning"}, "baselineState": "absent", "level": "warning"},
+{"ruleId": "Info", "message": {"text": "new info"}, "baselineState": "new", "properties": {"qodanaSeverity": "Info"}}
+]}]}`
+
+	for _, testData := range []struct {
+		name        string
+		minSeverity string
+		expected    []string
+	}{
+		{"all severities", "info", []string{"Critical", "Low", "Warning", "Info"}},
+		{"moderate and higher", "moderate", []string{"Critical", "Warning", "Info"}},
+		{"critical only", "Critical", []string{"Critical", "Info"}},
+	} {
+		t.Run(testData.name, func(t *testing.T) {
+			if err := os.WriteFile(sarifPath, []byte(report), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if err := FilterAbsentResults(sarifPath, testData.minSeverity); err != nil {
+				t.Fatal(err)
+			}
+			actual, err := ReadReport(sarifPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var ruleIds []string
+			for _, r := range actual.Runs[0].Results {
+				ruleIds = append(ruleIds, r.RuleId)
+			}
+			if strings.Join(ruleIds, ",") != strings.Join(testData.expected, ",") {
+				t.Errorf("expected %v, got %v", testData.expected, ruleIds)
+			}
+		})
+	}
+}
+
+func TestPruneBaselineWhenClean(t *testing.T) {
+	dir := t.TempDir()
+	sarifPath := filepath.Join(dir, QodanaSarifName)
+	baselinePath := filepath.Join(dir, "baseline.sarif.json")
+	writeReport := func(path string, states ...string) {
+		results := make([]string, 0, len(states))
+		for _, state := range states {
+			results = append(results, `{"ruleId": "ConstantValue", "message": {"text": "Condition is always true"}, "baselineState": "`+state+`"}`)
+		}
+		report := `{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "QDJVM"}}, "results": [` + strings.Join(results, ",") + `]}]}`
+		if err := os.WriteFile(path, []byte(report), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	baselineResults := func() int {
+		baseline, err := ReadReport(baselinePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return len(baseline.Runs[0].Results)
+	}
+
+	writeReport(baselinePath, "", "")
+	writeReport(sarifPath, "absent", "unchanged")
+	pruned, err := PruneBaselineWhenClean(sarifPath, baselinePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pruned || baselineResults() != 2 {
+		t.Errorf("baseline with an unchanged problem must not be pruned")
+	}
+
+	writeReport(sarifPath, "absent", "absent")
+	pruned, err = PruneBaselineWhenClean(sarifPath, baselinePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pruned || baselineResults() != 0 {
+		t.Errorf("expected the baseline of a fully fixed run to be pruned")
+	}
+}
+
+func TestSeedBaseline(t *testing.T) {
+	dir := t.TempDir()
+	options := &QodanaOptions{ProjectDir: dir, Baseline: filepath.Join(".qodana", "baseline.sarif.json"), BaselineCreateIfMissing: true}
+	baselinePath := options.PrepareBaselineSeed()
+	if baselinePath != filepath.Join(dir, ".qodana", "baseline.sarif.json") || options.Baseline != "" {
+		t.Fatalf("expected the run without the missing baseline, got seed %q and baseline %q", baselinePath, options.Baseline)
+	}
+
+	sarifPath := filepath.Join(dir, QodanaSarifName)
+	report := `{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "QDJVM"}}, "results": [
+		{"ruleId": "ConstantValue", "message": {"text": "Condition is always true"}, "baselineState": "new"},
+		{"ruleId": "UnusedSymbol", "message": {"text": "Unused variable"}}
+	]}]}`
+	if err := os.WriteFile(sarifPath, []byte(report), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	exitCode, err := SeedBaseline(sarifPath, baselinePath, QodanaFailThresholdExitCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exitCode != QodanaSuccessExitCode {
+		t.Errorf("expected the seeding run to pass the quality gate, got exit code %d", exitCode)
+	}
+	for _, path := range []string{sarifPath, baselinePath} {
+		seeded, err := ReadReport(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, r := range seeded.Runs[0].Results {
+			if r.BaselineState != baselineStateUnchanged {
+				t.Errorf("%s: expected all results unchanged, got %q for %s", path, r.BaselineState, r.RuleId)
+			}
+		}
+	}
+
+	options.Baseline = filepath.Join(".qodana", "baseline.sarif.json")
+	if seed := options.PrepareBaselineSeed(); seed != "" || options.Baseline == "" {
+		t.Errorf("expected the existing baseline to be used, got seed %q", seed)
+	}
+}
+
+func TestAnnotateBaselineState(t *testing.T) {
+	sarifPath := filepath.Join(t.TempDir(), QodanaSarifName)
+	report := `{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "QDJVM"}}, "results": [
+		{"ruleId": "ConstantValue", "message": {"text": "Condition is always true"}, "baselineState": "new"},
+		{"ruleId": "ConstantValue", "message": {"text": "Condition is always false"}, "baselineState": "unchanged"},
+		{"ruleId": "UnusedImport", "message": {"text": "Unused import"}}
+	]}]}`
+	if err := os.WriteFile(sarifPath, []byte(report), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// annotating twice doesn't duplicate the prefixes
+	for i := 0; i < 2; i++ {
+		if err := AnnotateBaselineState(sarifPath); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	annotated, err := ReadReport(sarifPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"[NEW] Condition is always true", "[UNCHANGED] Condition is always false", "Unused import"}
+	for i, r := range annotated.Runs[0].Results {
+		if r.Message.Text != expected[i] {
+			t.Errorf("expected %q, got %q", expected[i], r.Message.Text)
+		}
+	}
+}
+
+func TestApplyBaselineIgnoreRules(t *testing.T) {
+	report := `{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "QDJVM"}}, "results": [
+		{"ruleId": "SpellCheckingInspection", "message": {"text": "Typo"}, "baselineState": "new", "properties": {"qodanaSeverity": "High"}},
+		{"ruleId": "SpellCheckingInspection", "message": {"text": "Typo"}, "properties": {"qodanaSeverity": "High"}},
+		{"ruleId": "ConstantValue", "message": {"text": "Condition is always true"}, "baselineState": "%s", "properties": {"qodanaSeverity": "High"}}
+	]}]}`
+	for _, tc := range []struct {
+		name          string
+		constantValue string
+		thresholds    map[string]string
+		expected      int
+	}{
+		{"only ignored rules are new", "unchanged", map[string]string{severityAny: "0"}, QodanaSuccessExitCode},
+		{"ignored rules are not counted", "new", map[string]string{severityHigh: "1"}, QodanaSuccessExitCode},
+		{"other new problems still fail", "new", map[string]string{severityAny: "0"}, QodanaFailThresholdExitCode},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sarifPath := filepath.Join(t.TempDir(), QodanaSarifName)
+			if err := os.WriteFile(sarifPath, []byte(strings.Replace(report, "%s", tc.constantValue, 1)), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			exitCode, err := ApplyBaselineIgnoreRules(sarifPath, []string{"SpellCheckingInspection"}, tc.thresholds, QodanaFailThresholdExitCode)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exitCode != tc.expected {
+				t.Errorf("expected exit code %d, got %d", tc.expected, exitCode)
+			}
+
+			ignored, err := ReadReport(sarifPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ignored.Runs[0].Results) != 3 {
+				t.Fatalf("expected the ignored results to stay in the report")
+			}
+			for _, r := range ignored.Runs[0].Results[:2] {
+				if r.BaselineState != baselineStateUnchanged {
+					t.Errorf("expected the ignored result to be unchanged, got %v", r.BaselineState)
+				}
+			}
+		})
+	}
+}
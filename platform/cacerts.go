@@ -0,0 +1,195 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/binary"
+	"encoding/pem"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+const (
+	// javaTrustStorePassword is the password of the generated Java trust store, it only guards the store integrity.
+	javaTrustStorePassword = "changeit"
+	javaToolOptionsEnv     = "JAVA_TOOL_OPTIONS"
+)
+
+// systemCaBundles are the common locations of the system CA bundle, the first existing one is extended with --ca-cert.
+var systemCaBundles = []string{
+	"/etc/ssl/certs/ca-certificates.crt",
+	"/etc/pki/tls/certs/ca-bundle.crt",
+	"/etc/ssl/cert.pem",
+}
+
+// ValidateCaCerts checks that the files passed via --ca-cert exist and contain PEM certificates.
+func ValidateCaCerts(paths []string) error {
+	for _, path := range paths {
+		if _, err := readCaCert(path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readCaCert returns the DER certificates from the PEM file at path.
+func readCaCert(path string) ([][]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the CA certificate: %w", err)
+	}
+	certs := pemCertificates(data)
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("%s is not a PEM certificate file: no CERTIFICATE blocks found", path)
+	}
+	return certs, nil
+}
+
+// pemCertificates returns the DER certificates of the CERTIFICATE blocks in data, other blocks are skipped.
+func pemCertificates(data []byte) [][]byte {
+	var certs [][]byte
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return certs
+		}
+		if block.Type == "CERTIFICATE" {
+			certs = append(certs, block.Bytes)
+		}
+	}
+}
+
+// SetupCaCerts makes the certificates passed via --ca-cert trusted by this process and the processes it starts:
+// they are added to the system CA bundle, and the bundle is set as SSL_CERT_FILE and NODE_EXTRA_CA_CERTS,
+// and as a Java trust store via JAVA_TOOL_OPTIONS. Both replace the default certificates, so it fails if there
+// is no system CA bundle to extend. The returned function removes the written files after the run.
+func (o *QodanaOptions) SetupCaCerts() (func(), error) {
+	noCleanup := func() {}
+	if len(o.CaCerts) == 0 {
+		return noCleanup, nil
+	}
+	certs := systemCaCerts()
+	if len(certs) == 0 {
+		return noCleanup, fmt.Errorf(
+			"--ca-cert: no system CA bundle found (%s) to add the certificates to, add them to the system trust store instead",
+			strings.Join(systemCaBundles, ", "),
+		)
+	}
+	for _, path := range o.CaCerts {
+		caCerts, err := readCaCert(path)
+		if err != nil {
+			return noCleanup, err
+		}
+		certs = append(certs, caCerts...)
+	}
+	dir, err := os.MkdirTemp("", "qodana-ca-certs")
+	if err != nil {
+		return noCleanup, fmt.Errorf("failed to create a directory for the CA certificates: %w", err)
+	}
+	cleanup := func() {
+		if err := os.RemoveAll(dir); err != nil {
+			log.Warnf("Failed to remove the CA certificates directory %s: %v", dir, err)
+		}
+	}
+	if err = writeCaCerts(dir, certs); err != nil {
+		cleanup()
+		return noCleanup, err
+	}
+	return cleanup, nil
+}
+
+// systemCaCerts returns the certificates of the first existing system CA bundle.
+func systemCaCerts() [][]byte {
+	for _, bundle := range systemCaBundles {
+		if data, err := os.ReadFile(bundle); err == nil {
+			return pemCertificates(data)
+		}
+	}
+	return nil
+}
+
+// writeCaCerts writes certs to dir as a PEM bundle and a Java trust store and sets the environment to use them.
+func writeCaCerts(dir string, certs [][]byte) error {
+	var bundle bytes.Buffer
+	for _, cert := range certs {
+		_ = pem.Encode(&bundle, &pem.Block{Type: "CERTIFICATE", Bytes: cert})
+	}
+	bundlePath := filepath.Join(dir, "ca-bundle.pem")
+	if err := os.WriteFile(bundlePath, bundle.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write the CA bundle: %w", err)
+	}
+	trustStorePath := filepath.Join(dir, "truststore.jks")
+	if err := os.WriteFile(trustStorePath, javaTrustStore(certs, javaTrustStorePassword, time.Now()), 0o644); err != nil {
+		return fmt.Errorf("failed to write the Java trust store: %w", err)
+	}
+	javaOptions := fmt.Sprintf(
+		"%s -Djavax.net.ssl.trustStorePassword=%s -Djavax.net.ssl.trustStoreType=JKS",
+		QuoteIfSpace("-Djavax.net.ssl.trustStore="+trustStorePath), // JAVA_TOOL_OPTIONS are split by spaces unless quoted
+		javaTrustStorePassword,
+	)
+	if existing := os.Getenv(javaToolOptionsEnv); existing != "" {
+		javaOptions = existing + " " + javaOptions
+	}
+	for key, value := range map[string]string{
+		"SSL_CERT_FILE":       bundlePath,
+		"NODE_EXTRA_CA_CERTS": bundlePath,
+		javaToolOptionsEnv:    javaOptions,
+	} {
+		if err := os.Setenv(key, value); err != nil {
+			return err
+		}
+	}
+	log.Debugf("Using the CA bundle %s with %d certificates", bundlePath, len(certs))
+	return nil
+}
+
+// javaTrustStore returns a JKS key store with the given DER certificates as trusted certificate entries.
+func javaTrustStore(certs [][]byte, password string, created time.Time) []byte {
+	var store bytes.Buffer
+	writeUint32 := func(value uint32) { _ = binary.Write(&store, binary.BigEndian, value) }
+	writeUtf := func(value string) {
+		_ = binary.Write(&store, binary.BigEndian, uint16(len(value)))
+		store.WriteString(value)
+	}
+	writeUint32(0xFEEDFEED) // magic
+	writeUint32(2)          // version
+	writeUint32(uint32(len(certs)))
+	for i, cert := range certs {
+		writeUint32(2) // trusted certificate entry
+		writeUtf(fmt.Sprintf("qodana-ca-%d", i))
+		_ = binary.Write(&store, binary.BigEndian, created.UnixMilli())
+		writeUtf("X.509")
+		writeUint32(uint32(len(cert)))
+		store.Write(cert)
+	}
+	digest := sha1.New()
+	for _, c := range utf16.Encode([]rune(password)) {
+		digest.Write([]byte{byte(c >> 8), byte(c)})
+	}
+	digest.Write([]byte("Mighty Aphrodite"))
+	digest.Write(store.Bytes())
+	store.Write(digest.Sum(nil))
+	return store.Bytes()
+}
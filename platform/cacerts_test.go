@@ -0,0 +1,139 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetupCaCerts(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "proxy.pem")
+	der := writeTestCaCert(t, certPath)
+	systemBundle := filepath.Join(dir, "ca-certificates.crt")
+	systemDer := writeTestCaCert(t, systemBundle)
+	setSystemCaBundles(t, systemBundle)
+	tempDir := filepath.Join(dir, "temp dir")
+	if err := os.Mkdir(tempDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TMPDIR", tempDir)
+	t.Setenv("SSL_CERT_FILE", "")
+	t.Setenv("NODE_EXTRA_CA_CERTS", "")
+	t.Setenv(javaToolOptionsEnv, "-Xmx1g")
+
+	options := &QodanaOptions{CaCerts: []string{certPath}}
+	assert.NoError(t, ValidateCaCerts(options.CaCerts))
+	cleanup, err := options.SetupCaCerts()
+	assert.NoError(t, err)
+
+	bundlePath := os.Getenv("SSL_CERT_FILE")
+	assert.Equal(t, bundlePath, os.Getenv("NODE_EXTRA_CA_CERTS"))
+	bundle, err := os.ReadFile(bundlePath)
+	assert.NoError(t, err)
+	assert.Equal(t, [][]byte{systemDer, der}, pemCertificates(bundle))
+
+	javaOptions := os.Getenv(javaToolOptionsEnv)
+	trustStorePath := filepath.Join(filepath.Dir(bundlePath), "truststore.jks")
+	assert.True(t, strings.HasPrefix(javaOptions, "-Xmx1g \"-Djavax.net.ssl.trustStore="+trustStorePath+"\" "), javaOptions)
+	trustStore, err := os.ReadFile(trustStorePath)
+	assert.NoError(t, err)
+	assert.True(t, bytes.Contains(trustStore, der))
+	assert.Equal(t, uint32(0xFEEDFEED), binary.BigEndian.Uint32(trustStore))
+	assert.Equal(t, uint32(len(pemCertificates(bundle))), binary.BigEndian.Uint32(trustStore[8:]))
+
+	// the trailing digest is SHA-1 of the UTF-16 password, "Mighty Aphrodite" and the store content
+	content := trustStore[:len(trustStore)-sha1.Size]
+	digest := sha1.New()
+	digest.Write([]byte{0, 'c', 0, 'h', 0, 'a', 0, 'n', 0, 'g', 0, 'e', 0, 'i', 0, 't'})
+	digest.Write([]byte("Mighty Aphrodite"))
+	digest.Write(content)
+	assert.Equal(t, digest.Sum(nil), trustStore[len(content):])
+
+	cleanup()
+	assert.NoFileExists(t, bundlePath)
+	assert.NoFileExists(t, trustStorePath)
+}
+
+func TestSetupCaCertsWithoutSystemBundle(t *testing.T) {
+	certPath := filepath.Join(t.TempDir(), "proxy.pem")
+	writeTestCaCert(t, certPath)
+	setSystemCaBundles(t, filepath.Join(t.TempDir(), "missing.crt"))
+	t.Setenv("SSL_CERT_FILE", "")
+
+	_, err := (&QodanaOptions{CaCerts: []string{certPath}}).SetupCaCerts()
+	assert.ErrorContains(t, err, "no system CA bundle found")
+	assert.Empty(t, os.Getenv("SSL_CERT_FILE"))
+}
+
+// setSystemCaBundles replaces the system CA bundle locations for the test.
+func setSystemCaBundles(t *testing.T, bundles ...string) {
+	original := systemCaBundles
+	systemCaBundles = bundles
+	t.Cleanup(func() { systemCaBundles = original })
+}
+
+func TestValidateCaCerts(t *testing.T) {
+	dir := t.TempDir()
+	notPem := filepath.Join(dir, "cert.der")
+	if err := os.WriteFile(notPem, []byte("not a certificate"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	assert.ErrorContains(t, ValidateCaCerts([]string{notPem}), "is not a PEM certificate file")
+	assert.Error(t, ValidateCaCerts([]string{filepath.Join(dir, "missing.pem")}))
+	assert.NoError(t, ValidateCaCerts(nil))
+}
+
+// writeTestCaCert writes a self-signed CA certificate to path and returns its DER bytes.
+func writeTestCaCert(t *testing.T, path string) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Qodana Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return der
+}
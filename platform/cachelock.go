@@ -0,0 +1,79 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// cacheLockRetryInterval is how often the lock of the shared cache directory is retried.
+var cacheLockRetryInterval = 500 * time.Millisecond
+
+// CacheLock is the exclusive lock of the cache directory shared by concurrent runs (--cache-dir-shared).
+type CacheLock struct {
+	file *os.File
+}
+
+// cacheLockPath returns the path of the lock file of cacheDir. The file is next to the directory,
+// so it survives --clear-cache.
+func cacheLockPath(cacheDir string) string {
+	return filepath.Clean(cacheDir) + ".lock"
+}
+
+// LockCacheDir acquires the lock of cacheDir waiting for the other runs to release it up to timeout:
+// 0 fails immediately if the lock is held, negative waits without limit.
+func LockCacheDir(cacheDir string, timeout time.Duration) (*CacheLock, error) {
+	path := cacheLockPath(cacheDir)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return nil, fmt.Errorf("couldn't create the directory for the cache lock: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0o666)
+	if err != nil {
+		return nil, fmt.Errorf("couldn't open the cache lock %s: %w", path, err)
+	}
+	deadline := time.Now().Add(timeout)
+	for waiting := false; ; waiting = true {
+		locked, err := tryLockFile(file)
+		if err != nil {
+			_ = file.Close()
+			return nil, fmt.Errorf("couldn't lock the cache directory %s: %w", cacheDir, err)
+		}
+		if locked {
+			return &CacheLock{file: file}, nil
+		}
+		if timeout >= 0 && !time.Now().Before(deadline) {
+			_ = file.Close()
+			return nil, fmt.Errorf("the cache directory %s is used by another Qodana run (locked by %s)", cacheDir, path)
+		}
+		if !waiting {
+			WarningMessage("The cache directory %s is used by another Qodana run, waiting for it to finish", cacheDir)
+		}
+		time.Sleep(cacheLockRetryInterval)
+	}
+}
+
+// Unlock releases the lock, the lock file is kept for the next runs.
+func (l *CacheLock) Unlock() error {
+	if err := unlockFile(l.file); err != nil {
+		_ = l.file.Close()
+		return err
+	}
+	return l.file.Close()
+}
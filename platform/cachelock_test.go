@@ -0,0 +1,52 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLockCacheDir(t *testing.T) {
+	cacheLockRetryInterval = 10 * time.Millisecond
+	cacheDir := filepath.Join(t.TempDir(), "cache")
+
+	lock, err := LockCacheDir(cacheDir, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, timeout := range []time.Duration{0, 50 * time.Millisecond} {
+		if _, err := LockCacheDir(cacheDir, timeout); err == nil {
+			t.Fatalf("expected the cache directory to be locked with timeout %s", timeout)
+		}
+	}
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		if err := lock.Unlock(); err != nil {
+			t.Error(err)
+		}
+	}()
+	lock, err = LockCacheDir(cacheDir, -1)
+	if err != nil {
+		t.Fatalf("expected to get the lock after it's released: %v", err)
+	}
+	if err := lock.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"os/exec"
 	"os/signal"
 	"runtime"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -41,11 +42,78 @@ const (
 	QodanaOutOfMemoryExitCode = 137
 	// QodanaEapLicenseExpiredExitCode reports an expired license.
 	QodanaEapLicenseExpiredExitCode = 7
+	// QodanaLicenseViolationExitCode reports new dependency license violations with --fail-on-license-violation.
+	QodanaLicenseViolationExitCode = 3
+	// QodanaInternalErrorExitCode reports internal errors of the analysis when failOnErrorNotification is set in qodana.yaml.
+	QodanaInternalErrorExitCode = 70
 	// QodanaTimeoutExitCodePlaceholder is not a real exit code (it is not obtained from IDE process! and not returned from CLI)
 	QodanaTimeoutExitCodePlaceholder = 1000
+	// QodanaStartupTimeoutExitCodePlaceholder is not a real exit code, it's returned when the analysis hasn't started in --startup-timeout
+	QodanaStartupTimeoutExitCodePlaceholder = 1001
 	// Placeholder used to identify the case when the analysis reached timeout
 )
 
+// ParseExitCodeMap parses the --exit-code-map values in the from=to format (e.g. 255=10) into a map.
+// Mapping the same exit code twice is an error, while several codes can be mapped to the same one.
+func ParseExitCodeMap(values []string) (map[int]int, error) {
+	codeMap := make(map[int]int)
+	for _, value := range values {
+		from, to, found := strings.Cut(value, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid exit code mapping %q, expected the from=to format, e.g. 255=10", value)
+		}
+		fromCode, err := parseExitCode(from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exit code mapping %q: %w", value, err)
+		}
+		toCode, err := parseExitCode(to)
+		if err != nil {
+			return nil, fmt.Errorf("invalid exit code mapping %q: %w", value, err)
+		}
+		if mapped, ok := codeMap[fromCode]; ok && mapped != toCode {
+			return nil, fmt.Errorf("exit code %d is mapped to both %d and %d", fromCode, mapped, toCode)
+		}
+		codeMap[fromCode] = toCode
+	}
+	return codeMap, nil
+}
+
+func parseExitCode(value string) (int, error) {
+	code, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || code < 0 || code > 255 {
+		return 0, fmt.Errorf("%q is not an exit code between 0 and 255", value)
+	}
+	return code, nil
+}
+
+// ParseContainerLabels parses the --container-label values in the key=value format into a map.
+func ParseContainerLabels(values []string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, value := range values {
+		key, label, found := strings.Cut(value, "=")
+		if !found || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid container label %q, expected the key=value format", value)
+		}
+		labels[strings.TrimSpace(key)] = label
+	}
+	return labels, nil
+}
+
+// MapExitCode returns the exit code configured for code with --exit-code-map, unmapped codes are returned as is.
+// It's applied to the exit code of the analysis, --timeout-exit-code is already user-defined and is not mapped.
+func (o *QodanaOptions) MapExitCode(code int) int {
+	codeMap, err := ParseExitCodeMap(o.ExitCodeMap)
+	if err != nil {
+		log.Warnf("Ignoring --exit-code-map: %s", err)
+		return code
+	}
+	if mapped, ok := codeMap[code]; ok {
+		log.Debugf("Exit code %d is mapped to %d", code, mapped)
+		return mapped
+	}
+	return code
+}
+
 // RunCmd executes subprocess with forwarding of signals, and returns its exit code.
 func RunCmd(cwd string, args ...string) (int, error) {
 	return RunCmdWithTimeout(cwd, os.Stdout, os.Stderr, time.Duration(math.MaxInt64), 1, args...)
@@ -40,7 +40,80 @@ But you can always override qodana.yaml options with the following command-line
 `, (*linterInfo).GetInfo(options).LinterName),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			log.SetFormatter(&log.TextFormatter{DisableQuote: true, DisableTimestamp: true})
+			if err := platform.ValidateFailOnSeverity(options.FailOnSeverity); err != nil {
+				return err
+			}
+			if err := platform.ValidateBaselineMatch(options.BaselineMatch); err != nil {
+				return err
+			}
+			if err := platform.ValidateAbsentMinSeverity(options.AbsentMinSeverity); err != nil {
+				return err
+			}
+			if _, err := platform.ParseExitCodeMap(options.ExitCodeMap); err != nil {
+				return err
+			}
+			if err := platform.ValidateCaCerts(options.CaCerts); err != nil {
+				return err
+			}
+			if _, err := platform.ParseCludes(append(options.Includes, options.Excludes...)); err != nil {
+				return err
+			}
+			if err := options.ValidateFailOnErrorNotification(); err != nil {
+				return err
+			}
+			severityExitCodes, err := platform.ParseSeverityExitCodes(options.SeverityExitCodes)
+			if err != nil {
+				return err
+			}
+			if options.ReportZipOnly && options.ReportZip == "" {
+				return fmt.Errorf("--report-zip-only requires --report-zip")
+			}
+			if options.BaselineCreateIfMissing && options.Baseline == "" {
+				return fmt.Errorf("--baseline-create-if-missing requires --baseline")
+			}
+			if err := options.DeriveAnalysisId(); err != nil {
+				return err
+			}
+			if err := options.ResolveDiffBaseBranch(); err != nil {
+				return err
+			}
+			if err := options.ValidateS3Upload(); err != nil {
+				return err
+			}
+			if options.DryRun {
+				return fmt.Errorf("--dry-run is supported only by the IDE-based linters")
+			}
+			if len(options.ScopeGlobs) > 0 {
+				return fmt.Errorf("--scope-glob is supported only by the IDE-based linters")
+			}
+			if options.BaselineAuto {
+				return fmt.Errorf("--baseline-auto is supported only by the IDE-based linters")
+			}
+			if options.BaselineNetGate {
+				options.BaselineIncludeAbsent = true
+			}
 			exitCode, err := platform.RunAnalysis(options)
+			if err == nil && exitCode == platform.QodanaSuccessExitCode && options.BaselineNetGate && options.Baseline != "" {
+				exitCode, err = platform.CheckBaselineNetGate(options.GetSarifPath(), exitCode)
+			}
+			if err == nil {
+				err = platform.PruneBaseline(options, options.GetSarifPath())
+			}
+			licenseViolations := 0
+			if err == nil && options.FailOnLicenseViolation && (exitCode == platform.QodanaSuccessExitCode || exitCode == platform.QodanaFailThresholdExitCode) {
+				licenseViolations, err = platform.CountNewLicenseViolations(options.GetSarifPath())
+				if licenseViolations > 0 {
+					exitCode = platform.QodanaLicenseViolationExitCode
+				}
+			}
+			severity := ""
+			if err == nil && (exitCode == platform.QodanaSuccessExitCode || exitCode == platform.QodanaFailThresholdExitCode) {
+				var code int
+				severity, code, err = platform.SeverityExitCode(options.GetSarifPath(), severityExitCodes)
+				if severity != "" {
+					exitCode = code
+				}
+			}
 			if platform.IsContainer() {
 				err := platform.ChangePermissionsRecursively(options.ResultsDir)
 				if err != nil {
@@ -48,10 +121,20 @@ But you can always override qodana.yaml options with the following command-line
 				}
 			}
 			log.Debug("exitCode: ", exitCode)
+			if severity != "" {
+				platform.EmptyMessage()
+				platform.ErrorMessage("New problems of %s severity are found", severity)
+				os.Exit(exitCode)
+			}
+			if exitCode == platform.QodanaLicenseViolationExitCode {
+				platform.EmptyMessage()
+				platform.ErrorMessage("%d new dependency license violations are found", licenseViolations)
+				os.Exit(options.MapExitCode(exitCode))
+			}
 			if exitCode == platform.QodanaFailThresholdExitCode {
 				platform.EmptyMessage()
 				platform.ErrorMessage("The number of problems exceeds the fail threshold")
-				os.Exit(exitCode)
+				os.Exit(options.MapExitCode(exitCode))
 			}
 			return err
 		},
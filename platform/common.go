@@ -17,15 +17,18 @@
 package platform
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"github.com/JetBrains/qodana-cli/v2024/cloud"
 	"github.com/pterm/pterm"
 	log "github.com/sirupsen/logrus"
+	"net"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -180,6 +183,9 @@ func GetDotNetConfig(projectDir string, yamlName string) bool {
 	return setQodanaDotNet(projectDir, dotnet, yamlName)
 }
 
+// eapSuffix marks the EAP versions of the native linters, e.g. QDJVM-EAP.
+const eapSuffix = "-EAP"
+
 // AllNativeCodes is a list of all supported Qodana linters product codes
 var AllNativeCodes = []string{QDNET, QDJVM, QDJVMC, QDGO, QDPY, QDPYC, QDJS, QDPHP}
 
@@ -220,6 +226,32 @@ func SelectAnalyzer(path string, analyzers []string, interactive bool, selectFun
 	return analyzer
 }
 
+// ResolveLinter returns the image of the supported linter with the given name: either a full image name with any tag
+// (e.g. jetbrains/qodana-jvm:2024.2) or a short one without the tag (e.g. qodana-jvm) for the image of the current release.
+func ResolveLinter(name string) (string, error) {
+	var shortNames []string
+	for _, image := range AllImages {
+		repository, _, _ := strings.Cut(image, ":")
+		shortName := strings.TrimPrefix(repository, "jetbrains/")
+		if name == shortName || name == repository {
+			return image, nil
+		}
+		if nameRepository, _, _ := strings.Cut(name, ":"); nameRepository == repository {
+			return name, nil
+		}
+		shortNames = append(shortNames, shortName)
+	}
+	return "", fmt.Errorf("unknown linter %q, available linters are: %s", name, strings.Join(shortNames, ", "))
+}
+
+// ValidateNativeIde checks that ide is a product code of a native linter, optionally with the -EAP suffix.
+func ValidateNativeIde(ide string) error {
+	if !IsNativeAnalyzer(strings.TrimSuffix(ide, eapSuffix)) {
+		return fmt.Errorf("unknown IDE %q, available codes are: %s", ide, strings.Join(AllNativeCodes, ", "))
+	}
+	return nil
+}
+
 func IsNativeAnalyzer(analyzer string) bool {
 	return Contains(AllNativeCodes, analyzer)
 }
@@ -240,28 +272,100 @@ func analyzerToSelect(analyzers []string, path string) (map[string]string, []str
 	return analyzersMap, analyzersList
 }
 
-// ShowReport serves the Qodana report
-func ShowReport(resultsDir string, reportPath string, port int) {
+// ShowReport serves the Qodana report on host (all interfaces if empty) and port,
+// with the basic auth if password is set.
+func ShowReport(resultsDir string, reportPath string, host string, port int, portAuto bool, user string, password string) {
 	cloudUrl := cloud.GetReportUrl(resultsDir)
 	if cloudUrl != "" {
-		openReport(cloudUrl, reportPath, port)
+		openReport(cloudUrl, nil, nil)
 	} else {
+		listener, err := listenReportPort(host, port, portAuto)
+		if err != nil {
+			log.Fatal(err)
+		}
 		WarningMessage("Press Ctrl+C to stop serving the report\n")
 		PrintProcess(
 			func(_ *pterm.SpinnerPrinter) {
 				if _, err := os.Stat(reportPath); os.IsNotExist(err) {
 					log.Fatal("Qodana report not found. Get a report by running `qodana scan`")
 				}
-				openReport("", reportPath, port)
+				openReport("", reportHandler(reportPath, user, password), listener)
 			},
-			fmt.Sprintf("Showing Qodana report from %s", fmt.Sprintf("http://localhost:%d/", port)),
+			fmt.Sprintf("Showing Qodana report from %s/", reportServerUrl(listener)),
 			"",
 		)
 	}
 }
 
-// openReport serves the report on the given port and opens the browser.
-func openReport(cloudUrl string, path string, port int) {
+// ValidateReportAuth checks the --report-user and --report-password values.
+func ValidateReportAuth(user string, password string) error {
+	if password != "" && user == "" {
+		return fmt.Errorf("--report-password requires --report-user")
+	}
+	return nil
+}
+
+// ShowCloudReport opens the Qodana Cloud report of the results in resultsDir in the browser.
+func ShowCloudReport(resultsDir string) error {
+	cloudUrl := cloud.GetReportUrl(resultsDir)
+	if cloudUrl == "" {
+		return fmt.Errorf("no Qodana Cloud report found in %s. Run `qodana scan` with the %s environment variable set to publish the report to Qodana Cloud, or run `qodana show` without --cloud to serve the local report", resultsDir, QodanaToken)
+	}
+	return openBrowser(cloudUrl)
+}
+
+// reportPortAttempts is the number of ports tried with --port-auto.
+const reportPortAttempts = 100
+
+// listenReportPort listens on the given host and port to serve the report.
+// If the port is busy and portAuto is set, the next free port is taken instead.
+func listenReportPort(host string, port int, portAuto bool) (net.Listener, error) {
+	for i := 0; i < reportPortAttempts && port+i <= 65535; i++ {
+		listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port+i)))
+		if err == nil {
+			if i > 0 {
+				WarningMessage("Port %d is busy, serving the report on port %d\n", port, port+i)
+			}
+			return listener, nil
+		}
+		if !portAuto {
+			return nil, fmt.Errorf("failed to serve the report on port %d: %w. Specify another port with --port or pass --port-auto to pick the next free one", port, err)
+		}
+	}
+	return nil, fmt.Errorf("failed to find a free port to serve the report on in %d-%d", port, min(port+reportPortAttempts-1, 65535))
+}
+
+// reportServerUrl returns the URL of the report served with the listener, localhost if it listens on all interfaces.
+func reportServerUrl(listener net.Listener) string {
+	addr := listener.Addr().(*net.TCPAddr)
+	host := "localhost"
+	if !addr.IP.IsUnspecified() {
+		host = addr.IP.String()
+	}
+	return "http://" + net.JoinHostPort(host, strconv.Itoa(addr.Port))
+}
+
+// reportHandler serves the report files in path, if password is set the requests require the basic auth.
+func reportHandler(path string, user string, password string) http.Handler {
+	handler := noCache(http.FileServer(http.Dir(path)))
+	if password == "" {
+		return handler
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestUser, requestPassword, ok := r.BasicAuth()
+		if !ok ||
+			subtle.ConstantTimeCompare([]byte(requestUser), []byte(user)) != 1 ||
+			subtle.ConstantTimeCompare([]byte(requestPassword), []byte(password)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="Qodana report", charset="UTF-8"`)
+			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// openReport serves the report with the given handler and listener and opens the browser.
+func openReport(cloudUrl string, handler http.Handler, listener net.Listener) {
 	if cloudUrl != "" {
 		resp, err := http.Get(cloudUrl)
 		if err == nil && resp.StatusCode == 200 {
@@ -272,18 +376,19 @@ func openReport(cloudUrl string, path string, port int) {
 		}
 		return
 	} else {
-		url := fmt.Sprintf("http://localhost:%d", port)
+		url := reportServerUrl(listener)
 		go func() {
 			resp, err := http.Get(url)
-			if err == nil && resp.StatusCode == 200 {
+			// the browser asks for the credentials
+			if err == nil && (resp.StatusCode == 200 || resp.StatusCode == http.StatusUnauthorized) {
 				err := openBrowser(url)
 				if err != nil {
 					return
 				}
 			}
 		}()
-		http.Handle("/", noCache(http.FileServer(http.Dir(path))))
-		err := http.ListenAndServe(fmt.Sprintf(":%d", port), nil)
+		http.Handle("/", handler)
+		err := http.Serve(listener, nil)
 		if err != nil {
 			WarningMessage("Problem serving report, %s\n", err.Error())
 			return
@@ -17,7 +17,11 @@
 package platform
 
 import (
+	"fmt"
 	"github.com/stretchr/testify/assert"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -203,3 +207,106 @@ func Test_runCmd(t *testing.T) {
 		}
 	}
 }
+
+func TestJavaExecutable(t *testing.T) {
+	javaHome := t.TempDir()
+	if _, err := JavaExecutable(javaHome); err == nil {
+		t.Error("expected an error for a Java home without java executable")
+	}
+
+	java := filepath.Join(javaHome, "bin", javaExecFileName())
+	assert.NoError(t, os.MkdirAll(filepath.Dir(java), 0o755))
+	assert.NoError(t, os.WriteFile(java, []byte{}, 0o644))
+	//goland:noinspection GoBoolExpressions
+	if runtime.GOOS != "windows" {
+		if _, err := JavaExecutable(javaHome); err == nil {
+			t.Error("expected an error for a non-executable java")
+		}
+		assert.NoError(t, os.Chmod(java, 0o755))
+	}
+
+	actual, err := JavaExecutable(javaHome)
+	assert.NoError(t, err)
+	assert.Equal(t, java, actual)
+}
+
+func TestShowCloudReportWithoutUrl(t *testing.T) {
+	resultsDir := t.TempDir()
+	err := ShowCloudReport(resultsDir)
+	assert.ErrorContains(t, err, "no Qodana Cloud report found in "+resultsDir)
+	assert.ErrorContains(t, err, QodanaToken)
+}
+
+func TestListenReportPort(t *testing.T) {
+	busy, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = busy.Close() }()
+	port := busy.Addr().(*net.TCPAddr).Port
+
+	_, err = listenReportPort("", port, false)
+	assert.ErrorContains(t, err, fmt.Sprintf("port %d", port))
+	assert.ErrorContains(t, err, "--port-auto")
+
+	listener, err := listenReportPort("", port, true)
+	assert.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+	assert.Greater(t, listener.Addr().(*net.TCPAddr).Port, port)
+}
+
+func TestReportHandlerBasicAuth(t *testing.T) {
+	reportDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(reportDir, "index.html"), []byte("report"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	handler := reportHandler(reportDir, "qodana", "secret")
+	for _, tc := range []struct {
+		name     string
+		auth     bool
+		user     string
+		password string
+		expected int
+	}{
+		{"no credentials", false, "", "", http.StatusUnauthorized},
+		{"wrong password", true, "qodana", "wrong", http.StatusUnauthorized},
+		{"wrong user", true, "admin", "secret", http.StatusUnauthorized},
+		{"valid credentials", true, "qodana", "secret", http.StatusOK},
+	} {
+		request := httptest.NewRequest(http.MethodGet, "/", nil)
+		if tc.auth {
+			request.SetBasicAuth(tc.user, tc.password)
+		}
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, request)
+		assert.Equal(t, tc.expected, recorder.Code, tc.name)
+		if tc.expected == http.StatusUnauthorized {
+			assert.Contains(t, recorder.Header().Get("WWW-Authenticate"), "Basic", tc.name)
+		}
+	}
+
+	recorder := httptest.NewRecorder()
+	reportHandler(reportDir, "", "").ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusOK, recorder.Code, "no auth without a password")
+	assert.Error(t, ValidateReportAuth("", "secret"))
+	assert.NoError(t, ValidateReportAuth("", ""))
+}
+
+func TestResolveLinter(t *testing.T) {
+	for _, name := range []string{"qodana-jvm", "jetbrains/qodana-jvm", Image(QDJVM)} {
+		linter, err := ResolveLinter(name)
+		assert.NoError(t, err, name)
+		assert.Equal(t, Image(QDJVM), linter, name)
+	}
+	linter, err := ResolveLinter("jetbrains/qodana-jvm:2024.1")
+	assert.NoError(t, err)
+	assert.Equal(t, "jetbrains/qodana-jvm:2024.1", linter)
+
+	_, err = ResolveLinter("qodana-cobol")
+	assert.ErrorContains(t, err, "qodana-jvm-community")
+
+	assert.NoError(t, ValidateNativeIde(QDJVM))
+	assert.NoError(t, ValidateNativeIde(QDJVM+"-EAP"))
+	assert.Error(t, ValidateNativeIde(QDAND))
+}
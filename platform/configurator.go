@@ -30,11 +30,12 @@ import (
 )
 
 const (
-	QodanaSarifName = "qodana.sarif.json"
-	configName      = "qodana"
-	ReleaseVersion  = "2024.3"
-	shortVersion    = "243"
-	isReleased      = true
+	QodanaSarifName      = "qodana.sarif.json"
+	QodanaShortSarifName = "qodana-short.sarif.json"
+	configName           = "qodana"
+	ReleaseVersion       = "2024.3"
+	shortVersion         = "243"
+	isReleased           = true
 )
 
 // langsProductCodes is a map of languages to linters.
@@ -0,0 +1,94 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// coverageFileNames are the common coverage reports detected in the project when --coverage-dir is not set.
+var coverageFileNames = []string{"jacoco.xml", "coverage.xml", "lcov.info"}
+
+// coverageSearchDepth limits how deep the project is searched for the coverage reports.
+const coverageSearchDepth = 6
+
+// resolveCoverageDir returns the coverage directory to use if --coverage-dir is not set: the default directory
+// if it has any data, otherwise the directory of the first coverage report found in the project, otherwise the default one.
+func resolveCoverageDir(defaultDir string, projectDir string) string {
+	if hasCoverageData(defaultDir) {
+		return defaultDir
+	}
+	if detected := detectCoverageDir(projectDir); detected != "" {
+		log.Debugf("Using coverage data from %s", detected)
+		return detected
+	}
+	return defaultDir
+}
+
+// detectCoverageDir returns the directory of the first coverage report (see coverageFileNames) found in projectDir,
+// hidden directories and node_modules are skipped. It returns an empty string if there is none.
+func detectCoverageDir(projectDir string) string {
+	if projectDir == "" {
+		return ""
+	}
+	detected := ""
+	_ = filepath.WalkDir(projectDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path == projectDir {
+				return nil
+			}
+			name := d.Name()
+			rel, _ := filepath.Rel(projectDir, path)
+			if strings.HasPrefix(name, ".") || name == "node_modules" || strings.Count(rel, string(filepath.Separator)) >= coverageSearchDepth {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if Contains(coverageFileNames, d.Name()) {
+			detected = filepath.Dir(path)
+			return filepath.SkipAll
+		}
+		return nil
+	})
+	return detected
+}
+
+// hasCoverageData reports whether dir exists and is not empty.
+func hasCoverageData(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	return err == nil && len(entries) > 0
+}
+
+// CoverageWarning returns a warning if the coverage thresholds are configured in qodana.yaml,
+// but there is no coverage data to check them, otherwise an empty string.
+func (o *QodanaOptions) CoverageWarning() string {
+	if o.QdConfig.FailureConditions.TestCoverageThresholds == nil {
+		return ""
+	}
+	if dir := o.CoverageDirPath(); !hasCoverageData(dir) {
+		return fmt.Sprintf("testCoverageThresholds are set in qodana.yaml, but no coverage data is found in %s, so they will not be checked. Pass the directory with the coverage data via --coverage-dir", dir)
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCoverageDirPath(t *testing.T) {
+	projectDir := t.TempDir()
+	for _, path := range []string{
+		filepath.Join(".gradle", "jacoco.xml"),
+		filepath.Join("app", "build", "reports", "jacoco.xml"),
+	} {
+		writeCoverageFile(t, filepath.Join(projectDir, path))
+	}
+	defaultDir := filepath.Join(projectDir, ".qodana", "code-coverage")
+
+	// the detected report is used when the default directory is empty
+	options := &QodanaOptions{ProjectDir: projectDir}
+	assert.Equal(t, filepath.Join(projectDir, "app", "build", "reports"), options.CoverageDirPath())
+
+	// the default directory takes precedence over the detected report
+	writeCoverageFile(t, filepath.Join(defaultDir, "lcov.info"))
+	options = &QodanaOptions{ProjectDir: projectDir}
+	assert.Equal(t, defaultDir, options.CoverageDirPath())
+
+	// --coverage-dir takes precedence over both
+	options = &QodanaOptions{ProjectDir: projectDir, CoverageDir: "/tmp/coverage"}
+	assert.Equal(t, "/tmp/coverage", options.CoverageDirPath())
+
+	// no coverage data at all
+	options = &QodanaOptions{ProjectDir: t.TempDir()}
+	assert.Equal(t, filepath.Join(options.ProjectDir, ".qodana", "code-coverage"), options.CoverageDirPath())
+}
+
+func TestCoverageWarning(t *testing.T) {
+	options := &QodanaOptions{ProjectDir: t.TempDir()}
+	assert.Empty(t, options.CoverageWarning())
+
+	options.QdConfig.FailureConditions.TestCoverageThresholds = &CoverageThresholds{}
+	assert.Contains(t, options.CoverageWarning(), "--coverage-dir")
+
+	writeCoverageFile(t, filepath.Join(options.ProjectDir, "coverage", "coverage.xml"))
+	options.CoverageDir = ""
+	assert.Empty(t, options.CoverageWarning())
+}
+
+func writeCoverageFile(t *testing.T, path string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("<report/>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,189 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/JetBrains/qodana-cli/v2024/sarif"
+)
+
+// ReportDiff contains the problems that appeared, disappeared or stayed between two SARIF reports.
+type ReportDiff struct {
+	Added     []sarif.Result
+	Removed   []sarif.Result
+	Unchanged []sarif.Result
+}
+
+// diffProblem is the JSON representation of a problem in the report diff.
+type diffProblem struct {
+	RuleId   string `json:"ruleId"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	File     string `json:"file,omitempty"`
+	Line     int    `json:"line,omitempty"`
+}
+
+// DiffReports compares the problems of two SARIF reports by their fingerprints.
+// Results without fingerprints are compared by rule id, file and start line.
+func DiffReports(from *sarif.Report, to *sarif.Report) *ReportDiff {
+	diff := &ReportDiff{}
+	previous := make(map[string][]sarif.Result)
+	var keys []string
+	for _, r := range reportResults(from) {
+		key := diffKey(&r)
+		if _, ok := previous[key]; !ok {
+			keys = append(keys, key)
+		}
+		previous[key] = append(previous[key], r)
+	}
+	for _, r := range reportResults(to) {
+		key := diffKey(&r)
+		if matched := previous[key]; len(matched) > 0 {
+			diff.Unchanged = append(diff.Unchanged, r)
+			previous[key] = matched[1:]
+		} else {
+			diff.Added = append(diff.Added, r)
+		}
+	}
+	for _, key := range keys {
+		diff.Removed = append(diff.Removed, previous[key]...)
+	}
+	return diff
+}
+
+// reportResults returns the results of all runs of the report, skipping the absent baseline results.
+func reportResults(report *sarif.Report) []sarif.Result {
+	var results []sarif.Result
+	for _, run := range report.Runs {
+		for _, r := range run.Results {
+			if r.BaselineState != baselineStateAbsent {
+				results = append(results, r)
+			}
+		}
+	}
+	return results
+}
+
+// diffKey returns the fingerprint of the result or a synthetic ruleId+uri+startLine key if there is none.
+func diffKey(r *sarif.Result) string {
+	if fingerprint := findFingerprint(r); fingerprint != "" {
+		return fingerprint
+	}
+	file, line := resultLocation(r)
+	return fmt.Sprintf("%s:%s:%d", r.RuleId, file, line)
+}
+
+// resultLocation returns the file and the start line of the first location of the result.
+func resultLocation(r *sarif.Result) (string, int) {
+	if len(r.Locations) == 0 || r.Locations[0].PhysicalLocation == nil {
+		return "", 0
+	}
+	location := r.Locations[0].PhysicalLocation
+	file, line := "", 0
+	if location.ArtifactLocation != nil {
+		file = location.ArtifactLocation.Uri
+	}
+	if location.Region != nil {
+		line = int(location.Region.StartLine)
+	}
+	return file, line
+}
+
+// Print prints the numbers of added, removed and unchanged problems, and the added and removed problems if printProblems is set.
+func (d *ReportDiff) Print(printProblems bool) {
+	if printProblems {
+		for _, group := range []struct {
+			title   string
+			results []sarif.Result
+		}{
+			{"Added problems", d.Added},
+			{"Removed problems", d.Removed},
+		} {
+			if len(group.results) == 0 {
+				continue
+			}
+			EmptyMessage()
+			fmt.Println(PrimaryBold(group.title))
+			EmptyMessage()
+			for _, r := range group.results {
+				printDiffProblem(&r)
+			}
+		}
+		EmptyMessage()
+	}
+	if len(d.Added) > 0 {
+		ErrorMessage("Added: %d", len(d.Added))
+	} else {
+		SuccessMessage("Added: 0")
+	}
+	SuccessMessage("Removed: %d", len(d.Removed))
+	SuccessMessage("Unchanged: %d", len(d.Unchanged))
+}
+
+// printDiffProblem prints the problem, tolerating results without locations or code snippets.
+func printDiffProblem(r *sarif.Result) {
+	message := ""
+	if r.Message != nil {
+		message = r.Message.Text
+	}
+	file, line := resultLocation(r)
+	column, contextLine, context := 0, 0, ""
+	if len(r.Locations) > 0 && r.Locations[0].PhysicalLocation != nil {
+		location := r.Locations[0].PhysicalLocation
+		if location.Region != nil {
+			column = int(location.Region.StartColumn)
+		}
+		if location.ContextRegion != nil && location.ContextRegion.Snippet != nil {
+			contextLine = int(location.ContextRegion.StartLine)
+			context = location.ContextRegion.Snippet.Text
+		}
+	}
+	printProblem(r.RuleId, getSeverity(r), message, file, line, column, contextLine, context)
+}
+
+// ToJSON returns the JSON representation of the diff.
+func (d *ReportDiff) ToJSON() (string, error) {
+	output := map[string]interface{}{
+		"added":     toDiffProblems(d.Added),
+		"removed":   toDiffProblems(d.Removed),
+		"unchanged": toDiffProblems(d.Unchanged),
+		"total": map[string]int{
+			"added":     len(d.Added),
+			"removed":   len(d.Removed),
+			"unchanged": len(d.Unchanged),
+		},
+	}
+	out, err := json.MarshalIndent(output, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+func toDiffProblems(results []sarif.Result) []diffProblem {
+	problems := make([]diffProblem, 0, len(results))
+	for _, r := range results {
+		problem := diffProblem{RuleId: r.RuleId, Severity: getSeverity(&r)}
+		if r.Message != nil {
+			problem.Message = r.Message.Text
+		}
+		problem.File, problem.Line = resultLocation(&r)
+		problems = append(problems, problem)
+	}
+	return problems
+}
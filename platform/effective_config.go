@@ -0,0 +1,95 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"path/filepath"
+)
+
+// EffectiveConfig is the configuration that applies to the analysis: qodana.yaml (with --config-override and
+// the environment variables expanded) overridden by the command-line options.
+type EffectiveConfig struct {
+	ProjectDir        string                 `yaml:"projectDir" json:"projectDir"`
+	ConfigFile        string                 `yaml:"configFile" json:"configFile"`
+	Linter            string                 `yaml:"linter,omitempty" json:"linter,omitempty"`
+	Ide               string                 `yaml:"ide,omitempty" json:"ide,omitempty"`
+	ProfileName       string                 `yaml:"profileName,omitempty" json:"profileName,omitempty"`
+	ProfilePath       string                 `yaml:"profilePath,omitempty" json:"profilePath,omitempty"`
+	Script            string                 `yaml:"script" json:"script"`
+	ScriptParameters  map[string]interface{} `yaml:"scriptParameters,omitempty" json:"scriptParameters,omitempty"`
+	Properties        map[string]string      `yaml:"properties,omitempty" json:"properties,omitempty"`
+	FailureThresholds map[string]string      `yaml:"failureThresholds,omitempty" json:"failureThresholds,omitempty"`
+	Bootstrap         string                 `yaml:"bootstrap,omitempty" json:"bootstrap,omitempty"`
+}
+
+// EffectiveConfig computes the configuration the analysis would run with, without starting it.
+func (o *QodanaOptions) EffectiveConfig() (*EffectiveConfig, error) {
+	qodanaYamlPath := FindQodanaYaml(o.ProjectDir)
+	if o.ConfigName != "" {
+		qodanaYamlPath = o.ConfigName
+	}
+	qdConfig, _, err := o.LoadQodanaYaml(qodanaYamlPath)
+	if err != nil {
+		return nil, err
+	}
+	qdConfig.ResolveProfilePath(o.ProjectDir, qodanaYamlPath)
+	if err = qdConfig.LoadPropertiesFile(o.ProjectDir); err != nil {
+		return nil, err
+	}
+	if err = qdConfig.ExpandEnv(); err != nil {
+		return nil, err
+	}
+	if !filepath.IsAbs(qodanaYamlPath) {
+		qodanaYamlPath = filepath.Join(o.ProjectDir, qodanaYamlPath)
+	}
+
+	config := &EffectiveConfig{
+		ProjectDir:        o.ProjectDir,
+		ConfigFile:        qodanaYamlPath,
+		Linter:            o.Linter,
+		Ide:               o.Ide,
+		ProfileName:       o.ProfileName,
+		ProfilePath:       o.ProfilePath,
+		Script:            o.Script,
+		Properties:        map[string]string{},
+		FailureThresholds: getFailureThresholds(qdConfig, o),
+		Bootstrap:         qdConfig.Bootstrap,
+	}
+	if config.Linter == "" && config.Ide == "" {
+		config.Linter = qdConfig.Linter
+		config.Ide = qdConfig.Ide
+	}
+	if config.ProfileName == "" && config.ProfilePath == "" {
+		config.ProfileName = qdConfig.Profile.Name
+		config.ProfilePath = qdConfig.Profile.Path
+	}
+	if (config.Script == "" || config.Script == "default") && qdConfig.Script.Name != "" {
+		config.Script = qdConfig.Script.Name
+		config.ScriptParameters = qdConfig.Script.Parameters
+	}
+	if config.Script == "" {
+		config.Script = "default"
+	}
+	for k, v := range qdConfig.Properties {
+		config.Properties[k] = v
+	}
+	cliProps, _ := o.Properties()
+	for k, v := range cliProps { // CLI – overrides qodana.yaml
+		config.Properties[k] = v
+	}
+	return config, nil
+}
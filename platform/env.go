@@ -34,6 +34,7 @@ const (
 	QodanaDockerEnv          = "QODANA_DOCKER"
 	QodanaToolEnv            = "QODANA_TOOL"
 	QodanaConfEnv            = "QODANA_CONF"
+	QodanaConfigNameEnv      = "QODANA_CONFIG_NAME"
 	qodanaClearKeyring       = "QODANA_CLEAR_KEYRING"
 	qodanaEnv                = "QODANA_ENV"
 	qodanaJobUrl             = "QODANA_JOB_URL"
@@ -74,6 +75,9 @@ func ExtractQodanaEnvironment(setEnvironmentFunc func(string, string)) {
 	if revision := os.Getenv(QodanaRevision); revision != "" {
 		setEnvironmentFunc(QodanaRevision, revision)
 	}
+	if configNames := os.Getenv(QodanaConfigNameEnv); configNames != "" {
+		setEnvironmentFunc(QodanaConfigNameEnv, configNames)
+	}
 	ci := cienvironment.DetectCIEnvironment()
 	qEnv := "cli"
 	if ci != nil {
@@ -101,6 +105,19 @@ func ExtractQodanaEnvironment(setEnvironmentFunc func(string, string)) {
 	setEnvironmentFunc(qodanaEnv, fmt.Sprintf("%s:%s", qEnv, Version))
 }
 
+// proxyEnvironment are the standard proxy variables, in both cases as the tools read either of them.
+var proxyEnvironment = []string{"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy"}
+
+// ExtractProxyEnvironment passes the proxy variables set on the host, so the license and Qodana Cloud requests
+// from the container go through the same proxy. The native runs inherit them.
+func ExtractProxyEnvironment(setEnvironmentFunc func(string, string)) {
+	for _, name := range proxyEnvironment {
+		if value := os.Getenv(name); value != "" {
+			setEnvironmentFunc(name, value)
+		}
+	}
+}
+
 func getCIName(ci *cienvironment.CiEnvironment) string {
 	return strings.ReplaceAll(strings.ToLower(ci.Name), " ", "-")
 }
@@ -225,6 +242,11 @@ func isBitBucket() bool {
 	return os.Getenv("BITBUCKET_PIPELINE_UUID") != ""
 }
 
+// isAzure returns true if the current environment is Azure Pipelines.
+func isAzure() bool {
+	return strings.EqualFold(os.Getenv("TF_BUILD"), "true")
+}
+
 // isBitBucketPipe returns true if the current environment is in a working BitBucket Pipe.
 func isBitBucketPipe() bool {
 	return os.Getenv("BITBUCKET_PIPE_STORAGE_DIR") != "" || os.Getenv("BITBUCKET_PIPE_SHARED_STORAGE_DIR") != ""
@@ -0,0 +1,90 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"fmt"
+	"github.com/JetBrains/qodana-cli/v2024/sarif"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// https://learn.microsoft.com/en-us/azure/devops/pipelines/scripts/logging-commands#logissue-log-an-error-or-warning
+const (
+	azureLogIssueError   = "error"
+	azureLogIssueWarning = "warning"
+)
+
+// toAzureLogIssueType maps SARIF and Qodana severity levels to Azure Pipelines issue types, the rest are warnings.
+var toAzureLogIssueType = map[string]string{
+	sarifError:     azureLogIssueError,
+	qodanaCritical: azureLogIssueError,
+	qodanaHigh:     azureLogIssueError,
+}
+
+// azurePropertyEscaper escapes the values of the logging command properties.
+var azurePropertyEscaper = strings.NewReplacer("%", "%AZP25", ";", "%3B", "\r", "%0D", "\n", "%0A", "]", "%5D")
+
+// azureMessageEscaper escapes the message of the logging command.
+var azureMessageEscaper = strings.NewReplacer("%", "%AZP25", "\r", "%0D", "\n", "%0A")
+
+// azureSourcePrefix returns the path of projectDir relative to the repository root,
+// the SARIF URIs are relative to the project directory while Azure Pipelines expects repository-relative paths.
+func azureSourcePrefix(projectDir string) string {
+	if projectDir == "" {
+		return ""
+	}
+	root := os.Getenv("BUILD_SOURCESDIRECTORY")
+	if root == "" {
+		var err error
+		if root, err = GitRoot(projectDir, ""); err != nil {
+			return ""
+		}
+	}
+	absProjectDir, err := filepath.Abs(projectDir)
+	if err != nil {
+		return ""
+	}
+	prefix, err := filepath.Rel(root, absProjectDir)
+	if err != nil || prefix == "." || strings.HasPrefix(prefix, "..") {
+		return ""
+	}
+	return filepath.ToSlash(prefix)
+}
+
+// sarifResultToAzureLogIssue converts a SARIF result to an Azure Pipelines task.logissue logging command.
+func sarifResultToAzureLogIssue(r *sarif.Result, sourcePrefix string) string {
+	issueType, ok := toAzureLogIssueType[getSeverity(r)]
+	if !ok {
+		issueType = azureLogIssueWarning
+	}
+	properties := []string{"type=" + issueType}
+	if location := extractLocationProperties(r); location != nil {
+		properties = append(
+			properties,
+			"sourcepath="+azurePropertyEscaper.Replace(path.Join(sourcePrefix, location.Uri)),
+			fmt.Sprintf("linenumber=%d", location.StartLine),
+		)
+		if column := r.Locations[0].PhysicalLocation.Region.StartColumn; column > 0 {
+			properties = append(properties, fmt.Sprintf("columnnumber=%d", column))
+		}
+	}
+	properties = append(properties, "code="+azurePropertyEscaper.Replace(r.RuleId))
+	return fmt.Sprintf("##vso[task.logissue %s;]%s", strings.Join(properties, ";"), azureMessageEscaper.Replace(r.Message.Text))
+}
@@ -63,13 +63,13 @@ var (
 	}
 )
 
-// sendBitBucketReport sends annotations to BitBucket Code Insights
-func sendBitBucketReport(annotations []bbapi.ReportAnnotation, toolName, cloudUrl, reportId string) error {
+// sendBitBucketReport sends annotations to BitBucket Code Insights, the report fails if the fail thresholds are exceeded
+func sendBitBucketReport(annotations []bbapi.ReportAnnotation, newProblems int, failed bool, toolName, cloudUrl, reportId string) error {
 	client, ctx := getBitBucketClient(), getBitBucketContext()
 	repoOwner, repoName, sha := getBitBucketRepoOwner(), getBitBucketRepoName(), getBitBucketCommit()
 	_, resp, err := client.
 		ReportsApi.CreateOrUpdateReport(ctx, repoOwner, repoName, sha, reportId).
-		Body(buildReport(toolName, annotations, cloudUrl)).
+		Body(buildReport(toolName, newProblems, failed, cloudUrl)).
 		Execute()
 	if err = checkBitBucketApiError(err, resp, http.StatusOK); err != nil {
 		return fmt.Errorf("failed to create code insights report: %w", err)
@@ -118,11 +118,9 @@ func getBitBucketContext() context.Context {
 }
 
 // buildReport builds a report to be sent to BitBucket Code Insights
-func buildReport(toolName string, annotations []bbapi.ReportAnnotation, cloudUrl string) bbapi.Report {
-	var result string
-	if len(annotations) == 0 {
-		result = bitBucketReportPassed
-	} else {
+func buildReport(toolName string, newProblems int, failed bool, cloudUrl string) bbapi.Report {
+	result := bitBucketReportPassed
+	if failed {
 		result = bitBucketReportFailed
 	}
 
@@ -132,13 +130,13 @@ func buildReport(toolName string, annotations []bbapi.ReportAnnotation, cloudUrl
 	data.SetReporter(bitBucketReporter)
 	data.SetLogoUrl(bitBucketAvatar)
 	data.SetLink(cloudUrl)
-	data.SetDetails(getProblemsFoundMessage(len(annotations)))
+	data.SetDetails(getProblemsFoundMessage(newProblems))
 	data.SetResult(result)
 	return *data
 }
 
 // buildAnnotation builds an annotation to be sent to BitBucket Code Insights
-func buildAnnotation(r *sarif.Result, ruleDescription string, reportLink string) bbapi.ReportAnnotation {
+func buildAnnotation(r *sarif.Result, ruleDescription string, reportLink string, uriBase string) bbapi.ReportAnnotation {
 	bbSeverity, ok := toBitBucketSeverity[getSeverity(r)]
 	if !ok {
 		log.Debugf("Unknown SARIF severity: %s", getSeverity(r))
@@ -161,7 +159,11 @@ func buildAnnotation(r *sarif.Result, ruleDescription string, reportLink string)
 			data.SetPath(location.ArtifactLocation.Uri)
 		}
 	}
-	data.SetLink(reportLink)
+	if link := resultSourceLink(uriBase, r); link != "" {
+		data.SetLink(link)
+	} else {
+		data.SetLink(reportLink)
+	}
 	return *data
 }
 
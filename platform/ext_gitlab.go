@@ -23,6 +23,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"os"
 	"path/filepath"
+	"sort"
 )
 
 // https://docs.gitlab.com/ee/ci/testing/code_quality.html#implement-a-custom-tool
@@ -115,8 +116,27 @@ func sarifResultToCodeClimate(r *sarif.Result) CCIssue {
 	}
 }
 
+// sortCodeClimateIssues orders the issues by path, line, check name and fingerprint,
+// so the same results always produce the same report regardless of the SARIF order.
+func sortCodeClimateIssues(issues []CCIssue) {
+	sort.SliceStable(issues, func(i, j int) bool {
+		a, b := issues[i], issues[j]
+		if a.Location.Path != b.Location.Path {
+			return a.Location.Path < b.Location.Path
+		}
+		if a.Location.Lines.Begin != b.Location.Lines.Begin {
+			return a.Location.Lines.Begin < b.Location.Lines.Begin
+		}
+		if a.CheckName != b.CheckName {
+			return a.CheckName < b.CheckName
+		}
+		return a.Fingerprint < b.Fingerprint
+	})
+}
+
 // writeGlCodeQualityReport saves GitLab CodeQuality issues to a file in JSON format
 func writeGlCodeQualityReport(issues []CCIssue, sarifPath string) error {
+	sortCodeClimateIssues(issues)
 	outputFile := filepath.Join(filepath.Dir(sarifPath), glCodeQualityReport)
 	file, err := os.Create(outputFile)
 	if err != nil {
@@ -17,8 +17,12 @@
 package platform
 
 import (
+	"bytes"
 	"encoding/json"
+	"github.com/JetBrains/qodana-cli/v2024/sarif"
 	bbapi "github.com/reviewdog/go-bitbucket"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 )
@@ -229,7 +233,7 @@ func TestSarifResultToBitBucketAnnotation(t *testing.T) {
 	}
 	annotations := make([]bbapi.ReportAnnotation, len(sarifReport.Runs[0].Results))
 	for i, r := range sarifReport.Runs[0].Results {
-		annotations[i] = buildAnnotation(&r, "This is a long boring description", "")
+		annotations[i] = buildAnnotation(&r, "This is a long boring description", "", "")
 	}
 	expectedAnnotations := getExpectedAnnotations(t)
 	for i, annotation := range annotations { // doing comparison like this because only some fields are interesting
@@ -320,14 +324,135 @@ func TestSarifResultToCodeClimate(t *testing.T) {
 	}
 }
 
+// TestWriteGlCodeQualityReportStable tests that the report doesn't depend on the order of the SARIF results.
+func TestWriteGlCodeQualityReportStable(t *testing.T) {
+	sarifReport, err := ReadReportFromString(sarifFileData)
+	if err != nil {
+		t.Fatalf("Failed to parse SARIF file: %v", err)
+	}
+	results := sarifReport.Runs[0].Results
+
+	var reports [][]byte
+	for _, reversed := range []bool{false, true} {
+		issues := make([]CCIssue, 0, len(results))
+		for i := range results {
+			if reversed {
+				i = len(results) - 1 - i
+			}
+			issues = append(issues, sarifResultToCodeClimate(&results[i]))
+		}
+		sarifPath := filepath.Join(t.TempDir(), QodanaSarifName)
+		if err := writeGlCodeQualityReport(issues, sarifPath); err != nil {
+			t.Fatal(err)
+		}
+		report, err := os.ReadFile(filepath.Join(filepath.Dir(sarifPath), glCodeQualityReport))
+		if err != nil {
+			t.Fatal(err)
+		}
+		reports = append(reports, report)
+	}
+
+	if !bytes.Equal(reports[0], reports[1]) {
+		t.Errorf("GitLab CodeQuality report depends on the results order:\n%s\n%s", reports[0], reports[1])
+	}
+	var issues []CCIssue
+	if err := json.Unmarshal(reports[0], &issues); err != nil {
+		t.Fatal(err)
+	}
+	if issues[0].CheckName != "MissingLevel" || issues[len(issues)-1].CheckName != "GoUnusedExportedFunction" {
+		t.Errorf("unexpected issues order: %+v", issues)
+	}
+}
+
+func TestBuildBitBucketReportResult(t *testing.T) {
+	counts := newProblemCounts{}
+	for _, severity := range []string{qodanaHigh, qodanaHigh, qodanaLow} {
+		counts.add(&sarif.Result{Properties: &sarif.PropertyBag{AdditionalProperties: map[string]interface{}{"qodanaSeverity": severity}}})
+	}
+	for _, tc := range []struct {
+		name       string
+		thresholds map[string]string
+		expected   string
+	}{
+		{"no thresholds", nil, bitBucketReportPassed},
+		{"total threshold not exceeded", map[string]string{severityAny: "3"}, bitBucketReportPassed},
+		{"total threshold exceeded", map[string]string{severityAny: "2"}, bitBucketReportFailed},
+		{"severity threshold exceeded", map[string]string{severityHigh: "1", severityLow: "5"}, bitBucketReportFailed},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			failed, err := counts.exceeds(tc.thresholds)
+			if err != nil {
+				t.Fatal(err)
+			}
+			report := buildReport("Qodana for JVM", counts[severityAny], failed, "")
+			if *report.Result != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, *report.Result)
+			}
+			if *report.Details != getProblemsFoundMessage(3) {
+				t.Errorf("unexpected details: %s", *report.Details)
+			}
+		})
+	}
+}
+
 // Uncomment for local testing
 //func TestBitBucketRequest(t *testing.T) {
 //	os.Setenv("BITBUCKET_TEST", "true")
 //	os.Setenv("BITBUCKET_REPO_FULL_NAME", "tiulpin_/code-analytics-examples")
 //	os.Setenv("BITBUCKET_COMMIT", "fa099b0")
 //	log.SetLevel(log.DebugLevel)
-//	err := sendBitBucketReport(getExpectedAnnotations(t), "Qodana for Everyone", "https://jetbrains.com/qodana/", "qodana-1")
+//	err := sendBitBucketReport(getExpectedAnnotations(t), 1, false, "Qodana for Everyone", "https://jetbrains.com/qodana/", "qodana-1")
 //	if err != nil {
 //		t.Errorf("Failed to send BitBucket report: %v", err)
 //	}
 //}
+
+func TestSarifResultToAzureLogIssue(t *testing.T) {
+	sarifReport, err := ReadReportFromString(sarifFileData)
+	if err != nil {
+		t.Fatalf("Failed to parse SARIF file: %v", err)
+	}
+
+	expectedIssues := []string{
+		"##vso[task.logissue type=warning;sourcepath=service/src/main/java/AppStarter.java;linenumber=12;code=GoUnusedExportedFunction;]Unused function 'SaveReportFile'",
+		"##vso[task.logissue type=error;sourcepath=service/src/main/java/AppStarter.java;linenumber=9;code=VulnerableLibrariesLocal;]Dependency go:golang.org/x/crypto:v0.17.0 is vulnerable, safe version v0.21.0 CVE-2023-42818 9.8 Improper Restriction of Excessive Authentication Attempts vulnerability with High severity found Results powered by Checkmarx(c)",
+		"##vso[task.logissue type=warning;sourcepath=service/src/main/java/AppStarter.java;linenumber=2;code=ExampleNoteLevel;]This is an example note level message.",
+		"##vso[task.logissue type=warning;code=MissingLevel;]This result does not specify a level.",
+		"##vso[task.logissue type=warning;code=PhysicalLocationNilTest;]Testing when PhysicalLocation is nil",
+	}
+	for i, result := range sarifReport.Runs[0].Results {
+		if issue := sarifResultToAzureLogIssue(&result, "service"); issue != expectedIssues[i] {
+			t.Errorf("Issue at index %d does not match expected. Got %q, want %q", i, issue, expectedIssues[i])
+		}
+	}
+
+	escaped, err := ReadReportFromString(`{"version": "2.1.0", "runs": [{"results": [
+{"ruleId": "Rule;1", "message": {"text": "100% wrong\nvalue"}, "properties": {"qodanaSeverity": "Critical"},
+ "locations": [{"physicalLocation": {"artifactLocation": {"uri": "a;b.py"}, "region": {"startLine": 3, "startColumn": 7}}}]}
+]}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "##vso[task.logissue type=error;sourcepath=a%3Bb.py;linenumber=3;columnnumber=7;code=Rule%3B1;]100%AZP25 wrong%0Avalue"
+	if issue := sarifResultToAzureLogIssue(&escaped.Runs[0].Results[0], ""); issue != expected {
+		t.Errorf("Got %q, want %q", issue, expected)
+	}
+}
+
+func TestAzureSourcePrefix(t *testing.T) {
+	root := t.TempDir()
+	t.Setenv("BUILD_SOURCESDIRECTORY", root)
+	for _, testData := range []struct {
+		projectDir string
+		expected   string
+	}{
+		{root, ""},
+		{filepath.Join(root, "services", "api"), "services/api"},
+		{filepath.Dir(root), ""},
+		{"", ""},
+	} {
+		if actual := azureSourcePrefix(testData.projectDir); actual != testData.expected {
+			t.Errorf("project %q: expected %q, got %q", testData.projectDir, testData.expected, actual)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/JetBrains/qodana-cli/v2024/sarif"
+)
+
+// generatedFileGlobs are the built-in patterns of generated files dropped from the results by --exclude-generated.
+// A pattern without a slash is matched against the file name, otherwise against the trailing path segments.
+var generatedFileGlobs = []string{
+	"*_pb2.py",
+	"*_pb2_grpc.py",
+	"*_pb2.pyi",
+	"*.pb.go",
+	"*_grpc.pb.go",
+	"*.pb.cc",
+	"*.pb.h",
+	"*.pb.swift",
+	"*_pb.js",
+	"*_pb.d.ts",
+	"*.g.dart",
+	"*.freezed.dart",
+	"*.mocks.dart",
+	"*.g.cs",
+	"*.Designer.cs",
+	"*.generated.*",
+	"*_generated.go",
+	"zz_generated.*.go",
+	"*.gen.go",
+	"*.min.js",
+}
+
+// isGeneratedFile checks if the file (a SARIF artifact URI) matches any of the given globs.
+func isGeneratedFile(file string, globs []string) bool {
+	file = strings.TrimPrefix(strings.ReplaceAll(file, "\\", "/"), "file://")
+	segments := strings.Split(file, "/")
+	for _, glob := range globs {
+		if !strings.Contains(glob, "/") {
+			if matched, _ := path.Match(glob, segments[len(segments)-1]); matched {
+				return true
+			}
+			continue
+		}
+		for i := range segments {
+			if matched, _ := path.Match(glob, strings.Join(segments[i:], "/")); matched {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ExcludeGeneratedResults removes the results located in generated files from the report at sarifPath,
+// returns the number of removed results.
+func ExcludeGeneratedResults(sarifPath string, globs []string) (int, error) {
+	report, err := ReadReport(sarifPath)
+	if err != nil {
+		return 0, fmt.Errorf("error reading SARIF %s: %w", sarifPath, err)
+	}
+	removed := 0
+	for i := range report.Runs {
+		results := make([]sarif.Result, 0, len(report.Runs[i].Results))
+		for _, r := range report.Runs[i].Results {
+			if file, _ := resultLocation(&r); file != "" && isGeneratedFile(file, globs) {
+				removed++
+				continue
+			}
+			results = append(results, r)
+		}
+		report.Runs[i].Results = results
+	}
+	if removed == 0 {
+		return 0, nil
+	}
+	return removed, WriteReport(sarifPath, report)
+}
+
+// GeneratedFileGlobs returns the built-in generated file patterns extended with the ones from qodana.yaml.
+func (q *QodanaYaml) GeneratedFileGlobs() []string {
+	return append(append([]string{}, generatedFileGlobs...), q.GeneratedFiles...)
+}
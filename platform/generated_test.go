@@ -0,0 +1,79 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestExcludeGeneratedResults(t *testing.T) {
+	sarifPath := filepath.Join(t.TempDir(), QodanaSarifName)
+	report := `{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "QDPY"}}, "results": [
+{"ruleId": "PyUnusedLocal", "message": {"text": "Unused local"},
+ "locations": [{"physicalLocation": {"artifactLocation": {"uri": "proto/service_pb2.py"}, "region": {"startLine": 12}}}]},
+{"ruleId": "PyUnusedLocal", "message": {"text": "Unused local"},
+ "locations": [{"physicalLocation": {"artifactLocation": {"uri": "src/service.py"}, "region": {"startLine": 7}}}]},
+{"ruleId": "PyUnusedLocal", "message": {"text": "Unused local"},
+ "locations": [{"physicalLocation": {"artifactLocation": {"uri": "src/api/client.py"}, "region": {"startLine": 3}}}]}
+]}]}`
+	if err := os.WriteFile(sarifPath, []byte(report), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	yaml := &QodanaYaml{GeneratedFiles: []string{"api/*.py"}}
+	removed, err := ExcludeGeneratedResults(sarifPath, yaml.GeneratedFileGlobs())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 2 {
+		t.Errorf("expected 2 removed results, got %d", removed)
+	}
+	actual, err := ReadReport(sarifPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := actual.Runs[0].Results
+	if len(results) != 1 {
+		t.Fatalf("expected 1 result, got %d", len(results))
+	}
+	if file, _ := resultLocation(&results[0]); file != "src/service.py" {
+		t.Errorf("expected the result in src/service.py to be kept, got %s", file)
+	}
+}
+
+func TestIsGeneratedFile(t *testing.T) {
+	for _, testData := range []struct {
+		file     string
+		expected bool
+	}{
+		{"service_pb2.py", true},
+		{"file:///data/project/gen/service_pb2_grpc.py", true},
+		{"lib\\models\\user.g.dart", true},
+		{"api/v1/api.pb.go", true},
+		{"Forms/MainForm.Designer.cs", true},
+		{"src/pb2.py", false},
+		{"lib/user.dart", false},
+	} {
+		t.Run(testData.file, func(t *testing.T) {
+			if actual := isGeneratedFile(testData.file, generatedFileGlobs); actual != testData.expected {
+				t.Errorf("isGeneratedFile(%q) = %v, expected %v", testData.file, actual, testData.expected)
+			}
+		})
+	}
+}
@@ -17,7 +17,11 @@
 package platform
 
 import (
+	"fmt"
+	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
+	"os"
+	"os/exec"
 	"strings"
 )
 
@@ -118,6 +122,15 @@ func GitCurrentRevision(cwd string, logdir string) (string, error) {
 	return strings.TrimSpace(stdout), nil
 }
 
+// GitMergeBase returns the best common ancestor of HEAD and the given revision.
+func GitMergeBase(cwd string, revision string, logdir string) (string, error) {
+	stdout, _, err := gitRun(cwd, []string{"merge-base", "HEAD", revision}, logdir)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(stdout), nil
+}
+
 // GitRevisionExists returns true when revision exists in history.
 func GitRevisionExists(cwd string, revision string, logdir string) bool {
 	_, stderr, err := gitRun(cwd, []string{"show", "--no-patch", revision}, logdir)
@@ -126,3 +139,66 @@ func GitRevisionExists(cwd string, revision string, logdir string) bool {
 	}
 	return true
 }
+
+// IsGitRepository returns true if cwd is inside a git work tree.
+func IsGitRepository(cwd string) bool {
+	stdout, _, ret, err := RunCmdRedirectOutput(cwd, "git", "rev-parse", "--is-inside-work-tree")
+	return err == nil && ret == 0 && strings.TrimSpace(stdout) == "true"
+}
+
+// GitSubmoduleUpdate initializes and updates the submodules of the repository recursively.
+func GitSubmoduleUpdate(cwd string, logdir string) error {
+	_, stderr, err := gitRun(cwd, []string{"submodule", "update", "--init", "--recursive"}, logdir)
+	if err == nil && strings.Contains(stderr, "fatal:") {
+		err = fmt.Errorf("%s", strings.TrimSpace(stderr))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update the git submodules in %s: %w", cwd, err)
+	}
+	return nil
+}
+
+// InitGitSubmodules checks out the submodules of the project repository before the analysis (--init-submodules),
+// so their sources are analyzed too. It does nothing if the project is not in a git repository.
+func (o *QodanaOptions) InitGitSubmodules() error {
+	if !o.InitSubmodules {
+		return nil
+	}
+	if _, err := exec.LookPath("git"); err != nil {
+		return fmt.Errorf("--init-submodules requires git, but it's not found: %w", err)
+	}
+	if !IsGitRepository(o.ProjectDir) {
+		WarningMessage("%s is not a git repository, --init-submodules is ignored", o.ProjectDir)
+		return nil
+	}
+	return GitSubmoduleUpdate(o.ProjectDir, o.LogDirPath())
+}
+
+// DeriveAnalysisId sets the analysis id derived from the remote url of the repository (QODANA_REMOTE_URL if set)
+// and the analyzed commit (--commit or HEAD), so the runs of the same commit get the same id.
+// The id is a name-based (MD5) UUID like the device id, see GetDeviceIdSalt.
+// It's called before the results directory is known (the id is used by the {id} token), so git isn't logged.
+func (o *QodanaOptions) DeriveAnalysisId() error {
+	if !o.AnalysisIdFromCommit {
+		return nil
+	}
+	revision := strings.TrimPrefix(o.Commit, "CI")
+	if revision == "" {
+		head, err := GitCurrentRevision(o.ProjectDir, "")
+		if err != nil || head == "" {
+			return fmt.Errorf("--analysis-id-from-commit requires a git repository with commits, but %s is not", o.ProjectDir)
+		}
+		revision = head
+	}
+	remoteUrl := os.Getenv(QodanaRemoteUrl)
+	if remoteUrl == "" {
+		remoteUrl, _ = GitRemoteUrl(o.ProjectDir, "")
+	}
+	o.AnalysisId = analysisIdFromCommit(remoteUrl, revision)
+	log.Debugf("Analysis id %s is derived from %s@%s", o.AnalysisId, remoteUrl, revision)
+	return nil
+}
+
+func analysisIdFromCommit(remoteUrl string, revision string) string {
+	return uuid.NewMD5(uuid.NameSpaceURL, []byte(remoteUrl+"@"+revision)).String()
+}
@@ -37,12 +37,25 @@ func GitRemoteUrlLegacy(cwd string) string {
 
 // GitLog returns the git log of the given repository in the given format.
 func GitLog(cwd string, format string, since int) []string {
+	sinceDate := ""
+	if since > 0 {
+		sinceDate = fmt.Sprintf("%d.days", since)
+	}
+	return GitLogPeriod(cwd, format, sinceDate, "")
+}
+
+// GitLogPeriod returns the git log of the given repository in the given format for the commits between the since
+// and until dates in any format git understands, e.g. 2024-01-31, empty dates don't limit the period.
+func GitLogPeriod(cwd string, format string, since string, until string) []string {
 	args := []string{"--no-pager", "log", "--all", "--no-use-mailmap"}
 	if format != "" {
 		args = append(args, "--pretty=format:"+format)
 	}
-	if since > 0 {
-		args = append(args, fmt.Sprintf("--since=%d.days", since))
+	if since != "" {
+		args = append(args, "--since="+since)
+	}
+	if until != "" {
+		args = append(args, "--until="+until)
 	}
 	return gitOutput(cwd, args)
 }
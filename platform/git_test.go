@@ -17,10 +17,12 @@
 package platform
 
 import (
+	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -120,3 +122,142 @@ func gitClone(repoURL, directory string, revision string, branch string) error {
 	}
 	return nil
 }
+
+func TestResolveDiffBaseBranch(t *testing.T) {
+	projectDir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=qodana", "-c", "user.email=qodana@example.com", "-c", "commit.gpgsign=false"}, args...)...)
+		cmd.Dir = projectDir
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-b", "main")
+	git("commit", "--allow-empty", "-m", "base")
+	base := git("rev-parse", "HEAD")
+	git("checkout", "-b", "feature")
+	git("commit", "--allow-empty", "-m", "feature")
+	head := git("rev-parse", "HEAD")
+	git("checkout", "main")
+	git("commit", "--allow-empty", "-m", "main")
+	git("checkout", "feature")
+
+	options := &QodanaOptions{ProjectDir: projectDir, ResultsDir: t.TempDir(), DiffBaseBranch: "main"}
+	if err := options.ResolveDiffBaseBranch(); err != nil {
+		t.Fatal(err)
+	}
+	if options.DiffStart != base || options.DiffEnd != head {
+		t.Errorf("expected the range %s..%s, got %s..%s", base, head, options.DiffStart, options.DiffEnd)
+	}
+
+	options = &QodanaOptions{ProjectDir: projectDir, ResultsDir: t.TempDir(), DiffBaseBranch: "main", Commit: head}
+	if err := options.ResolveDiffBaseBranch(); err == nil {
+		t.Error("expected an error for conflicting --commit")
+	}
+	options = &QodanaOptions{ProjectDir: projectDir, ResultsDir: t.TempDir(), DiffBaseBranch: "missing"}
+	if err := options.ResolveDiffBaseBranch(); err == nil {
+		t.Error("expected an error for a missing branch")
+	}
+	options = &QodanaOptions{ProjectDir: t.TempDir(), ResultsDir: t.TempDir(), DiffBaseBranch: "main"}
+	if err := options.ResolveDiffBaseBranch(); err == nil {
+		t.Error("expected an error outside of a git repository")
+	}
+}
+
+func TestInitGitSubmodules(t *testing.T) {
+	t.Setenv("GIT_CONFIG_COUNT", "1")
+	t.Setenv("GIT_CONFIG_KEY_0", "protocol.file.allow")
+	t.Setenv("GIT_CONFIG_VALUE_0", "always")
+	git := func(dir string, args ...string) {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=qodana", "-c", "user.email=qodana@example.com", "-c", "commit.gpgsign=false"}, args...)...)
+		cmd.Dir = dir
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %v: %s", args, err, out)
+		}
+	}
+	root := t.TempDir()
+	library := filepath.Join(root, "library")
+	project := filepath.Join(root, "project")
+	for _, dir := range []string{library, project} {
+		if err := os.Mkdir(dir, 0o755); err != nil {
+			t.Fatal(err)
+		}
+		git(dir, "init", "-b", "main")
+	}
+	if err := os.WriteFile(filepath.Join(library, "lib.py"), []byte("print(1)\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	git(library, "add", "lib.py")
+	git(library, "commit", "-m", "library")
+	git(project, "submodule", "add", library, "library")
+	git(project, "commit", "-m", "project")
+	clone := filepath.Join(root, "clone")
+	git(root, "clone", project, clone)
+
+	options := &QodanaOptions{ProjectDir: clone, ResultsDir: t.TempDir()}
+	if err := options.InitGitSubmodules(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(clone, "library", "lib.py")); err == nil {
+		t.Fatal("expected the submodule to stay uninitialized without --init-submodules")
+	}
+	options.InitSubmodules = true
+	if err := options.InitGitSubmodules(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(clone, "library", "lib.py")); err != nil {
+		t.Errorf("expected the submodule sources to be checked out: %v", err)
+	}
+
+	options = &QodanaOptions{ProjectDir: t.TempDir(), ResultsDir: t.TempDir(), InitSubmodules: true}
+	if err := options.InitGitSubmodules(); err != nil {
+		t.Errorf("expected no error outside of a git repository, got %v", err)
+	}
+}
+
+func TestDeriveAnalysisId(t *testing.T) {
+	t.Setenv(QodanaRemoteUrl, "")
+	projectDir := t.TempDir()
+	git := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=qodana", "-c", "user.email=qodana@example.com", "-c", "commit.gpgsign=false"}, args...)...)
+		cmd.Dir = projectDir
+		out, err := cmd.Output()
+		if err != nil {
+			t.Fatalf("git %v: %v", args, err)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "-b", "main")
+	git("remote", "add", "origin", REPO)
+	git("commit", "--allow-empty", "-m", "first")
+	first := git("rev-parse", "HEAD")
+	derive := func(commit string) string {
+		options := &QodanaOptions{ProjectDir: projectDir, AnalysisId: "random", AnalysisIdFromCommit: true, Commit: commit}
+		if err := options.DeriveAnalysisId(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := uuid.Parse(options.AnalysisId); err != nil {
+			t.Fatalf("%s is not a GUID: %v", options.AnalysisId, err)
+		}
+		return options.AnalysisId
+	}
+	id := derive("")
+	if id != derive("") || id != derive("CI"+first) {
+		t.Errorf("expected the same id for the same commit %s", first)
+	}
+	git("commit", "--allow-empty", "-m", "second")
+	if derive("") == id {
+		t.Error("expected another id for another commit")
+	}
+	t.Setenv(QodanaRemoteUrl, "https://example.com/fork.git")
+	if derive("CI"+first) == id {
+		t.Error("expected another id for another remote")
+	}
+
+	options := &QodanaOptions{ProjectDir: t.TempDir(), AnalysisIdFromCommit: true}
+	if err := options.DeriveAnalysisId(); err == nil {
+		t.Error("expected an error outside of a git repository")
+	}
+}
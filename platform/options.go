@@ -18,57 +18,124 @@ package platform
 
 import (
 	"bytes"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	log "github.com/sirupsen/logrus"
+	"io"
 	"math"
 	"os"
 	"path"
 	"path/filepath"
 	"reflect"
+	"strconv"
 	"strings"
 	"text/tabwriter"
 	"time"
 	"unicode"
+
+	"gopkg.in/yaml.v3"
 )
 
 // QodanaOptions is a struct that contains all the options to run a Qodana linter.
 type QodanaOptions struct {
 	ResultsDir                string
 	CacheDir                  string
+	CacheDirShared            bool
+	CacheLockTimeoutMs        int
+	OutputRoot                string
+	JavaHome                  string
 	ProjectDir                string
 	ReportDir                 string
+	ReportZip                 string
+	ReportZipOnly             bool
+	S3Upload                  string
+	DryRun                    bool
 	CoverageDir               string
 	Linter                    string
 	Ide                       string
 	SourceDirectory           string
+	ScopeGlobs                []string
 	DisableSanity             bool
 	ProfileName               string
+	ListProfiles              bool
 	ProfilePath               string
 	RunPromo                  string
 	StubProfile               string // note: deprecated option
 	Baseline                  string
+	BaselineAuto              bool
 	BaselineIncludeAbsent     bool
+	AbsentMinSeverity         string
+	BaselineMatch             string
+	BaselineNetGate           bool
+	BaselineIgnoreRules       []string
+	IgnoreRules               []string
+	BaselinePruneWhenClean    bool
+	BaselineCreateIfMissing   bool
+	AnnotateBaselineState     bool
+	OnlyNew                   bool
+	Watch                     bool
 	SaveReport                bool
+	NoWebAssets               bool
 	ShowReport                bool
 	Port                      int
+	PortAuto                  bool
+	ReportHost                string
+	ReportUser                string
+	ReportPassword            string
 	Property                  []string
+	LinterArgs                []string
 	Script                    string
 	FailThreshold             string
+	FailOnSeverity            []string
+	SeverityExitCodes         []string
+	FailOnLicenseViolation    bool
+	ExcludeGenerated          bool
 	Commit                    string
 	DiffStart                 string
 	DiffEnd                   string
+	DiffBaseBranch            string
 	ForceLocalChangesScript   bool
 	AnalysisId                string
+	AnalysisIdFromCommit      bool
+	AnalysisName              string
+	SbomOutput                string
+	SbomFormat                string
 	Env                       []string
 	Volumes                   []string
 	User                      string
 	PrintProblems             bool
+	PrintFormat               string
+	CollapseRepeated          bool
+	ProblemsGroupBy           string
+	ProblemsLimit             int
 	GenerateCodeClimateReport bool
 	SendBitBucketInsights     bool
+	AzureAnnotations          bool
+	UriBase                   string
 	SkipPull                  bool
+	RequirePinnedImage        bool
+	NoProxyPassthrough        bool
+	ContainerPrivileged       bool
+	ContainerGroupAdd         []string
+	ContainerLabels           []string
+	ContainerNamePrefix       string
+	MountGit                  string
+	SkipPreflight             bool
+	ProjectReadOnly           bool
+	ProjectReadOnlyNoFixes    bool // the deprecated --project-readonly, ignored when the fixes are applied
+	StreamResultsDir          string
+	Exec                      string
 	ClearCache                bool
+	CleanResults              bool
 	ConfigName                string
+	ConfigOverride            string
+	Includes                  []string
+	Excludes                  []string
+	MaxRuntimeNotifications   int
+	FailOnErrorNotification   string
 	FullHistory               bool
+	ResultsDirPerCommit       bool
 	ApplyFixes                bool
 	Cleanup                   bool
 	FixesStrategy             string // note: deprecated option
@@ -82,11 +149,23 @@ type QodanaOptions struct {
 	CdnetConfiguration        string
 	CdnetPlatform             string
 	CdnetNoBuild              bool
+	CdnetTargetFrameworks     []string
 	ClangCompileCommands      string // clang specific options
 	ClangArgs                 string
+	ClangCompileDbCommand     string
 	AnalysisTimeoutMs         int
 	AnalysisTimeoutExitCode   int
+	ExitCodeMap               []string
+	StartupTimeoutMs          int
+	ContainerLogLevel         string
 	JvmDebugPort              int
+	ResultUmask               string
+	PluginsFromFiles          []string
+	CaCerts                   []string
+	SarifSplitSize            int64
+	SarifRebaseUris           []string
+	DedupHighestSeverity      bool
+	InitSubmodules            bool
 	QdConfig                  QodanaYaml
 }
 
@@ -125,6 +204,11 @@ func (o *QodanaOptions) LogOptions() {
 	log.Debug(buffer.String())
 }
 
+// TargetFrameworks returns the target frameworks given with --framework as a semicolon-separated list.
+func (o *QodanaOptions) TargetFrameworks() string {
+	return strings.Join(o.CdnetTargetFrameworks, ";")
+}
+
 // GetToken default options function to obtain token (no user interaction)
 func (o *QodanaOptions) GetToken() string {
 	return o.LoadToken(false, o.RequiresToken(false), false)
@@ -134,8 +218,29 @@ func (o *QodanaOptions) FetchAnalyzerSettings() {
 	qodanaYamlPath := FindQodanaYaml(o.ProjectDir)
 	if o.ConfigName != "" {
 		qodanaYamlPath = o.ConfigName
+	} else if o.usesConfigNameFromEnv(qodanaYamlPath) {
+		// the IDE looks up only the default names, so the file found through QODANA_CONFIG_NAME is passed as --config
+		o.ConfigName = qodanaYamlPath
+	}
+	qdConfig, mergedConfig, err := o.LoadQodanaYaml(qodanaYamlPath)
+	if err != nil {
+		ErrorMessage("Failed to load %s: %s", qodanaYamlPath, err)
+		os.Exit(1)
+	}
+	o.QdConfig = *qdConfig
+	profilePathResolved := o.QdConfig.ResolveProfilePath(o.ProjectDir, qodanaYamlPath)
+	if err := o.QdConfig.LoadPropertiesFile(o.ProjectDir); err != nil {
+		ErrorMessage("Invalid %s: %s", qodanaYamlPath, err)
+		os.Exit(1)
+	}
+	if err := o.QdConfig.ExpandEnv(); err != nil {
+		ErrorMessage("Invalid %s: %s", qodanaYamlPath, err)
+		os.Exit(1)
+	}
+	if err := o.QdConfig.ValidateSeverityOverrides(); err != nil {
+		ErrorMessage("Invalid %s: %s", qodanaYamlPath, err)
+		os.Exit(1)
 	}
-	o.QdConfig = *LoadQodanaYaml(o.ProjectDir, qodanaYamlPath)
 	if o.Linter == "" && o.Ide == "" {
 		if o.QdConfig.Linter == "" && o.QdConfig.Ide == "" {
 			WarningMessage(
@@ -164,9 +269,191 @@ func (o *QodanaOptions) FetchAnalyzerSettings() {
 			o.Ide = o.QdConfig.Ide
 		}
 	}
+	if err := o.ExpandOutputDirs(); err != nil {
+		ErrorMessage(err.Error())
+		os.Exit(1)
+	}
 	o.ResultsDir = o.resultsDirPath()
 	o.ReportDir = o.reportDirPath()
 	o.CacheDir = o.GetCacheDir()
+	if IsContainer() || o.IsNative() {
+		if err := o.ApplyInlineProfile(&o.QdConfig); err != nil {
+			ErrorMessage(err.Error())
+			os.Exit(1)
+		}
+		if profilePathResolved && o.ProfilePath == "" && o.ProfileName == "" {
+			// the IDE resolves profile.path against the project directory
+			o.ProfilePath = o.QdConfig.Profile.Path
+		}
+		if mergedConfig != nil {
+			if err := o.useMergedConfig(mergedConfig); err != nil {
+				ErrorMessage(err.Error())
+				os.Exit(1)
+			}
+		}
+	}
+}
+
+// LoadQodanaYaml loads qodana.yaml from qodanaYamlPath (relative to the project directory) merged over the configuration
+// it extends and with --config-override, then the configuration from the command line is applied: the checks from
+// --include and --exclude are appended, --max-runtime-notifications and --fail-on-error-notification override the values.
+// The merged YAML is returned too, it's nil if there is nothing to merge.
+func (o *QodanaOptions) LoadQodanaYaml(qodanaYamlPath string) (*QodanaYaml, []byte, error) {
+	if o.ConfigOverride == "" && !o.hasCliConfig() {
+		if q := loadQodanaYaml(o.ProjectDir, qodanaYamlPath, false); q.Extends == "" {
+			return q, nil, nil
+		}
+	}
+	basePath := qodanaYamlPath
+	if !filepath.IsAbs(basePath) {
+		basePath = filepath.Join(o.ProjectDir, qodanaYamlPath)
+	}
+	base, err := readYamlMap(basePath, true)
+	if err != nil {
+		return nil, nil, err
+	}
+	if o.ConfigOverride != "" {
+		overlay, err := readYamlMap(o.ConfigOverride, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		base = mergeYamlMaps(base, overlay)
+	}
+	overlay, err := o.cliConfigOverlay()
+	if err != nil {
+		return nil, nil, err
+	}
+	overlayMap, err := overlay.yamlMap()
+	if err != nil {
+		return nil, nil, err
+	}
+	merged, err := yaml.Marshal(mergeYamlMaps(base, overlayMap))
+	if err != nil {
+		return nil, nil, err
+	}
+	q := &QodanaYaml{}
+	if err = yaml.Unmarshal(merged, q); err != nil {
+		return nil, nil, err
+	}
+	return q, merged, nil
+}
+
+// hasCliConfig reports whether any qodana.yaml setting is passed from the command line, see cliConfigOverlay.
+func (o *QodanaOptions) hasCliConfig() bool {
+	return len(o.Includes) > 0 || len(o.Excludes) > 0 || o.MaxRuntimeNotifications > 0 || o.FailOnErrorNotification != ""
+}
+
+// cliConfigOverlay returns the qodana.yaml settings passed from the command line.
+func (o *QodanaOptions) cliConfigOverlay() (cliConfigOverlay, error) {
+	var overlay cliConfigOverlay
+	var err error
+	if overlay.Includes, err = ParseCludes(o.Includes); err != nil {
+		return overlay, err
+	}
+	if overlay.Excludes, err = ParseCludes(o.Excludes); err != nil {
+		return overlay, err
+	}
+	if o.MaxRuntimeNotifications > 0 {
+		overlay.MaxRuntimeNotifications = &o.MaxRuntimeNotifications
+	}
+	if o.FailOnErrorNotification != "" {
+		if err = o.ValidateFailOnErrorNotification(); err != nil {
+			return overlay, err
+		}
+		failOnError, _ := strconv.ParseBool(o.FailOnErrorNotification)
+		overlay.FailOnErrorNotification = &failOnError
+	}
+	return overlay, nil
+}
+
+// ValidateFailOnErrorNotification checks the value of --fail-on-error-notification, empty value is allowed.
+func (o *QodanaOptions) ValidateFailOnErrorNotification() error {
+	if o.FailOnErrorNotification == "" {
+		return nil
+	}
+	if _, err := strconv.ParseBool(o.FailOnErrorNotification); err != nil {
+		return fmt.Errorf("invalid --fail-on-error-notification value %q, expected true or false", o.FailOnErrorNotification)
+	}
+	return nil
+}
+
+// usesConfigNameFromEnv reports whether qodanaYamlPath found by FindQodanaYaml is an existing file other than the default ones.
+func (o *QodanaOptions) usesConfigNameFromEnv(qodanaYamlPath string) bool {
+	if qodanaYamlPath == configName+".yaml" || qodanaYamlPath == configName+".yml" {
+		return false
+	}
+	_, err := os.Stat(filepath.Join(o.ProjectDir, qodanaYamlPath))
+	return err == nil
+}
+
+const (
+	mergedConfigName  = "qodana-merged.yaml"
+	inlineProfileName = "qodana-inline-profile.xml"
+)
+
+// useMergedConfig writes the configuration merged with --config-override to the config directory and uses it as --config,
+// so the IDE gets the same configuration as the CLI.
+func (o *QodanaOptions) useMergedConfig(merged []byte) error {
+	path, err := o.writeConfFile(mergedConfigName, merged)
+	if err != nil {
+		return fmt.Errorf("failed to write the merged configuration: %w", err)
+	}
+	o.ConfigName = path
+	return nil
+}
+
+// writeConfFile writes content to the file with the given name in the config directory, replacing the one
+// written by the previous run, and returns its path.
+func (o *QodanaOptions) writeConfFile(name string, content []byte) (string, error) {
+	confDir := o.ConfDirPath()
+	if err := os.MkdirAll(confDir, os.ModePerm); err != nil {
+		return "", err
+	}
+	path := filepath.Join(confDir, name)
+	if err := os.WriteFile(path, content, 0o644); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// ApplyInlineProfile writes the inline profile from qodana.yaml to the config directory and uses it as the profile path.
+// Profiles passed via --profile-path or --profile-name take precedence over the inline profile,
+// which in turn takes precedence over the profile section of qodana.yaml.
+func (o *QodanaOptions) ApplyInlineProfile(q *QodanaYaml) error {
+	if q.InlineProfile == "" || o.ProfilePath != "" || o.ProfileName != "" {
+		return nil
+	}
+	if err := validateXml(q.InlineProfile); err != nil {
+		return fmt.Errorf("inlineProfile in qodana.yaml is not a valid XML: %w", err)
+	}
+	path, err := o.writeConfFile(inlineProfileName, []byte(q.InlineProfile))
+	if err != nil {
+		return fmt.Errorf("failed to write the inline profile: %w", err)
+	}
+	o.ProfilePath = path
+	return nil
+}
+
+// validateXml checks that the given content is a well-formed XML document with a root element.
+func validateXml(content string) error {
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	hasRoot := false
+	for {
+		token, err := decoder.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if _, ok := token.(xml.StartElement); ok {
+			hasRoot = true
+		}
+	}
+	if !hasRoot {
+		return errors.New("no root element found")
+	}
+	return nil
 }
 
 // Setenv sets the Qodana container environment variables if such variable was not set before.
@@ -214,11 +501,39 @@ func (o *QodanaOptions) StartHash() (string, error) {
 	}
 }
 
+// ResolveDiffBaseBranch sets the diff run range for --diff-base-branch:
+// from the merge base of HEAD and the branch to HEAD.
+func (o *QodanaOptions) ResolveDiffBaseBranch() error {
+	if o.DiffBaseBranch == "" {
+		return nil
+	}
+	logdir := o.LogDirPath()
+	if _, err := GitRoot(o.ProjectDir, logdir); err != nil {
+		return fmt.Errorf("--diff-base-branch requires a git repository, but %s is not inside one", o.ProjectDir)
+	}
+	if !GitRevisionExists(o.ProjectDir, o.DiffBaseBranch, logdir) {
+		return fmt.Errorf("branch %s passed with --diff-base-branch doesn't exist in %s", o.DiffBaseBranch, o.ProjectDir)
+	}
+	mergeBase, err := GitMergeBase(o.ProjectDir, o.DiffBaseBranch, logdir)
+	if err != nil {
+		return fmt.Errorf("failed to find the merge base of HEAD and %s: %w", o.DiffBaseBranch, err)
+	}
+	head, err := GitCurrentRevision(o.ProjectDir, logdir)
+	if err != nil {
+		return err
+	}
+	o.DiffStart = mergeBase
+	o.DiffEnd = head
+	_, err = o.StartHash()
+	return err
+}
+
 // ResetScanScenarioOptions drops the options that lead to local change analysis
 func (o *QodanaOptions) ResetScanScenarioOptions() {
 	o.Commit = ""
 	o.DiffStart = ""
 	o.DiffEnd = ""
+	o.DiffBaseBranch = ""
 	o.FullHistory = false
 	o.ForceLocalChangesScript = false
 	o.Script = ""
@@ -271,9 +586,19 @@ func (o *QodanaOptions) GetLinterDir() string {
 	)
 }
 
+// outputRootDir returns the given subdirectory of --output-root, empty if it's not set.
+func (o *QodanaOptions) outputRootDir(name string) string {
+	if o.OutputRoot == "" {
+		return ""
+	}
+	return filepath.Join(o.OutputRoot, name)
+}
+
 func (o *QodanaOptions) resultsDirPath() string {
 	if o.ResultsDir == "" {
-		if IsContainer() {
+		if o.OutputRoot != "" {
+			o.ResultsDir = o.outputRootDir("results")
+		} else if IsContainer() {
 			o.ResultsDir = "/data/results"
 		} else {
 			o.ResultsDir = filepath.Join(o.GetLinterDir(), "results")
@@ -284,7 +609,9 @@ func (o *QodanaOptions) resultsDirPath() string {
 
 func (o *QodanaOptions) GetCacheDir() string {
 	if o.CacheDir == "" {
-		if IsContainer() {
+		if o.OutputRoot != "" {
+			o.CacheDir = o.outputRootDir("cache")
+		} else if IsContainer() {
 			o.CacheDir = "/data/cache"
 		} else {
 			o.CacheDir = filepath.Join(o.GetLinterDir(), "cache")
@@ -295,7 +622,9 @@ func (o *QodanaOptions) GetCacheDir() string {
 
 func (o *QodanaOptions) reportDirPath() string {
 	if o.ReportDir == "" {
-		if IsContainer() {
+		if o.OutputRoot != "" {
+			o.ReportDir = o.outputRootDir("report")
+		} else if IsContainer() {
 			o.ReportDir = "/data/results/report"
 		} else {
 			o.ReportDir = filepath.Join(o.resultsDirPath(), "report")
@@ -304,13 +633,19 @@ func (o *QodanaOptions) reportDirPath() string {
 	return o.ReportDir
 }
 
+// CoverageDirPath returns the coverage directory: --coverage-dir if it's set, otherwise the default directory
+// if it has any data, otherwise the directory of a common coverage report found in the project (see resolveCoverageDir).
 func (o *QodanaOptions) CoverageDirPath() string {
 	if o.CoverageDir == "" {
-		if IsContainer() {
-			o.CoverageDir = "/data/coverage"
+		defaultDir := ""
+		if o.OutputRoot != "" {
+			defaultDir = o.outputRootDir("coverage")
+		} else if IsContainer() {
+			defaultDir = "/data/coverage"
 		} else {
-			o.CoverageDir = filepath.Join(o.ProjectDir, ".qodana", "code-coverage")
+			defaultDir = filepath.Join(o.ProjectDir, ".qodana", "code-coverage")
 		}
+		o.CoverageDir = resolveCoverageDir(defaultDir, o.ProjectDir)
 	}
 	return o.CoverageDir
 }
@@ -331,6 +666,8 @@ func (o *QodanaOptions) ConfDirPath() string {
 	return confDir
 }
 
+// Properties splits --property values into key=value properties and flags. The value is everything after the first '=',
+// a value without '=' is a flag: JVM options (-Xmx4g) are kept as is, the rest become boolean system properties (foo is -Dfoo).
 func (o *QodanaOptions) Properties() (map[string]string, []string) {
 	var flagsArr []string
 	props := map[string]string{}
@@ -338,6 +675,8 @@ func (o *QodanaOptions) Properties() (map[string]string, []string) {
 		kv := strings.SplitN(arg, "=", 2)
 		if len(kv) == 2 {
 			props[kv[0]] = kv[1]
+		} else if arg != "" && !strings.HasPrefix(arg, "-") {
+			flagsArr = append(flagsArr, "-D"+arg)
 		} else {
 			flagsArr = append(flagsArr, arg)
 		}
@@ -380,10 +719,68 @@ func (o *QodanaOptions) GetAnalysisTimeout() time.Duration {
 	return time.Duration(o.AnalysisTimeoutMs) * time.Millisecond
 }
 
+// ProjectMountReadOnly reports whether the project directory is mounted read-only to the container: with --readonly-project
+// always, with the deprecated --project-readonly unless the fixes are applied.
+func (o *QodanaOptions) ProjectMountReadOnly() bool {
+	return o.ProjectReadOnly || o.ProjectReadOnlyNoFixes && !o.ApplyFixes && !o.Cleanup
+}
+
+// ValidateProjectReadOnly checks that --readonly-project is not combined with the fixes that modify the project,
+// the fixes strategy from qodana.yaml is taken into account, so it has to be called after FetchAnalyzerSettings.
+func (o *QodanaOptions) ValidateProjectReadOnly() error {
+	if !o.ProjectReadOnly {
+		return nil
+	}
+	if o.ApplyFixes || o.Cleanup {
+		return fmt.Errorf("--readonly-project can't be used with --apply-fixes or --cleanup")
+	}
+	strategy := o.FixesStrategy
+	if strategy == "" {
+		strategy = o.QdConfig.FixesStrategy
+	}
+	if strategy = Lower(strategy); strategy == "apply" || strategy == "cleanup" {
+		return fmt.Errorf("--readonly-project can't be used with the %s fixes strategy", strategy)
+	}
+	return nil
+}
+
+// GetStartupTimeout returns the time limit for the container to reach the analysis stage, 0 if there is no limit.
+func (o *QodanaOptions) GetStartupTimeout() time.Duration {
+	if o.StartupTimeoutMs <= 0 {
+		return 0
+	}
+	return time.Duration(o.StartupTimeoutMs) * time.Millisecond
+}
+
+// GetCacheLockTimeout returns how long to wait for the lock of the shared cache directory, negative if without limit.
+func (o *QodanaOptions) GetCacheLockTimeout() time.Duration {
+	if o.CacheLockTimeoutMs < 0 {
+		return -1
+	}
+	return time.Duration(o.CacheLockTimeoutMs) * time.Millisecond
+}
+
+// LockCacheDir locks the cache directory for the run if it's shared with the concurrent runs (--cache-dir-shared),
+// returns nil if it's not shared.
+func (o *QodanaOptions) LockCacheDir() (*CacheLock, error) {
+	if !o.CacheDirShared {
+		return nil, nil
+	}
+	return LockCacheDir(o.CacheDir, o.GetCacheLockTimeout())
+}
+
 func (o *QodanaOptions) IsCommunity() bool {
 	return o.LicensePlan == "COMMUNITY"
 }
 
+// BaselinePath returns the path to the baseline report, relative paths are resolved against the project directory.
+func (o *QodanaOptions) BaselinePath() string {
+	if filepath.IsAbs(o.Baseline) {
+		return o.Baseline
+	}
+	return filepath.Join(o.ProjectDir, o.Baseline)
+}
+
 func (o *QodanaOptions) GetTmpResultsDir() string {
 	return path.Join(o.ResultsDir, "tmp")
 }
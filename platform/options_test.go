@@ -17,8 +17,10 @@
 package platform
 
 import (
+	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -108,4 +110,191 @@ func TestFetchAnalyzerSettings(t *testing.T) {
 			t.Fatal(err)
 		}
 	})
+
+	t.Run("QODANA_CONFIG_NAME file is used as configName", func(t *testing.T) {
+		projectDir := t.TempDir()
+		t.Setenv(QodanaConfigNameEnv, "qodana.ci.yaml,qodana.local.yaml")
+		if _, err := setupTest(projectDir, "qodana.local.yaml", "ide: expectedIde_env"); err != nil {
+			t.Fatalf("Failed to setup test: %v", err)
+		}
+
+		o := &QodanaOptions{ProjectDir: projectDir}
+		o.FetchAnalyzerSettings()
+
+		assert.Equal(t, "expectedIde_env", o.Ide)
+		assert.Equal(t, "qodana.local.yaml", o.ConfigName)
+	})
+
+	t.Run("default file name is not used as configName", func(t *testing.T) {
+		projectDir := t.TempDir()
+		t.Setenv(QodanaConfigNameEnv, "qodana.ci.yaml")
+		if _, err := setupTest(projectDir, "qodana.yaml", "ide: expectedIde"); err != nil {
+			t.Fatalf("Failed to setup test: %v", err)
+		}
+
+		o := &QodanaOptions{ProjectDir: projectDir}
+		o.FetchAnalyzerSettings()
+
+		assert.Equal(t, "expectedIde", o.Ide)
+		assert.Empty(t, o.ConfigName)
+	})
+}
+
+func TestApplyInlineProfile(t *testing.T) {
+	inlineProfile := `<component name="InspectionProjectProfileManager"><profile version="1.0"><option name="myName" value="inline" /></profile></component>`
+
+	t.Run("inline profile is written to the config directory", func(t *testing.T) {
+		confDir := t.TempDir()
+		t.Setenv(QodanaConfEnv, confDir)
+		o := &QodanaOptions{}
+		err := o.ApplyInlineProfile(&QodanaYaml{InlineProfile: inlineProfile})
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(confDir, inlineProfileName), o.ProfilePath)
+		content, err := os.ReadFile(o.ProfilePath)
+		assert.NoError(t, err)
+		assert.Equal(t, inlineProfile, string(content))
+	})
+
+	t.Run("profile path has precedence", func(t *testing.T) {
+		o := &QodanaOptions{ProfilePath: "custom.xml"}
+		err := o.ApplyInlineProfile(&QodanaYaml{InlineProfile: inlineProfile})
+		assert.NoError(t, err)
+		assert.Equal(t, "custom.xml", o.ProfilePath)
+	})
+
+	t.Run("profile name has precedence", func(t *testing.T) {
+		o := &QodanaOptions{ProfileName: "qodana.recommended"}
+		err := o.ApplyInlineProfile(&QodanaYaml{InlineProfile: inlineProfile})
+		assert.NoError(t, err)
+		assert.Equal(t, "", o.ProfilePath)
+	})
+
+	t.Run("malformed XML is rejected", func(t *testing.T) {
+		o := &QodanaOptions{}
+		err := o.ApplyInlineProfile(&QodanaYaml{InlineProfile: "<component><profile></component>"})
+		assert.Error(t, err)
+		assert.Equal(t, "", o.ProfilePath)
+	})
+}
+
+func TestOutputRoot(t *testing.T) {
+	t.Setenv(QodanaDockerEnv, "")
+	root := filepath.Join(t.TempDir(), "qodana")
+
+	options := &QodanaOptions{OutputRoot: root, ProjectDir: t.TempDir()}
+	assert.Equal(t, filepath.Join(root, "results"), options.resultsDirPath())
+	assert.Equal(t, filepath.Join(root, "report"), options.reportDirPath())
+	assert.Equal(t, filepath.Join(root, "coverage"), options.CoverageDirPath())
+	assert.Equal(t, filepath.Join(root, "cache"), options.GetCacheDir())
+
+	overridden := &QodanaOptions{
+		OutputRoot:  root,
+		ResultsDir:  "/tmp/results",
+		ReportDir:   "/tmp/report",
+		CoverageDir: "/tmp/coverage",
+		CacheDir:    "/tmp/cache",
+	}
+	assert.Equal(t, "/tmp/results", overridden.resultsDirPath())
+	assert.Equal(t, "/tmp/report", overridden.reportDirPath())
+	assert.Equal(t, "/tmp/coverage", overridden.CoverageDirPath())
+	assert.Equal(t, "/tmp/cache", overridden.GetCacheDir())
+}
+
+func TestValidateProjectReadOnly(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		options *QodanaOptions
+		valid   bool
+	}{
+		{"analysis", &QodanaOptions{ProjectReadOnly: true}, true},
+		{"writable project with fixes", &QodanaOptions{ApplyFixes: true}, true},
+		{"apply fixes", &QodanaOptions{ProjectReadOnly: true, ApplyFixes: true}, false},
+		{"cleanup", &QodanaOptions{ProjectReadOnly: true, Cleanup: true}, false},
+		{"fixes strategy", &QodanaOptions{ProjectReadOnly: true, FixesStrategy: "apply"}, false},
+		{"fixes strategy in qodana.yaml", &QodanaOptions{ProjectReadOnly: true, QdConfig: QodanaYaml{FixesStrategy: "cleanup"}}, false},
+		{"CLI fixes strategy overrides qodana.yaml", &QodanaOptions{ProjectReadOnly: true, FixesStrategy: "none", QdConfig: QodanaYaml{FixesStrategy: "apply"}}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.options.ValidateProjectReadOnly()
+			assert.Equal(t, tc.valid, err == nil, "unexpected result: %v", err)
+		})
+	}
+}
+
+func TestMapExitCode(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		mapping  []string
+		code     int
+		expected int
+	}{
+		{"no mapping", []string{}, QodanaFailThresholdExitCode, QodanaFailThresholdExitCode},
+		{"mapped", []string{"255=10", "70=11"}, QodanaFailThresholdExitCode, 10},
+		{"unmapped", []string{"255=10"}, QodanaInternalErrorExitCode, QodanaInternalErrorExitCode},
+		{"identity", []string{"255=255"}, QodanaFailThresholdExitCode, QodanaFailThresholdExitCode},
+		{"several codes to one", []string{"255=10", "70=10"}, QodanaInternalErrorExitCode, 10},
+		{"mapping is not chained", []string{"255=70", "70=11"}, QodanaFailThresholdExitCode, QodanaInternalErrorExitCode},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			options := &QodanaOptions{ExitCodeMap: tc.mapping}
+			assert.Equal(t, tc.expected, options.MapExitCode(tc.code))
+		})
+	}
+}
+
+func TestPropertiesAndFlags(t *testing.T) {
+	opts := &QodanaOptions{Property: []string{
+		"idea.log.level=DEBUG",
+		"qodana.filter=a=b,c=d",
+		"idea.is.internal",
+		"-Xmx4g",
+		"-Dfoo",
+		"empty.value=",
+	}}
+	props, flags := opts.Properties()
+	assert.Equal(t, map[string]string{
+		"idea.log.level": "DEBUG",
+		"qodana.filter":  "a=b,c=d",
+		"empty.value":    "",
+	}, props)
+	assert.Equal(t, []string{"-Didea.is.internal", "-Xmx4g", "-Dfoo"}, flags)
+}
+
+func TestParseContainerLabels(t *testing.T) {
+	labels, err := ParseContainerLabels([]string{"ci.job=42", "empty=", "url=https://ci/?a=b"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"ci.job": "42", "empty": "", "url": "https://ci/?a=b"}, labels)
+	for _, value := range []string{"ci.job", "=42"} {
+		_, err := ParseContainerLabels([]string{value})
+		assert.Error(t, err, value)
+	}
+}
+
+func TestParseExitCodeMapErrors(t *testing.T) {
+	for _, mapping := range [][]string{
+		{"255=10", "255=11"},
+		{"255"},
+		{"255=x"},
+		{"256=1"},
+		{"1=-1"},
+	} {
+		_, err := ParseExitCodeMap(mapping)
+		assert.Error(t, err, mapping)
+	}
+	codeMap, err := ParseExitCodeMap([]string{"255=10", "255=10"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int]int{255: 10}, codeMap)
+}
+
+func TestUserFlagDefault(t *testing.T) {
+	if IsContainer() {
+		t.Skip("--user is available only outside a container")
+	}
+	options := &QodanaOptions{}
+	cmd := &cobra.Command{}
+	assert.NoError(t, ComputeFlags(cmd, options))
+	assert.NoError(t, cmd.ParseFlags(nil))
+	assert.Equal(t, GetDefaultUser(), options.User)
+	assert.NoError(t, cmd.ParseFlags([]string{"--user", ""}))
+	assert.Equal(t, "", options.User)
 }
@@ -21,6 +21,7 @@ import (
 	"github.com/JetBrains/qodana-cli/v2024/sarif"
 	cienvironment "github.com/cucumber/ci-environment/go"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/liamg/clinch/terminal"
@@ -191,7 +192,9 @@ func PrintFile(file string) {
 func printProblem(ruleId string, level string, message string, path string, line int, column int, contextLine int, context string) {
 	printHeader(level, ruleId, "")
 	printPath(path, line, column)
-	printLines(context, contextLine, line, false)
+	if context != "" {
+		printLines(context, contextLine, line, false)
+	}
 	fmt.Print(message + "\n")
 }
 
@@ -274,6 +277,151 @@ func printSarifProblem(r *sarif.Result, ruleId, message string) {
 	}
 }
 
+// ProblemsGroupByValues are the supported values of --problems-group-by.
+var ProblemsGroupByValues = []string{"rule", "file", "severity"}
+
+// ValidateProblemsOutput checks the --problems-group-by and --problems-limit values.
+func ValidateProblemsOutput(groupBy string, limit int) error {
+	if groupBy != "" && !Contains(ProblemsGroupByValues, groupBy) {
+		return fmt.Errorf("unknown --problems-group-by value %q, use one of: %s", groupBy, strings.Join(ProblemsGroupByValues, ", "))
+	}
+	if limit < 0 {
+		return fmt.Errorf("--problems-limit must not be negative, got %d", limit)
+	}
+	return nil
+}
+
+// printSarifProblems prints the results using printFormat or the default output. With collapseRepeated,
+// consecutive results with the same rule and message are printed as one line with the number of occurrences.
+// With groupBy, the results are printed in groups under a header with the number of problems in the group,
+// limit (if positive) is the number of entries printed in each group or in total if the results are not grouped.
+// If uriBase is set, the default output includes the link to the problem location.
+func printSarifProblems(results []*sarif.Result, printFormat *ProblemFormat, collapseRepeated bool, groupBy string, limit int, uriBase string) {
+	if groupBy == "" {
+		printProblemEntries(results, printFormat, collapseRepeated, limit, uriBase)
+		return
+	}
+	for _, g := range groupProblems(results, groupBy) {
+		fmt.Println(PrimaryBold(fmt.Sprintf("%s (%d)", g.key, len(g.results))))
+		printProblemEntries(g.results, printFormat, collapseRepeated, limit, uriBase)
+	}
+}
+
+// printProblemEntries prints up to limit entries of the results and the number of the problems left out.
+func printProblemEntries(results []*sarif.Result, printFormat *ProblemFormat, collapseRepeated bool, limit int, uriBase string) {
+	var problems []repeatedProblem
+	if collapseRepeated {
+		problems = collapseRepeatedProblems(results)
+	} else {
+		for _, r := range results {
+			problems = append(problems, repeatedProblem{result: r, count: 1})
+		}
+	}
+	for i, p := range problems {
+		if limit > 0 && i == limit {
+			fmt.Println(miscStyle.Sprintf("… and %d more", countProblems(problems[i:])))
+			return
+		}
+		if p.count > 1 {
+			fmt.Println(formatRepeatedProblem(p))
+		} else if printFormat != nil {
+			fmt.Println(printFormat.Render(p.result))
+		} else {
+			printSarifProblem(p.result, p.result.RuleId, p.result.Message.Text)
+			if link := resultSourceLink(uriBase, p.result); link != "" {
+				fmt.Println(miscStyle.Sprint(link))
+			}
+		}
+	}
+}
+
+// countProblems returns the number of results in the problems.
+func countProblems(problems []repeatedProblem) int {
+	count := 0
+	for _, p := range problems {
+		count += p.count
+	}
+	return count
+}
+
+// problemGroup is the results with the same rule, file or severity.
+type problemGroup struct {
+	key     string
+	results []*sarif.Result
+}
+
+// groupProblems groups the results by the rule, file or severity, the largest groups go first.
+func groupProblems(results []*sarif.Result, groupBy string) []problemGroup {
+	var groups []problemGroup
+	indexes := make(map[string]int)
+	for _, r := range results {
+		var key string
+		switch groupBy {
+		case "rule":
+			key = r.RuleId
+		case "file":
+			key = getResultFile(r)
+			if key == "" {
+				key = "(no file)"
+			}
+		case "severity":
+			key = strings.ToUpper(getSeverity(r))
+		}
+		i, ok := indexes[key]
+		if !ok {
+			i = len(groups)
+			indexes[key] = i
+			groups = append(groups, problemGroup{key: key})
+		}
+		groups[i].results = append(groups[i].results, r)
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return len(groups[i].results) > len(groups[j].results)
+	})
+	return groups
+}
+
+// repeatedProblem is a run of consecutive results with the same rule and message.
+type repeatedProblem struct {
+	result *sarif.Result
+	count  int
+	files  []string
+}
+
+// collapseRepeatedProblems groups consecutive results with the same rule and message.
+func collapseRepeatedProblems(results []*sarif.Result) []repeatedProblem {
+	var problems []repeatedProblem
+	for _, r := range results {
+		last := len(problems) - 1
+		if last < 0 || problems[last].result.RuleId != r.RuleId || problems[last].result.Message.Text != r.Message.Text {
+			problems = append(problems, repeatedProblem{result: r})
+			last++
+		}
+		problems[last].count++
+		if file := getResultFile(r); file != "" && !Contains(problems[last].files, file) {
+			problems[last].files = append(problems[last].files, file)
+		}
+	}
+	return problems
+}
+
+// formatRepeatedProblem returns the line printed for the collapsed results.
+func formatRepeatedProblem(p repeatedProblem) string {
+	line := fmt.Sprintf("%s %s: %s (%d occurrences)", PrimaryBold(strings.ToUpper(getSeverity(p.result))), Primary(p.result.RuleId), p.result.Message.Text, p.count)
+	if len(p.files) > 0 {
+		line += " in " + strings.Join(p.files, ", ")
+	}
+	return line
+}
+
+// getResultFile returns the path of the file of the result, empty if it has no physical location.
+func getResultFile(r *sarif.Result) string {
+	if len(r.Locations) == 0 || r.Locations[0].PhysicalLocation == nil || r.Locations[0].PhysicalLocation.ArtifactLocation == nil {
+		return ""
+	}
+	return r.Locations[0].PhysicalLocation.ArtifactLocation.Uri
+}
+
 // getProblemsFoundMessage returns a message about the number of problems found, used in CLI and BitBucket report.
 func getProblemsFoundMessage(newProblems int) string {
 	if newProblems == 0 {
@@ -0,0 +1,152 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// OutputDirTokens are the tokens expanded in --results-dir and --report-dir, e.g. reports/{linter}/{date}.
+var OutputDirTokens = []string{"linter", "date", "id", "branch"}
+
+var outputDirTokenPattern = regexp.MustCompile(`\{([^{}]*)}`)
+
+// ExpandOutputDirs expands the tokens in --results-dir and --report-dir, so all the paths derived from them
+// (the SARIF files, the HTML report) use the same directories during the run.
+func (o *QodanaOptions) ExpandOutputDirs() error {
+	var err error
+	if o.ResultsDir, err = o.expandOutputDir(o.ResultsDir); err != nil {
+		return fmt.Errorf("invalid --results-dir: %w", err)
+	}
+	if o.ReportDir, err = o.expandOutputDir(o.ReportDir); err != nil {
+		return fmt.Errorf("invalid --report-dir: %w", err)
+	}
+	return nil
+}
+
+func (o *QodanaOptions) expandOutputDir(dir string) (string, error) {
+	var err error
+	expanded := outputDirTokenPattern.ReplaceAllStringFunc(dir, func(token string) string {
+		if err != nil {
+			return token
+		}
+		var value string
+		value, err = o.outputDirTokenValue(strings.Trim(token, "{}"))
+		return value
+	})
+	return expanded, err
+}
+
+// outputDirTokenValue returns the value of the token, it's always a single path element.
+func (o *QodanaOptions) outputDirTokenValue(token string) (string, error) {
+	var value string
+	switch token {
+	case "linter":
+		value = o.linterDirName()
+	case "date":
+		value = time.Now().Format("2006-01-02")
+	case "id":
+		value = o.AnalysisId
+	case "branch":
+		value = os.Getenv("QODANA_BRANCH")
+		if value == "" {
+			branch, err := getBranchName(o.ProjectDir)
+			if err != nil {
+				return "", fmt.Errorf("couldn't get the branch for {branch}: %w", err)
+			}
+			value = branch
+		}
+	default:
+		return "", fmt.Errorf("unknown token {%s}, available tokens: {%s}", token, strings.Join(OutputDirTokens, "}, {"))
+	}
+	if value == "" {
+		return "", fmt.Errorf("the value of {%s} is not known for this run", token)
+	}
+	return strings.NewReplacer("/", "-", "\\", "-").Replace(value), nil
+}
+
+// linterDirName returns the short name of the linter: the image name without the registry and the tag,
+// or the lowercase product code.
+func (o *QodanaOptions) linterDirName() string {
+	if o.Linter != "" {
+		image, _, _ := strings.Cut(o.Linter, "@")
+		name := path.Base(image)
+		name, _, _ = strings.Cut(name, ":")
+		return name
+	}
+	if o.Ide != "" {
+		return Lower(o.Ide)
+	}
+	if info := o.GetLinterInfo(); info != nil {
+		return Lower(info.ProductCode)
+	}
+	return ""
+}
+
+// CleanResultsDir removes the contents of the results directory for --clean-results. The directory itself is kept,
+// it can be a mount point. The root, the home and the project directories and their parents are refused.
+func (o *QodanaOptions) CleanResultsDir() error {
+	if !o.CleanResults {
+		return nil
+	}
+	resultsDir, err := filepath.Abs(o.ResultsDir)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path to results directory: %w", err)
+	}
+	if err := checkCleanableDir(resultsDir, o.ProjectDir); err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(resultsDir)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("failed to read results directory %s: %w", resultsDir, err)
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(resultsDir, entry.Name())); err != nil {
+			return fmt.Errorf("failed to clean results directory %s: %w", resultsDir, err)
+		}
+	}
+	return nil
+}
+
+// checkCleanableDir returns an error if removing the contents of dir would remove the root, the home
+// or the project directory.
+func checkCleanableDir(dir string, projectDir string) error {
+	if filepath.Dir(dir) == dir {
+		return fmt.Errorf("refusing to clean the root directory %s", dir)
+	}
+	if home, err := os.UserHomeDir(); IsHomeDirectory(dir) || (err == nil && isParentDir(dir, home)) {
+		return fmt.Errorf("refusing to clean %s, it contains the home directory", dir)
+	}
+	if project, err := filepath.Abs(projectDir); err == nil && (dir == project || isParentDir(dir, project)) {
+		return fmt.Errorf("refusing to clean %s, it contains the project directory", dir)
+	}
+	return nil
+}
+
+// isParentDir returns true if path is inside dir.
+func isParentDir(dir string, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
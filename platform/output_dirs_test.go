@@ -0,0 +1,103 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExpandOutputDirs(t *testing.T) {
+	t.Setenv("QODANA_BRANCH", "feature/dirs")
+	date := time.Now().Format("2006-01-02")
+	for _, testData := range []struct {
+		options  QodanaOptions
+		expected string
+	}{
+		{QodanaOptions{ResultsDir: "results", Linter: "jetbrains/qodana-jvm:2024.3"}, "results"},
+		{QodanaOptions{ResultsDir: "reports/{linter}/{date}", Linter: "registry.example.com:5000/jetbrains/qodana-jvm:2024.3"}, filepath.Join("reports", "qodana-jvm", date)},
+		{QodanaOptions{ResultsDir: "reports/{linter}-{id}", Ide: "QDPY", AnalysisId: "42"}, filepath.Join("reports", "qdpy-42")},
+		{QodanaOptions{ResultsDir: "reports/{branch}", Linter: "jetbrains/qodana-jvm"}, filepath.Join("reports", "feature-dirs")},
+	} {
+		options := testData.options
+		if err := options.ExpandOutputDirs(); err != nil {
+			t.Fatal(err)
+		}
+		if filepath.FromSlash(options.ResultsDir) != testData.expected {
+			t.Errorf("expected %s, got %s", testData.expected, options.ResultsDir)
+		}
+	}
+
+	for _, options := range []QodanaOptions{
+		{ResultsDir: "reports/{project}", Linter: "jetbrains/qodana-jvm"},
+		{ReportDir: "reports/{id}", Linter: "jetbrains/qodana-jvm"},
+	} {
+		if err := options.ExpandOutputDirs(); err == nil {
+			t.Errorf("expected an error for %q, %q", options.ResultsDir, options.ReportDir)
+		}
+	}
+}
+
+func TestCleanResultsDir(t *testing.T) {
+	projectDir := t.TempDir()
+	resultsDir := filepath.Join(t.TempDir(), "results")
+	if err := os.MkdirAll(filepath.Join(resultsDir, "log"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(resultsDir, QodanaSarifName), []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	options := &QodanaOptions{ProjectDir: projectDir, ResultsDir: resultsDir, CleanResults: true}
+	if err := options.CleanResultsDir(); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(resultsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("expected the results directory to be empty, got %d entries", len(entries))
+	}
+}
+
+func TestCleanResultsDirRefused(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	projectDir := filepath.Join(home, "project")
+	if err := os.MkdirAll(projectDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, resultsDir := range []string{
+		filepath.VolumeName(home) + string(filepath.Separator),
+		filepath.Dir(home),
+		home,
+		projectDir,
+	} {
+		t.Run(resultsDir, func(t *testing.T) {
+			options := &QodanaOptions{ProjectDir: projectDir, ResultsDir: resultsDir, CleanResults: true}
+			if err := options.CleanResultsDir(); err == nil {
+				t.Errorf("expected cleaning %s to be refused", resultsDir)
+			}
+		})
+	}
+	if _, err := os.Stat(projectDir); err != nil {
+		t.Errorf("expected the project directory to be kept: %v", err)
+	}
+}
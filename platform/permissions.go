@@ -17,11 +17,83 @@
 package platform
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 )
 
+// SetUmask sets the file mode creation mask of the current process (and the processes started from it)
+// to the given octal value. It has no effect on Windows.
+func SetUmask(mask string) error {
+	value, err := parseUmask(mask)
+	if err != nil {
+		return err
+	}
+	umask(value)
+	return nil
+}
+
+// ValidateUmask checks the --result-umask value before the run, an empty value is valid.
+func ValidateUmask(mask string) error {
+	if mask == "" {
+		return nil
+	}
+	_, err := parseUmask(mask)
+	return err
+}
+
+func parseUmask(mask string) (int, error) {
+	value, err := strconv.ParseUint(mask, 8, 32)
+	if err != nil || value > 0o777 {
+		return 0, fmt.Errorf("invalid umask %q: expected an octal value between 0000 and 0777", mask)
+	}
+	return int(value), nil
+}
+
+// ParseContainerUser parses the numeric uid and gid from the container user in the uid[:gid] format,
+// ok is false for the users given by name (except root).
+func ParseContainerUser(user string) (uid int, gid int, ok bool) {
+	if user == "root" {
+		return 0, 0, true
+	}
+	uidValue, gidValue, hasGid := strings.Cut(user, ":")
+	uid, err := strconv.Atoi(uidValue)
+	if err != nil {
+		return 0, 0, false
+	}
+	gid = uid
+	if hasGid {
+		if gid, err = strconv.Atoi(gidValue); err != nil {
+			return 0, 0, false
+		}
+	}
+	return uid, gid, true
+}
+
+// DirWritableBy checks by the owner and the permission bits that the user with the given uid and gid can create files
+// in the directory described by info. ok is false if the owner of the directory is not available (e.g. on Windows).
+func DirWritableBy(info os.FileInfo, uid int, gid int) (writable bool, ok bool) {
+	if uid == 0 {
+		return true, true
+	}
+	ownerUid, ownerGid, ok := fileOwner(info)
+	if !ok {
+		return false, false
+	}
+	perm := info.Mode().Perm()
+	switch {
+	case ownerUid == uid:
+		return perm&0o300 == 0o300, true
+	case ownerGid == gid:
+		return perm&0o030 == 0o030, true
+	default:
+		return perm&0o003 == 0o003, true
+	}
+}
+
 // ChangePermissionsRecursively changes the permissions of the given
 // directory and all its contents to allow read and write
 // permissions for files, and appropriate permissions for directories.
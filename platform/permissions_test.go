@@ -0,0 +1,135 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestSetUmask(t *testing.T) {
+	//goland:noinspection GoBoolExpressions
+	if runtime.GOOS == "windows" {
+		t.Skip("umask is not supported on Windows")
+	}
+	previous := umask(0o022)
+	t.Cleanup(func() {
+		umask(previous)
+	})
+
+	if err := SetUmask("0027"); err != nil {
+		t.Fatal(err)
+	}
+	sarifPath := filepath.Join(t.TempDir(), QodanaSarifName)
+	if err := os.WriteFile(sarifPath, []byte("{}"), 0o666); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(sarifPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0o640 {
+		t.Errorf("Expected permissions 0640, got %o", info.Mode().Perm())
+	}
+
+	for _, mask := range []string{"", "abc", "0999", "1000"} {
+		if err := SetUmask(mask); err == nil {
+			t.Errorf("Expected an error for umask %q", mask)
+		}
+	}
+}
+
+func TestValidateUmask(t *testing.T) {
+	for _, mask := range []string{"", "0002", "027", "0777"} {
+		if err := ValidateUmask(mask); err != nil {
+			t.Errorf("Expected umask %q to be valid, got %v", mask, err)
+		}
+	}
+	for _, mask := range []string{"abc", "0999", "1000", "-1"} {
+		if err := ValidateUmask(mask); err == nil {
+			t.Errorf("Expected an error for umask %q", mask)
+		}
+	}
+}
+
+func TestDirWritableBy(t *testing.T) {
+	//goland:noinspection GoBoolExpressions
+	if runtime.GOOS == "windows" {
+		t.Skip("file ownership is not supported on Windows")
+	}
+	dir := t.TempDir()
+	info, err := os.Stat(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ownerUid, ownerGid, _ := fileOwner(info)
+	otherUid, otherGid := ownerUid+1000, ownerGid+1000
+
+	for _, tc := range []struct {
+		name     string
+		perm     os.FileMode
+		uid      int
+		gid      int
+		writable bool
+	}{
+		{"owner", 0o700, ownerUid, otherGid, true},
+		{"read-only owner", 0o500, ownerUid, otherGid, false},
+		{"group", 0o770, otherUid, ownerGid, true},
+		{"other user", 0o755, otherUid, otherGid, false},
+		{"world-writable", 0o777, otherUid, otherGid, true},
+		{"root", 0o500, 0, 0, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.uid == 0 && !tc.writable {
+				t.Skip("root can write to any directory")
+			}
+			if err := os.Chmod(dir, tc.perm); err != nil {
+				t.Fatal(err)
+			}
+			info, err := os.Stat(dir)
+			if err != nil {
+				t.Fatal(err)
+			}
+			writable, ok := DirWritableBy(info, tc.uid, tc.gid)
+			if !ok || writable != tc.writable {
+				t.Errorf("expected writable=%v, got %v (ok=%v)", tc.writable, writable, ok)
+			}
+		})
+	}
+}
+
+func TestParseContainerUser(t *testing.T) {
+	for _, tc := range []struct {
+		user string
+		uid  int
+		gid  int
+		ok   bool
+	}{
+		{"1001:1002", 1001, 1002, true},
+		{"1001", 1001, 1001, true},
+		{"root", 0, 0, true},
+		{"qodana", 0, 0, false},
+		{"1001:qodana", 0, 0, false},
+	} {
+		uid, gid, ok := ParseContainerUser(tc.user)
+		if uid != tc.uid || gid != tc.gid || ok != tc.ok {
+			t.Errorf("%s: expected %d:%d (%v), got %d:%d (%v)", tc.user, tc.uid, tc.gid, tc.ok, uid, gid, ok)
+		}
+	}
+}
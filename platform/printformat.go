@@ -0,0 +1,100 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"fmt"
+	"github.com/JetBrains/qodana-cli/v2024/sarif"
+	"strconv"
+	"strings"
+)
+
+// ProblemFormatTokens are the tokens available in --print-format templates:
+//
+//	{severity} – Qodana severity of the problem (Critical, High, Moderate, Low, Info) or SARIF level
+//	{ruleId}   – inspection id
+//	{file}     – path to the file relative to the project root
+//	{line}     – start line of the problem
+//	{column}   – start column of the problem
+//	{message}  – problem description
+var ProblemFormatTokens = []string{"severity", "ruleId", "file", "line", "column", "message"}
+
+// ProblemFormat is a parsed --print-format template, e.g. "{severity}\t{file}:{line}\t{ruleId}".
+type ProblemFormat struct {
+	literals []string // literals[i] precedes tokens[i], the last literal follows the last token
+	tokens   []string
+}
+
+// ParseProblemFormat parses a --print-format template, \t and \n are replaced with a tab and a new line.
+func ParseProblemFormat(format string) (*ProblemFormat, error) {
+	format = strings.NewReplacer("\\t", "\t", "\\n", "\n").Replace(format)
+	f := &ProblemFormat{}
+	for {
+		start := strings.Index(format, "{")
+		if start < 0 {
+			break
+		}
+		end := strings.Index(format[start:], "}")
+		if end < 0 {
+			return nil, fmt.Errorf("unclosed token in print format at position %d", start)
+		}
+		token := format[start+1 : start+end]
+		if !Contains(ProblemFormatTokens, token) {
+			return nil, fmt.Errorf("unknown print format token {%s}, available tokens: {%s}", token, strings.Join(ProblemFormatTokens, "}, {"))
+		}
+		f.literals = append(f.literals, format[:start])
+		f.tokens = append(f.tokens, token)
+		format = format[start+end+1:]
+	}
+	f.literals = append(f.literals, format)
+	return f, nil
+}
+
+// Render formats the given SARIF result according to the template.
+func (f *ProblemFormat) Render(r *sarif.Result) string {
+	var b strings.Builder
+	for i, token := range f.tokens {
+		b.WriteString(f.literals[i])
+		b.WriteString(problemFormatValue(r, token))
+	}
+	b.WriteString(f.literals[len(f.literals)-1])
+	return b.String()
+}
+
+func problemFormatValue(r *sarif.Result, token string) string {
+	file, line := resultLocation(r)
+	switch token {
+	case "severity":
+		return getSeverity(r)
+	case "ruleId":
+		return r.RuleId
+	case "file":
+		return file
+	case "line":
+		return strconv.Itoa(line)
+	case "column":
+		if len(r.Locations) > 0 && r.Locations[0].PhysicalLocation != nil && r.Locations[0].PhysicalLocation.Region != nil {
+			return strconv.Itoa(int(r.Locations[0].PhysicalLocation.Region.StartColumn))
+		}
+		return "0"
+	case "message":
+		if r.Message != nil {
+			return r.Message.Text
+		}
+	}
+	return ""
+}
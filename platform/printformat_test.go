@@ -0,0 +1,146 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"github.com/JetBrains/qodana-cli/v2024/sarif"
+	"testing"
+)
+
+func TestProblemFormat(t *testing.T) {
+	r, err := ReadReportFromString(`{"version": "2.1.0", "runs": [{"results": [
+{"ruleId": "PyUnusedLocal", "message": {"text": "Local variable 'x' value is not used"}, "properties": {"qodanaSeverity": "High"},
+ "locations": [{"physicalLocation": {"artifactLocation": {"uri": "src/main.py"}, "region": {"startLine": 12, "startColumn": 5}}}]}
+]}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := &r.Runs[0].Results[0]
+
+	for _, testData := range []struct {
+		format   string
+		expected string
+	}{
+		{`{severity}\t{file}:{line}\t{ruleId}`, "High\tsrc/main.py:12\tPyUnusedLocal"},
+		{"{file}:{line}:{column}: {message}", "src/main.py:12:5: Local variable 'x' value is not used"},
+		{"no tokens", "no tokens"},
+	} {
+		format, err := ParseProblemFormat(testData.format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := format.Render(result); actual != testData.expected {
+			t.Errorf("format %q: expected %q, got %q", testData.format, testData.expected, actual)
+		}
+	}
+
+	for _, format := range []string{"{severity} {path}", "{file}:{line"} {
+		if _, err := ParseProblemFormat(format); err == nil {
+			t.Errorf("expected an error for format %q", format)
+		}
+	}
+}
+
+func TestCollapseRepeatedProblems(t *testing.T) {
+	report, err := ReadReportFromString(`{"version": "2.1.0", "runs": [{"results": [
+{"ruleId": "PyUnusedLocal", "message": {"text": "Local variable is not used"}, "properties": {"qodanaSeverity": "High"},
+ "locations": [{"physicalLocation": {"artifactLocation": {"uri": "src/a.py"}, "region": {"startLine": 1, "startColumn": 1}}}]},
+{"ruleId": "PyUnusedLocal", "message": {"text": "Local variable is not used"}, "properties": {"qodanaSeverity": "High"},
+ "locations": [{"physicalLocation": {"artifactLocation": {"uri": "src/a.py"}, "region": {"startLine": 7, "startColumn": 1}}}]},
+{"ruleId": "PyUnusedLocal", "message": {"text": "Local variable is not used"}, "properties": {"qodanaSeverity": "High"},
+ "locations": [{"physicalLocation": {"artifactLocation": {"uri": "src/b.py"}, "region": {"startLine": 3, "startColumn": 1}}}]},
+{"ruleId": "PyTypeChecker", "message": {"text": "Unexpected type"}, "properties": {"qodanaSeverity": "Moderate"},
+ "locations": [{"physicalLocation": {"artifactLocation": {"uri": "src/b.py"}, "region": {"startLine": 5, "startColumn": 1}}}]}
+]}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []*sarif.Result
+	for i := range report.Runs[0].Results {
+		results = append(results, &report.Runs[0].Results[i])
+	}
+
+	problems := collapseRepeatedProblems(results)
+	if len(problems) != 2 {
+		t.Fatalf("expected 2 printed problems, got %d", len(problems))
+	}
+	if problems[0].count != 3 || problems[1].count != 1 {
+		t.Errorf("unexpected counts: %d, %d", problems[0].count, problems[1].count)
+	}
+	DisableColor()
+	line := formatRepeatedProblem(problems[0])
+	expected := "HIGH PyUnusedLocal: Local variable is not used (3 occurrences) in src/a.py, src/b.py"
+	if line != expected {
+		t.Errorf("expected %q, got %q", expected, line)
+	}
+}
+
+func TestGroupProblems(t *testing.T) {
+	report, err := ReadReportFromString(`{"version": "2.1.0", "runs": [{"results": [
+{"ruleId": "PyTypeChecker", "message": {"text": "Unexpected type"}, "properties": {"qodanaSeverity": "Moderate"},
+ "locations": [{"physicalLocation": {"artifactLocation": {"uri": "src/b.py"}, "region": {"startLine": 5, "startColumn": 1}}}]},
+{"ruleId": "PyUnusedLocal", "message": {"text": "Local variable is not used"}, "properties": {"qodanaSeverity": "High"},
+ "locations": [{"physicalLocation": {"artifactLocation": {"uri": "src/a.py"}, "region": {"startLine": 1, "startColumn": 1}}}]},
+{"ruleId": "PyUnusedLocal", "message": {"text": "Local variable is not used"}, "properties": {"qodanaSeverity": "High"},
+ "locations": [{"physicalLocation": {"artifactLocation": {"uri": "src/b.py"}, "region": {"startLine": 3, "startColumn": 1}}}]},
+{"ruleId": "PyUnusedLocal", "message": {"text": "Local variable is not used"}, "properties": {"qodanaSeverity": "Moderate"},
+ "locations": []}
+]}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var results []*sarif.Result
+	for i := range report.Runs[0].Results {
+		results = append(results, &report.Runs[0].Results[i])
+	}
+
+	for _, testData := range []struct {
+		groupBy  string
+		expected map[string]int
+		first    string
+	}{
+		{"rule", map[string]int{"PyUnusedLocal": 3, "PyTypeChecker": 1}, "PyUnusedLocal"},
+		{"file", map[string]int{"src/b.py": 2, "src/a.py": 1, "(no file)": 1}, "src/b.py"},
+		{"severity", map[string]int{"MODERATE": 2, "HIGH": 2}, "MODERATE"},
+	} {
+		groups := groupProblems(results, testData.groupBy)
+		if len(groups) != len(testData.expected) {
+			t.Errorf("group by %s: expected %d groups, got %d", testData.groupBy, len(testData.expected), len(groups))
+			continue
+		}
+		if groups[0].key != testData.first {
+			t.Errorf("group by %s: expected %q to go first, got %q", testData.groupBy, testData.first, groups[0].key)
+		}
+		for _, g := range groups {
+			if len(g.results) != testData.expected[g.key] {
+				t.Errorf("group by %s: expected %d problems in %q, got %d", testData.groupBy, testData.expected[g.key], g.key, len(g.results))
+			}
+		}
+	}
+
+	if err := ValidateProblemsOutput("rule", 10); err != nil {
+		t.Error(err)
+	}
+	for _, testData := range []struct {
+		groupBy string
+		limit   int
+	}{{"module", 0}, {"", -1}} {
+		if err := ValidateProblemsOutput(testData.groupBy, testData.limit); err == nil {
+			t.Errorf("expected an error for %q, %d", testData.groupBy, testData.limit)
+		}
+	}
+}
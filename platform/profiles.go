@@ -0,0 +1,161 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// builtinProfiles are the profiles bundled with every Qodana linter distribution.
+var builtinProfiles = []string{"qodana.starter", "qodana.recommended", "empty"}
+
+// singleInspectionProfilePrefix is the prefix of the profile names running a single inspection, e.g. qodana.single:ConstantValue.
+const singleInspectionProfilePrefix = "qodana.single:"
+
+// inspectionProfile is the part of .idea/inspectionProfiles/*.xml needed to get the profile name.
+type inspectionProfile struct {
+	Profile struct {
+		Options []struct {
+			Name  string `xml:"name,attr"`
+			Value string `xml:"value,attr"`
+		} `xml:"option"`
+	} `xml:"profile"`
+}
+
+// ListProfiles returns the profile names that can be passed to --profile-name for the project:
+// the bundled ones, the ones shipped with the linter distribution at home (if it's known)
+// and the project profiles stored in .idea/inspectionProfiles.
+func ListProfiles(projectDir string, home string) []string {
+	profiles := append([]string{}, builtinProfiles...)
+	if home != "" {
+		profiles = append(profiles, DistributionProfiles(home)...)
+	}
+	return append(profiles, projectProfiles(projectDir)...)
+}
+
+// DistributionProfiles returns the names of the profiles shipped with the linter distribution at home, except
+// the bundled ones. They are packed as profiles/<name>.yaml (or .xml) into the jars of the Qodana plugin.
+func DistributionProfiles(home string) []string {
+	jars, err := filepath.Glob(filepath.Join(home, "plugins", "qodana", "lib", "*.jar"))
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, jar := range jars {
+		reader, err := zip.OpenReader(jar)
+		if err != nil {
+			continue
+		}
+		for _, f := range reader.File {
+			dir, file := path.Split(f.Name)
+			ext := path.Ext(file)
+			if path.Base(dir) != "profiles" || (ext != ".yaml" && ext != ".xml") {
+				continue
+			}
+			name := strings.TrimSuffix(file, ext)
+			if name != "" && !Contains(names, name) && !Contains(builtinProfiles, name) {
+				names = append(names, name)
+			}
+		}
+		_ = reader.Close()
+	}
+	sort.Strings(names)
+	return names
+}
+
+func projectProfiles(projectDir string) []string {
+	return ProfilesInDir(filepath.Join(projectDir, ".idea", "inspectionProfiles"))
+}
+
+// ProfilesInDir returns the names of the inspection profiles stored as XML files in dir, except the bundled ones.
+func ProfilesInDir(dir string) []string {
+	files, err := filepath.Glob(filepath.Join(dir, "*.xml"))
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, file := range files {
+		if filepath.Base(file) == "profiles_settings.xml" {
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		var component inspectionProfile
+		if err := xml.Unmarshal(data, &component); err != nil {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(file), ".xml")
+		for _, option := range component.Profile.Options {
+			if option.Name == "myName" && option.Value != "" {
+				name = option.Value
+			}
+		}
+		if !Contains(names, name) && !Contains(builtinProfiles, name) {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ValidateProfileName checks that name is one of the available profiles,
+// the error suggests the available profiles with a similar name.
+func ValidateProfileName(name string, available []string) error {
+	if Contains(available, name) || strings.HasPrefix(name, singleInspectionProfilePrefix) {
+		return nil
+	}
+	var similar []string
+	for _, profile := range available {
+		if strings.EqualFold(profile, name) || editDistance(Lower(profile), Lower(name)) <= max(2, len(name)/4) {
+			similar = append(similar, fmt.Sprintf("%q", profile))
+		}
+	}
+	if len(similar) > 0 {
+		return fmt.Errorf("profile %q is not found, did you mean %s?", name, strings.Join(similar, " or "))
+	}
+	return fmt.Errorf("profile %q is not found, available profiles: %s", name, strings.Join(available, ", "))
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a string, b string) int {
+	previous := make([]int, len(b)+1)
+	current := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(b)]
+}
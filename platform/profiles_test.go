@@ -0,0 +1,109 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestListProfiles(t *testing.T) {
+	projectDir := t.TempDir()
+	if got := ListProfiles(projectDir, ""); !reflect.DeepEqual(got, builtinProfiles) {
+		t.Errorf("expected only bundled profiles, got %v", got)
+	}
+
+	profilesDir := filepath.Join(projectDir, ".idea", "inspectionProfiles")
+	if err := os.MkdirAll(profilesDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{
+		"Project_Default.xml":   `<component name="InspectionProjectProfileManager"><profile version="1.0"><option name="myName" value="Project Default" /></profile></component>`,
+		"strict.xml":            `<component name="InspectionProjectProfileManager"><profile version="1.0"><option name="myName" value="Strict" /></profile></component>`,
+		"profiles_settings.xml": `<component name="InspectionProjectProfileManager"><settings><option name="PROJECT_PROFILE" value="Strict" /></settings></component>`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(profilesDir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := append(append([]string{}, builtinProfiles...), "Project Default", "Strict")
+	if got := ListProfiles(projectDir, ""); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+
+	home := t.TempDir()
+	writeQodanaPluginJar(t, home, "profiles/qodana.sanity.yaml", "profiles/qodana.starter.yaml", "profiles/README.md", "META-INF/plugin.xml")
+	expected = append(append([]string{}, builtinProfiles...), "qodana.sanity", "Project Default", "Strict")
+	if got := ListProfiles(projectDir, home); !reflect.DeepEqual(got, expected) {
+		t.Errorf("expected %v, got %v", expected, got)
+	}
+}
+
+// writeQodanaPluginJar writes a jar with the given empty entries to the Qodana plugin of the distribution at home.
+func writeQodanaPluginJar(t *testing.T, home string, entries ...string) {
+	libDir := filepath.Join(home, "plugins", "qodana", "lib")
+	if err := os.MkdirAll(libDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Create(filepath.Join(libDir, "qodana.jar"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	writer := zip.NewWriter(f)
+	for _, entry := range entries {
+		if _, err := writer.Create(entry); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestValidateProfileName(t *testing.T) {
+	available := []string{"qodana.starter", "qodana.recommended", "empty", "Project Default"}
+	for _, tc := range []struct {
+		name     string
+		expected string
+	}{
+		{name: "qodana.recommended"},
+		{name: "Project Default"},
+		{name: "qodana.single:ConstantValue"},
+		{name: "qodana.recomended", expected: `profile "qodana.recomended" is not found, did you mean "qodana.recommended"?`},
+		{name: "project default", expected: `profile "project default" is not found, did you mean "Project Default"?`},
+		{name: "strict", expected: `profile "strict" is not found, available profiles: qodana.starter, qodana.recommended, empty, Project Default`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := ValidateProfileName(tc.name, available)
+			if tc.expected == "" {
+				if err != nil {
+					t.Errorf("ValidateProfileName(%q) unexpected error: %v", tc.name, err)
+				}
+			} else if err == nil || err.Error() != tc.expected {
+				t.Errorf("ValidateProfileName(%q) = %v, expected %s", tc.name, err, tc.expected)
+			}
+		})
+	}
+}
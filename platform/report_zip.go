@@ -0,0 +1,120 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// reportZipModified is the modification time of all entries of the report archive, so the same report always produces the same archive.
+var reportZipModified = time.Date(1980, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// ArchiveReport writes the HTML report to the --report-zip archive.
+// With --report-zip-only, the report directory is removed afterward.
+func (o *QodanaOptions) ArchiveReport() error {
+	if o.ReportZip == "" {
+		return nil
+	}
+	reportDir := o.reportDirPath()
+	if _, err := os.Stat(reportDir); err != nil {
+		return fmt.Errorf("failed to archive the report to %s: the HTML report is not generated: %w", o.ReportZip, err)
+	}
+	if err := ZipReport(reportDir, o.ReportZip); err != nil {
+		return fmt.Errorf("failed to archive the report to %s: %w", o.ReportZip, err)
+	}
+	if o.ReportZipOnly {
+		return os.RemoveAll(reportDir)
+	}
+	return nil
+}
+
+// ZipReport packs the files of reportDir into the zip archive at zipPath.
+// The entries are sorted by path and have fixed timestamps, so the archive is reproducible.
+// On failure, the partially written archive is removed.
+func ZipReport(reportDir string, zipPath string) error {
+	zipPath, err := filepath.Abs(zipPath)
+	if err != nil {
+		return err
+	}
+	if err = os.MkdirAll(filepath.Dir(zipPath), 0o755); err != nil {
+		return err
+	}
+	if err = writeReportZip(reportDir, zipPath); err != nil {
+		_ = os.Remove(zipPath)
+		return err
+	}
+	return nil
+}
+
+func writeReportZip(reportDir string, zipPath string) error {
+	archive, err := os.Create(zipPath)
+	if err != nil {
+		return err
+	}
+	defer func(archive *os.File) {
+		_ = archive.Close()
+	}(archive)
+
+	writer := zip.NewWriter(archive)
+	// filepath.WalkDir visits the entries in lexical order
+	err = filepath.WalkDir(reportDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if absPath, err := filepath.Abs(path); err == nil && absPath == zipPath {
+			return nil
+		}
+		name, err := filepath.Rel(reportDir, path)
+		if err != nil {
+			return err
+		}
+		return addZipEntry(writer, path, filepath.ToSlash(name))
+	})
+	if err != nil {
+		return err
+	}
+	if err = writer.Close(); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+func addZipEntry(writer *zip.Writer, path string, name string) error {
+	entry, err := writer.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: reportZipModified,
+	})
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func(file *os.File) {
+		_ = file.Close()
+	}(file)
+	_, err = io.Copy(entry, file)
+	return err
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestArchiveReport(t *testing.T) {
+	dir := t.TempDir()
+	reportDir := filepath.Join(dir, "report")
+	for name, content := range map[string]string{
+		"index.html":                      "<html></html>",
+		"results/result-allProblems.json": "{}",
+	} {
+		path := filepath.Join(reportDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	options := &QodanaOptions{ReportDir: reportDir, ReportZip: filepath.Join(dir, "report.zip")}
+
+	if err := options.ArchiveReport(); err != nil {
+		t.Fatal(err)
+	}
+	reader, err := zip.OpenReader(options.ReportZip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(reader *zip.ReadCloser) {
+		_ = reader.Close()
+	}(reader)
+	var names []string
+	for _, f := range reader.File {
+		names = append(names, f.Name)
+	}
+	if len(names) != 2 || names[0] != "index.html" || names[1] != "results/result-allProblems.json" {
+		t.Errorf("unexpected archive entries: %v", names)
+	}
+	if _, err := os.Stat(filepath.Join(reportDir, "index.html")); err != nil {
+		t.Errorf("expected the report directory to be kept: %v", err)
+	}
+
+	// the same report produces the same archive
+	first, err := os.ReadFile(options.ReportZip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().Add(time.Hour)
+	if err = os.Chtimes(filepath.Join(reportDir, "index.html"), now, now); err != nil {
+		t.Fatal(err)
+	}
+	options.ReportZipOnly = true
+	if err = options.ArchiveReport(); err != nil {
+		t.Fatal(err)
+	}
+	second, err := os.ReadFile(options.ReportZip)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first, second) {
+		t.Error("expected the archive to be reproducible")
+	}
+	if _, err := os.Stat(reportDir); !os.IsNotExist(err) {
+		t.Errorf("expected the report directory to be removed with --report-zip-only")
+	}
+}
+
+func TestArchiveReportWithoutReport(t *testing.T) {
+	dir := t.TempDir()
+	options := &QodanaOptions{ReportDir: filepath.Join(dir, "report"), ReportZip: filepath.Join(dir, "report.zip")}
+
+	if err := options.ArchiveReport(); err == nil {
+		t.Fatal("expected an error without the HTML report")
+	}
+	if _, err := os.Stat(options.ReportZip); !os.IsNotExist(err) {
+		t.Errorf("expected no archive to be created")
+	}
+
+	// a failed archive is not left behind
+	if err := ZipReport(options.ReportDir, options.ReportZip); err == nil {
+		t.Fatal("expected an error without the HTML report")
+	}
+	if _, err := os.Stat(options.ReportZip); !os.IsNotExist(err) {
+		t.Errorf("expected the partial archive to be removed")
+	}
+}
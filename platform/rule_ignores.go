@@ -0,0 +1,71 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/JetBrains/qodana-cli/v2024/sarif"
+)
+
+// IgnoredRules returns the rule ids to drop from the results: ruleIgnores from qodana.yaml and --ignore-rule.
+func (o *QodanaOptions) IgnoredRules() []string {
+	return getIgnoredRules(&o.QdConfig, o)
+}
+
+func getIgnoredRules(yaml *QodanaYaml, options *QodanaOptions) []string {
+	ruleIds := append([]string{}, yaml.RuleIgnores...)
+	for _, ruleId := range options.IgnoreRules {
+		if !Contains(ruleIds, ruleId) {
+			ruleIds = append(ruleIds, ruleId)
+		}
+	}
+	return ruleIds
+}
+
+// DropIgnoredRules removes the results of the given rules from the report at sarifPath, returns the number
+// of removed results. Unlike disabling the inspection in the profile, the rules still run, only their results are dropped.
+func DropIgnoredRules(sarifPath string, ruleIds []string) (int, error) {
+	if len(ruleIds) == 0 {
+		return 0, nil
+	}
+	report, err := ReadReport(sarifPath)
+	if err != nil {
+		return 0, fmt.Errorf("error reading SARIF %s: %w", sarifPath, err)
+	}
+	dropped := 0
+	for i := range report.Runs {
+		results := make([]sarif.Result, 0, len(report.Runs[i].Results))
+		for _, r := range report.Runs[i].Results {
+			if Contains(ruleIds, r.RuleId) {
+				dropped++
+				continue
+			}
+			results = append(results, r)
+		}
+		report.Runs[i].Results = results
+	}
+	if dropped == 0 {
+		return 0, nil
+	}
+	if err := WriteReport(sarifPath, report); err != nil {
+		return 0, err
+	}
+	WarningMessage("Dropped %d result(s) of the ignored rules: %s", dropped, strings.Join(ruleIds, ", "))
+	return dropped, nil
+}
@@ -0,0 +1,70 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIgnoredRules(t *testing.T) {
+	options := &QodanaOptions{
+		QdConfig:    QodanaYaml{RuleIgnores: []string{"ConstantValue", "UnusedSymbol"}},
+		IgnoreRules: []string{"UnusedSymbol", "JavaDocReference"},
+	}
+	expected := []string{"ConstantValue", "UnusedSymbol", "JavaDocReference"}
+	if actual := options.IgnoredRules(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("expected %v, got %v", expected, actual)
+	}
+}
+
+func TestDropIgnoredRules(t *testing.T) {
+	sarifPath := filepath.Join(t.TempDir(), QodanaSarifName)
+	report := `{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "QDJVM"}}, "results": [
+{"ruleId": "ConstantValue", "message": {"text": "Condition is always true"}, "baselineState": "new"},
+{"ruleId": "ConstantValue", "message": {"text": "Condition is always false"}, "baselineState": "new"},
+{"ruleId": "UnusedSymbol", "message": {"text": "Unused variable"}, "baselineState": "new"}
+]}]}`
+	if err := os.WriteFile(sarifPath, []byte(report), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	dropped, err := DropIgnoredRules(sarifPath, []string{"ConstantValue"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dropped != 2 {
+		t.Errorf("expected 2 dropped results, got %d", dropped)
+	}
+	exitCode, err := RecheckFailureThresholds(sarifPath, map[string]string{severityAny: "1"}, QodanaFailThresholdExitCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if exitCode != QodanaSuccessExitCode {
+		t.Errorf("expected the dropped results not to count toward the threshold, got exit code %d", exitCode)
+	}
+	actual, err := ReadReport(sarifPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := actual.Runs[0].Results
+	if len(results) != 1 || results[0].RuleId != "UnusedSymbol" {
+		t.Errorf("expected only the UnusedSymbol result to be kept, got %v", results)
+	}
+}
@@ -28,6 +28,12 @@ import (
 )
 
 func RunAnalysis(options *QodanaOptions) (int, error) {
+	cleanupCaCerts, err := options.SetupCaCerts()
+	if err != nil {
+		ErrorMessage(err.Error())
+		return 1, err
+	}
+	defer cleanupCaCerts()
 	linterOptions, mountInfo, linterInfo, err := getLinterDescriptors(options)
 	if err != nil {
 		ErrorMessage(err.Error())
@@ -37,11 +43,34 @@ func RunAnalysis(options *QodanaOptions) (int, error) {
 	printLinterLicense(options, linterInfo)
 	printQodanaLogo(options, linterInfo)
 
-	defineResultAndCacheDir(options)
+	if err = defineResultAndCacheDir(options); err != nil {
+		ErrorMessage(err.Error())
+		return 1, err
+	}
+	if err = options.CleanResultsDir(); err != nil {
+		ErrorMessage(err.Error())
+		return 1, err
+	}
 	if err = ensureWorkingDirsCreated(options, mountInfo); err != nil {
 		ErrorMessage(err.Error())
 		return 1, err
 	}
+	if err = options.InitGitSubmodules(); err != nil {
+		ErrorMessage(err.Error())
+		return 1, err
+	}
+	cacheLock, err := options.LockCacheDir()
+	if err != nil {
+		ErrorMessage(err.Error())
+		return 1, err
+	}
+	if cacheLock != nil {
+		defer func() {
+			if err := cacheLock.Unlock(); err != nil {
+				log.Warnf("Failed to release the cache directory lock: %v", err)
+			}
+		}()
+	}
 
 	yaml := getQodanaYaml(options)
 	if err = (*linterOptions).Setup(options); err != nil {
@@ -61,18 +90,67 @@ func RunAnalysis(options *QodanaOptions) (int, error) {
 	logOs(eventsCh, options, linterInfo)
 	logProjectOpen(eventsCh, options, linterInfo)
 
+	if err = generateClangCompileDb(options); err != nil {
+		ErrorMessage(err.Error())
+		return 1, err
+	}
 	if err = (*linterOptions).RunAnalysis(options, yaml); err != nil {
 		ErrorMessage(err.Error())
 		return 1, err
 	}
 	log.Debugf("Java executable path: %s", mountInfo.JavaPath)
 
+	if options.ExcludeGenerated {
+		excluded, err := ExcludeGeneratedResults(options.GetSarifPath(), yaml.GeneratedFileGlobs())
+		if err != nil {
+			ErrorMessage(err.Error())
+			return 1, err
+		}
+		if excluded > 0 {
+			log.Printf("Excluded %d result(s) in generated files", excluded)
+		}
+	}
+
+	if _, err = ApplySeverityOverrides(options.GetSarifPath(), yaml.SeverityOverrides); err != nil {
+		ErrorMessage(err.Error())
+		return 1, err
+	}
+	if _, err = DropIgnoredRules(options.GetSarifPath(), getIgnoredRules(yaml, options)); err != nil {
+		ErrorMessage(err.Error())
+		return 1, err
+	}
+
 	thresholds := getFailureThresholds(yaml, options)
 	var analysisResult int
+	baselineSeed := options.PrepareBaselineSeed()
 	if analysisResult, err = computeBaselinePrintResults(options, mountInfo, thresholds); err != nil {
 		ErrorMessage(err.Error())
 		return 1, err
 	}
+	if analysisResult, err = ApplyBaselineIgnoreRules(options.GetSarifPath(), options.BaselineIgnoreRules, thresholds, analysisResult); err != nil {
+		ErrorMessage(err.Error())
+		return 1, err
+	}
+	if analysisResult, err = SeedBaseline(options.GetSarifPath(), baselineSeed, analysisResult); err != nil {
+		ErrorMessage(err.Error())
+		return 1, err
+	}
+	if options.BaselineIncludeAbsent && options.AbsentMinSeverity != "" {
+		if err = FilterAbsentResults(options.GetSarifPath(), options.AbsentMinSeverity); err != nil {
+			ErrorMessage(err.Error())
+			return 1, err
+		}
+	}
+	if options.AnnotateBaselineState {
+		if err = AnnotateBaselineState(options.GetSarifPath()); err != nil {
+			ErrorMessage(err.Error())
+			return 1, err
+		}
+	}
+	if _, err = SplitReport(options.GetSarifPath(), options.SarifSplitSize); err != nil {
+		ErrorMessage(err.Error())
+		return 1, err
+	}
 	if err = copySarifToReportPath(options); err != nil {
 		ErrorMessage(err.Error())
 		return 1, err
@@ -86,6 +164,14 @@ func RunAnalysis(options *QodanaOptions) (int, error) {
 		return 1, err
 	}
 	sendReportToQodanaServer(options, mountInfo)
+	if err = options.PublishReportToS3(); err != nil {
+		ErrorMessage(err.Error())
+		return 1, err
+	}
+	if err = options.ArchiveReport(); err != nil {
+		ErrorMessage(err.Error())
+		return 1, err
+	}
 	return analysisResult, nil
 }
 
@@ -94,13 +180,31 @@ func getQodanaYaml(options *QodanaOptions) *QodanaYaml {
 	if options.ConfigName != "" {
 		qodanaYamlPath = options.ConfigName
 	}
-	return LoadQodanaYaml(options.ProjectDir, qodanaYamlPath)
+	qodanaYaml, _, err := options.LoadQodanaYaml(qodanaYamlPath)
+	if err != nil {
+		log.Fatalf("Failed to load %s: %s", qodanaYamlPath, err)
+	}
+	qodanaYaml.ResolveProfilePath(options.ProjectDir, qodanaYamlPath)
+	if err := qodanaYaml.LoadPropertiesFile(options.ProjectDir); err != nil {
+		log.Fatalf("Invalid %s: %s", qodanaYamlPath, err)
+	}
+	if err := qodanaYaml.ExpandEnv(); err != nil {
+		log.Fatalf("Invalid %s: %s", qodanaYamlPath, err)
+	}
+	if err := qodanaYaml.ValidateSeverityOverrides(); err != nil {
+		log.Fatalf("Invalid %s: %s", qodanaYamlPath, err)
+	}
+	return qodanaYaml
 }
 
 func ensureWorkingDirsCreated(options *QodanaOptions, mountInfo *MountInfo) error {
 	var err error
 
-	if mountInfo.JavaPath, err = getJavaExecutablePath(); err != nil {
+	if options.JavaHome != "" {
+		if mountInfo.JavaPath, err = JavaExecutable(options.JavaHome); err != nil {
+			return err
+		}
+	} else if mountInfo.JavaPath, err = getJavaExecutablePath(); err != nil {
 		return fmt.Errorf("failed to get java executable path: %w", err)
 	}
 
@@ -164,7 +268,10 @@ func getLinterDescriptors(options *QodanaOptions) (*ThirdPartyOptions, *MountInf
 	return linterOptions, mountInfo, linterInfo, nil
 }
 
-func defineResultAndCacheDir(options *QodanaOptions) {
+func defineResultAndCacheDir(options *QodanaOptions) error {
+	if err := options.ExpandOutputDirs(); err != nil {
+		return err
+	}
 	// we don't provide default for cache dir, since we don't want to compute options.id without knowing the exact folder
 	if options.CacheDir == "" {
 		options.CacheDir = options.GetCacheDir()
@@ -172,6 +279,7 @@ func defineResultAndCacheDir(options *QodanaOptions) {
 	if options.ResultsDir == "" {
 		options.ResultsDir = options.resultsDirPath()
 	}
+	return nil
 }
 
 func sendReportToQodanaServer(options *QodanaOptions, mountInfo *MountInfo) {
@@ -229,3 +337,24 @@ func printQodanaLogo(options *QodanaOptions, linterInfo *LinterInfo) {
 	fmt.Println("Cache directory: " + options.GetCacheDir())
 	fmt.Print(QodanaLogo(linterInfo.LinterName, linterInfo.LinterVersion, linterInfo.IsEap))
 }
+
+// generateClangCompileDb runs the --clang-db-generate command the same way as bootstrap
+// and checks that the compilation database required by qodana-clang exists afterward.
+func generateClangCompileDb(options *QodanaOptions) error {
+	if options.ClangCompileDbCommand == "" {
+		return nil
+	}
+	Bootstrap(options.ClangCompileDbCommand, options.ProjectDir)
+	compileCommands := options.ClangCompileCommands
+	if !filepath.IsAbs(compileCommands) {
+		compileCommands = filepath.Join(options.ProjectDir, compileCommands)
+	}
+	if _, err := os.Stat(compileCommands); err != nil {
+		return fmt.Errorf(
+			"%s does not exist after running %q, make sure the command generates it or point --compile-commands to the generated file",
+			compileCommands,
+			options.ClangCompileDbCommand,
+		)
+	}
+	return nil
+}
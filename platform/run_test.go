@@ -0,0 +1,48 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"testing"
+)
+
+func TestGenerateClangCompileDb(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		command   string
+		expectErr bool
+	}{
+		{"no command", "", false},
+		{"command generates the database", "mkdir -p build && echo [] > build/compile_commands.json", false},
+		{"command does not generate the database", "echo skipped", true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			options := &QodanaOptions{
+				ProjectDir:            t.TempDir(),
+				ClangCompileCommands:  "./build/compile_commands.json",
+				ClangCompileDbCommand: tc.command,
+			}
+			err := generateClangCompileDb(options)
+			if tc.expectErr && err == nil {
+				t.Fatal("expected an error for the missing compilation database")
+			}
+			if !tc.expectErr && err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
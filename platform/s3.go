@@ -0,0 +1,421 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	s3DefaultRegion = "us-east-1"
+	// s3RequestTimeout limits every request, a file below s3MultipartThreshold or a part of s3PartSize is uploaded within it.
+	s3RequestTimeout = 5 * time.Minute
+)
+
+// s3MultipartThreshold is the file size from which the artifacts are uploaded in parts of s3PartSize.
+var (
+	s3MultipartThreshold int64 = 64 << 20
+	s3PartSize           int64 = 16 << 20
+)
+
+// S3Credentials are the credentials and the location of S3 or an S3-compatible storage.
+type S3Credentials struct {
+	AccessKeyId     string
+	SecretAccessKey string
+	SessionToken    string
+	Region          string
+	// Endpoint is the URL of an S3-compatible storage, objects are addressed path-style there.
+	// When empty, AWS S3 of Region is used.
+	Endpoint string
+}
+
+// S3CredentialsFromEnv reads the credentials from the standard AWS environment variables.
+func S3CredentialsFromEnv() (S3Credentials, error) {
+	creds := S3Credentials{
+		AccessKeyId:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		Region:          firstNonEmpty(os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION"), s3DefaultRegion),
+		Endpoint:        firstNonEmpty(os.Getenv("AWS_ENDPOINT_URL_S3"), os.Getenv("AWS_ENDPOINT_URL")),
+	}
+	if creds.AccessKeyId == "" || creds.SecretAccessKey == "" {
+		return creds, fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set to upload the report to S3")
+	}
+	return creds, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// ParseS3Location parses the --s3-upload value in the [s3://]bucket[/prefix] format.
+func ParseS3Location(location string) (string, string, error) {
+	bucket, prefix, _ := strings.Cut(strings.TrimPrefix(location, "s3://"), "/")
+	if bucket == "" {
+		return "", "", fmt.Errorf("invalid --s3-upload %q: expected [s3://]bucket[/prefix]", location)
+	}
+	return bucket, strings.Trim(prefix, "/"), nil
+}
+
+// ValidateS3Upload checks the --s3-upload location and the credentials before the analysis starts.
+func (o *QodanaOptions) ValidateS3Upload() error {
+	if o.S3Upload == "" {
+		return nil
+	}
+	if _, _, err := ParseS3Location(o.S3Upload); err != nil {
+		return err
+	}
+	_, err := S3CredentialsFromEnv()
+	return err
+}
+
+// PublishReportToS3 uploads the report to the --s3-upload location, if it is set.
+func (o *QodanaOptions) PublishReportToS3() error {
+	if o.S3Upload == "" {
+		return nil
+	}
+	bucket, prefix, err := ParseS3Location(o.S3Upload)
+	if err != nil {
+		return err
+	}
+	creds, err := S3CredentialsFromEnv()
+	if err != nil {
+		return err
+	}
+	return o.UploadReportToS3(bucket, prefix, creds)
+}
+
+// UploadReportToS3 uploads the SARIF report, the short SARIF report and the HTML report under prefix in bucket
+// and prints the URLs of the reports, the URLs of the HTML report assets are only logged.
+func (o *QodanaOptions) UploadReportToS3(bucket string, prefix string, creds S3Credentials) error {
+	files, err := o.s3ReportFiles(prefix)
+	if err != nil {
+		return err
+	}
+	client := &s3Client{creds: creds, bucket: bucket, http: &http.Client{Timeout: s3RequestTimeout}, now: time.Now}
+	for _, key := range sortedKeys(files) {
+		if err := client.upload(files[key], key); err != nil {
+			return fmt.Errorf("failed to upload %s to S3: %w", files[key], err)
+		}
+		log.Debugf("Uploaded %s to %s", files[key], client.objectUrl(key))
+	}
+	SuccessMessage("Report is successfully uploaded to %s", client.objectUrl(prefix))
+	for _, key := range []string{path.Join(prefix, QodanaSarifName), path.Join(prefix, QodanaShortSarifName), path.Join(prefix, "report", "index.html")} {
+		if _, ok := files[key]; ok {
+			SuccessMessage("Uploaded %s", client.objectUrl(key))
+		}
+	}
+	return nil
+}
+
+// s3ReportFiles returns the local paths of the report files by their object keys.
+func (o *QodanaOptions) s3ReportFiles(prefix string) (map[string]string, error) {
+	files := map[string]string{}
+	if _, err := os.Stat(o.GetSarifPath()); err != nil {
+		return nil, fmt.Errorf("nothing to upload: %w", err)
+	}
+	files[path.Join(prefix, QodanaSarifName)] = o.GetSarifPath()
+	if _, err := os.Stat(o.GetShortSarifPath()); err == nil {
+		files[path.Join(prefix, QodanaShortSarifName)] = o.GetShortSarifPath()
+	}
+	reportDir := o.reportDirPath()
+	if _, err := os.Stat(reportDir); os.IsNotExist(err) {
+		return files, nil
+	}
+	err := filepath.WalkDir(reportDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		name, err := filepath.Rel(reportDir, p)
+		if err != nil {
+			return err
+		}
+		files[path.Join(prefix, "report", filepath.ToSlash(name))] = p
+		return nil
+	})
+	return files, err
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// s3Client is a minimal S3 client signing its requests with AWS Signature Version 4.
+type s3Client struct {
+	creds  S3Credentials
+	bucket string
+	http   *http.Client
+	now    func() time.Time
+}
+
+func (c *s3Client) objectUrl(key string) string {
+	if c.creds.Endpoint == "" {
+		return fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", c.bucket, c.creds.Region, s3EscapePath(key))
+	}
+	return fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(c.creds.Endpoint, "/"), c.bucket, s3EscapePath(key))
+}
+
+// upload puts the file at key, in parts if it's larger than s3MultipartThreshold.
+func (c *s3Client) upload(file string, key string) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return err
+	}
+	contentType := mime.TypeByExtension(filepath.Ext(file))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	if info.Size() < s3MultipartThreshold {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		_, _, err = c.do(http.MethodPut, key, nil, data, contentType)
+		return err
+	}
+	return c.uploadMultipart(file, key, contentType)
+}
+
+type s3InitiateMultipartUploadResult struct {
+	UploadId string `xml:"UploadId"`
+}
+
+type s3CompletedPart struct {
+	PartNumber int    `xml:"PartNumber"`
+	ETag       string `xml:"ETag"`
+}
+
+type s3CompleteMultipartUpload struct {
+	XMLName xml.Name          `xml:"CompleteMultipartUpload"`
+	Parts   []s3CompletedPart `xml:"Part"`
+}
+
+func (c *s3Client) uploadMultipart(file string, key string, contentType string) error {
+	body, _, err := c.do(http.MethodPost, key, url.Values{"uploads": {""}}, nil, contentType)
+	if err != nil {
+		return err
+	}
+	var initiated s3InitiateMultipartUploadResult
+	if err = xml.Unmarshal(body, &initiated); err != nil {
+		return fmt.Errorf("failed to start the multipart upload: %w", err)
+	}
+	uploadId := initiated.UploadId
+
+	parts, err := c.uploadParts(file, key, uploadId)
+	if err == nil {
+		var completion []byte
+		completion, err = xml.Marshal(s3CompleteMultipartUpload{Parts: parts})
+		if err == nil {
+			body, _, err = c.do(http.MethodPost, key, url.Values{"uploadId": {uploadId}}, completion, "application/xml")
+		}
+		// CompleteMultipartUpload can fail after responding with 200
+		if err == nil && bytes.Contains(body, []byte("<Error>")) {
+			err = fmt.Errorf("failed to complete the multipart upload: %s", body)
+		}
+	}
+	if err != nil {
+		_, _, _ = c.do(http.MethodDelete, key, url.Values{"uploadId": {uploadId}}, nil, "")
+	}
+	return err
+}
+
+func (c *s3Client) uploadParts(file string, key string, uploadId string) ([]s3CompletedPart, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer func(f *os.File) {
+		_ = f.Close()
+	}(f)
+
+	var parts []s3CompletedPart
+	buf := make([]byte, s3PartSize)
+	for number := 1; ; number++ {
+		n, err := io.ReadFull(f, buf)
+		if err == io.EOF {
+			return parts, nil
+		}
+		if err != nil && err != io.ErrUnexpectedEOF {
+			return nil, err
+		}
+		query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadId}}
+		_, header, err := c.do(http.MethodPut, key, query, buf[:n], "")
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, s3CompletedPart{PartNumber: number, ETag: header.Get("ETag")})
+	}
+}
+
+// do sends a signed request for the object at key and returns the response body and headers.
+func (c *s3Client) do(method string, key string, query url.Values, body []byte, contentType string) ([]byte, http.Header, error) {
+	u, err := url.Parse(c.objectUrl(key))
+	if err != nil {
+		return nil, nil, err
+	}
+	u.RawQuery = s3CanonicalQuery(query)
+	req, err := http.NewRequest(method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	c.sign(req, body)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func(body io.ReadCloser) {
+		_ = body.Close()
+	}(resp.Body)
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, nil, fmt.Errorf("%s %s: %s: %s", method, u.Redacted(), resp.Status, respBody)
+	}
+	return respBody, resp.Header, nil
+}
+
+// sign adds the AWS Signature Version 4 headers to req.
+func (c *s3Client) sign(req *http.Request, body []byte) {
+	now := c.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if c.creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.creds.SessionToken)
+	}
+	headers := map[string]string{"host": req.URL.Host}
+	for name := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(req.Header.Get(name))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + c.creds.Region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonicalRequest))}, "\n")
+
+	key := hmacSha256([]byte("AWS4"+c.creds.SecretAccessKey), date)
+	for _, part := range []string{c.creds.Region, "s3", "aws4_request"} {
+		key = hmacSha256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSha256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.creds.AccessKeyId, scope, signedHeaders, signature,
+	))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSha256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3Escape encodes s as required by the canonical request: everything but the unreserved characters is escaped.
+func s3Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		ch := s[i]
+		if ch >= 'A' && ch <= 'Z' || ch >= 'a' && ch <= 'z' || ch >= '0' && ch <= '9' || strings.IndexByte("-._~", ch) >= 0 {
+			b.WriteByte(ch)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", ch)
+		}
+	}
+	return b.String()
+}
+
+func s3EscapePath(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = s3Escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// s3CanonicalQuery encodes the query with the parameters sorted by name.
+func s3CanonicalQuery(query url.Values) string {
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var params []string
+	for _, name := range names {
+		for _, value := range query[name] {
+			params = append(params, s3Escape(name)+"="+s3Escape(value))
+		}
+	}
+	return strings.Join(params, "&")
+}
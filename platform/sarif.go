@@ -17,7 +17,10 @@
 package platform
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/JetBrains/qodana-cli/v2024/sarif"
 	"github.com/google/uuid"
@@ -25,6 +28,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	"os"
 	"path/filepath"
+	"runtime"
 	"sort"
 	"strings"
 	"time"
@@ -35,6 +39,7 @@ const (
 	baselineStateEmpty     = ""          // baselineStateEmpty default baseline state (not set)
 	baselineStateNew       = "new"       // baselineStateNew new baseline state
 	baselineStateUnchanged = "unchanged" // baselineStateUnchanged unchanged baseline state
+	baselineStateAbsent    = "absent"    // baselineStateAbsent absent baseline state
 	extension              = ".sarif.json"
 	qodanaCritical         = "Critical"
 	qodanaHigh             = "High"
@@ -44,6 +49,8 @@ const (
 	sarifError             = "error"
 	sarifWarning           = "warning"
 	sarifNote              = "note"
+	analysisNameProperty   = "analysisName"
+	sarifVersion           = "2.1.0" // sarifVersion the only SARIF version supported
 )
 
 func MergeSarifReports(options *QodanaOptions, deviceId string) (int, error) {
@@ -64,20 +71,13 @@ func MergeSarifReports(options *QodanaOptions, deviceId string) (int, error) {
 		return 0, fmt.Errorf("Error merging SARIF files: %s\n", err)
 	}
 
-	for _, result := range finalReport.Runs[0].Results {
-		// update locations[].physicalLocation.artifactLocation.uri by removing the projectDir prefix
-		for _, location := range result.Locations {
-			if (location.PhysicalLocation == nil) || (location.PhysicalLocation.ArtifactLocation == nil) {
-				continue
-			}
-			toReplace := options.ProjectDir
-			if !strings.HasSuffix(toReplace, string(os.PathSeparator)) {
-				toReplace += string(os.PathSeparator)
-			}
-			location.PhysicalLocation.ArtifactLocation.Uri = strings.TrimPrefix(location.PhysicalLocation.ArtifactLocation.Uri, toReplace)
-		}
+	rebases, err := ParseUriRebases(options.SarifRebaseUris)
+	if err != nil {
+		return 0, err
 	}
-	finalReport.Runs[0].Results = removeDuplicates(finalReport.Runs[0].Results)
+	// the projectDir prefix is always removed, then the user-defined rebases are applied
+	rebaseReportUris(finalReport, append([]UriRebase{{From: options.ProjectDir}}, rebases...))
+	finalReport.Runs[0].Results = removeDuplicates(finalReport.Runs[0].Results, options.DedupHighestSeverity)
 
 	SetVersionControlParams(options, deviceId, finalReport)
 
@@ -90,21 +90,114 @@ func MergeSarifReports(options *QodanaOptions, deviceId string) (int, error) {
 	return totalProblems, nil
 }
 
-func removeDuplicates(results []sarif.Result) []sarif.Result {
+// UriRebase replaces the From prefix of artifact URIs with To.
+type UriRebase struct {
+	From string
+	To   string
+}
+
+// ParseUriRebases parses --sarif-rebase-uris values in the form of from=to, e.g. /data/project=.
+func ParseUriRebases(values []string) ([]UriRebase, error) {
+	rebases := make([]UriRebase, 0, len(values))
+	for _, value := range values {
+		from, to, found := strings.Cut(value, "=")
+		if !found || from == "" {
+			return nil, fmt.Errorf("invalid URI rebase %q, expected the from=to format, e.g. /data/project=.", value)
+		}
+		rebases = append(rebases, UriRebase{From: from, To: to})
+	}
+	return rebases, nil
+}
+
+// RebaseSarifUris rewrites the artifact URIs of the report at sarifPath according to --sarif-rebase-uris values.
+func RebaseSarifUris(sarifPath string, values []string) error {
+	rebases, err := ParseUriRebases(values)
+	if err != nil || len(rebases) == 0 {
+		return err
+	}
+	report, err := ReadReport(sarifPath)
+	if err != nil {
+		return fmt.Errorf("error reading SARIF %s: %w", sarifPath, err)
+	}
+	rebaseReportUris(report, rebases)
+	return WriteReport(sarifPath, report)
+}
+
+func rebaseReportUris(report *sarif.Report, rebases []UriRebase) {
+	rebaseLocations := func(locations []sarif.Location) {
+		for _, location := range locations {
+			if location.PhysicalLocation != nil {
+				rebaseArtifactLocation(location.PhysicalLocation.ArtifactLocation, rebases)
+			}
+		}
+	}
+	for _, run := range report.Runs {
+		for _, result := range run.Results {
+			rebaseLocations(result.Locations)
+			rebaseLocations(result.RelatedLocations)
+		}
+		for _, artifact := range run.Artifacts {
+			rebaseArtifactLocation(artifact.Location, rebases)
+		}
+	}
+}
+
+func rebaseArtifactLocation(location *sarif.ArtifactLocation, rebases []UriRebase) {
+	if location != nil {
+		location.Uri = rebaseUri(location.Uri, rebases)
+	}
+}
+
+// rebaseUri applies the first matching rebase to uri. The prefix matches whole path segments only, so
+// /data/project doesn't match /data/project2. An empty or "." target makes the URI relative.
+func rebaseUri(uri string, rebases []UriRebase) string {
+	const fileScheme = "file://"
+	path := strings.TrimPrefix(uri, fileScheme)
+	for _, rebase := range rebases {
+		from := strings.TrimRight(rebase.From, "/"+string(os.PathSeparator))
+		if from == "" || !strings.HasPrefix(path, from) {
+			continue
+		}
+		rest := path[len(from):]
+		if rest != "" && rest[0] != '/' && rest[0] != os.PathSeparator {
+			continue
+		}
+		rest = strings.TrimLeft(rest, "/"+string(os.PathSeparator))
+		to := strings.TrimRight(rebase.To, "/"+string(os.PathSeparator))
+		switch {
+		case rest == "":
+			return rebase.To
+		case to == "" || to == ".":
+			return rest
+		case strings.HasPrefix(uri, fileScheme):
+			return fileScheme + to + "/" + rest
+		default:
+			return to + "/" + rest
+		}
+	}
+	return uri
+}
+
+// removeDuplicates removes the results with the same fingerprint keeping the first one. With keepHighestSeverity,
+// the most severe of the duplicates is kept instead (the first of the equally severe ones) at the position of the first one.
+func removeDuplicates(results []sarif.Result, keepHighestSeverity bool) []sarif.Result {
 	if len(results) == 0 {
 		return results
 	}
-	seen := make(map[string]struct{}, len(results))
+	seen := make(map[string]int, len(results))
 	writeIndex := 0
 
 	for _, result := range results {
 		if result.PartialFingerprints != nil {
 			fingerPrint := getFingerprint(&result)
 			if fingerPrint != "" {
-				if _, exists := seen[fingerPrint]; exists {
+				if index, exists := seen[fingerPrint]; exists {
+					if keepHighestSeverity && severityRanks[Lower(getSeverity(&result))] > severityRanks[Lower(getSeverity(&results[index]))] {
+						results[index] = result
+					}
 					continue
 				}
-				seen[fingerPrint] = struct{}{}
+				seen[fingerPrint] = writeIndex
 			}
 		}
 		results[writeIndex] = result
@@ -118,7 +211,8 @@ func removeDuplicates(results []sarif.Result) []sarif.Result {
 	return results[:writeIndex]
 }
 
-func WriteReport(path string, finalReport *sarif.Report) error {
+// writeIndentedReport serializes the whole report in memory and writes it to path.
+func writeIndentedReport(path string, finalReport *sarif.Report) error {
 	// serialize object skipping empty fields
 	fatBytes, err := json.MarshalIndent(finalReport, "", " ")
 	if err != nil {
@@ -144,6 +238,138 @@ func WriteReport(path string, finalReport *sarif.Report) error {
 	return nil
 }
 
+// streamedResultsMarker is the empty results array of the first run in the report serialized by writeIndentedReport.
+var streamedResultsMarker = []byte("\n   \"results\": []")
+
+// WriteReport writes the report to path like writeIndentedReport, byte for byte, but encodes the results of the first
+// run one by one into the file instead of serializing the whole report in memory, which matters for huge reports.
+// Every pass rewriting the report goes through it.
+func WriteReport(path string, report *sarif.Report) error {
+	if len(report.Runs) == 0 || len(report.Runs[0].Results) == 0 {
+		return writeIndentedReport(path, report)
+	}
+	results := report.Runs[0].Results
+	header := *report
+	header.Runs = append([]sarif.Run{}, report.Runs...)
+	header.Runs[0].Results = []sarif.Result{}
+	headerBytes, err := json.MarshalIndent(&header, "", " ")
+	if err != nil {
+		return fmt.Errorf("Error marshalling report: %s\n", err)
+	}
+	markerIndex := bytes.Index(headerBytes, streamedResultsMarker)
+	if markerIndex < 0 {
+		return writeIndentedReport(path, report)
+	}
+	resultsStart := markerIndex + len(streamedResultsMarker) - 1 // right after [
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("Error creating resulting SARIF file: %s\n", err)
+	}
+	defer func(f *os.File) {
+		err := f.Close()
+		if err != nil {
+			fmt.Printf("Error closing resulting SARIF file: %s\n", err)
+		}
+	}(f)
+
+	w := bufio.NewWriter(f)
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("    ", " ") // results are at the fourth level of the report
+	_, _ = w.Write(headerBytes[:resultsStart])
+	for i := range results {
+		buf.Reset()
+		if err := encoder.Encode(&results[i]); err != nil {
+			return fmt.Errorf("Error marshalling report: %s\n", err)
+		}
+		if i > 0 {
+			_, _ = w.WriteString(",")
+		}
+		_, _ = w.WriteString("\n    ")
+		_, _ = w.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	}
+	_, _ = w.WriteString("\n   ")
+	_, _ = w.Write(headerBytes[resultsStart:])
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("Error writing resulting SARIF file: %s\n", err)
+	}
+	return nil
+}
+
+// SplitReport writes the results of the SARIF report at sarifPath into several qodana.part-N.sarif.json files
+// next to it if the report exceeds maxSize bytes. Every part contains the same run header and a subset of the results.
+// A result that alone exceeds maxSize is written to a separate part. Returns the paths of the written parts.
+// The parts written by a previous call are removed, so it must be called after the last change of the report.
+func SplitReport(sarifPath string, maxSize int64) ([]string, error) {
+	if maxSize <= 0 {
+		return nil, nil
+	}
+	dir := filepath.Dir(sarifPath)
+	staleParts, err := filepath.Glob(filepath.Join(dir, "qodana.part-*"+extension))
+	if err != nil {
+		return nil, err
+	}
+	for _, part := range staleParts {
+		if err = os.Remove(part); err != nil {
+			return nil, err
+		}
+	}
+	info, err := os.Stat(sarifPath)
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() <= maxSize {
+		return nil, nil
+	}
+	report, err := ReadReport(sarifPath)
+	if err != nil {
+		return nil, err
+	}
+	if len(report.Runs) == 0 {
+		return nil, fmt.Errorf("error reading SARIF %s: no runs found", sarifPath)
+	}
+	results := report.Runs[0].Results
+	report.Runs[0].Results = []sarif.Result{}
+	header, err := json.MarshalIndent(report, "", " ")
+	if err != nil {
+		return nil, fmt.Errorf("Error marshalling report: %s\n", err)
+	}
+
+	var chunks [][]sarif.Result
+	var chunk []sarif.Result
+	size := int64(len(header))
+	for _, result := range results {
+		// results are nested four levels deep in the report, plus the separating comma and newline
+		resultBytes, err := json.MarshalIndent(result, "    ", " ")
+		if err != nil {
+			return nil, fmt.Errorf("Error marshalling result: %s\n", err)
+		}
+		resultSize := int64(len(resultBytes) + 6)
+		if len(chunk) > 0 && size+resultSize > maxSize {
+			chunks = append(chunks, chunk)
+			chunk = nil
+			size = int64(len(header))
+		}
+		chunk = append(chunk, result)
+		size += resultSize
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+
+	parts := make([]string, 0, len(chunks))
+	for i, c := range chunks {
+		report.Runs[0].Results = c
+		part := filepath.Join(dir, fmt.Sprintf("qodana.part-%d%s", i+1, extension))
+		if err = WriteReport(part, report); err != nil {
+			return nil, err
+		}
+		parts = append(parts, part)
+	}
+	return parts, nil
+}
+
 func MakeShortSarif(sarifPath string, shortSarifPath string) error {
 	report, err := ReadReport(sarifPath)
 	if err != nil {
@@ -161,6 +387,22 @@ func MakeShortSarif(sarifPath string, shortSarifPath string) error {
 	return WriteReport(shortSarifPath, report)
 }
 
+// SetInvocationExitCode records exitCode as the exit code of the run in the report at sarifPath.
+func SetInvocationExitCode(sarifPath string, exitCode int) error {
+	report, err := ReadReport(sarifPath)
+	if err != nil {
+		return err
+	}
+	if len(report.Runs) == 0 {
+		return fmt.Errorf("error reading SARIF %s: no runs found", sarifPath)
+	}
+	if len(report.Runs[0].Invocations) == 0 {
+		report.Runs[0].Invocations = []sarif.Invocation{{}}
+	}
+	report.Runs[0].Invocations[0].ExitCode = int64(exitCode)
+	return WriteReport(sarifPath, report)
+}
+
 func SetVersionControlParams(options *QodanaOptions, deviceId string, finalReport *sarif.Report) {
 	linterOptions := options.GetLinterSpecificOptions()
 	if linterOptions == nil {
@@ -202,6 +444,45 @@ func SetVersionControlParams(options *QodanaOptions, deviceId string, finalRepor
 			},
 		},
 	}
+	if options.AnalysisName != "" {
+		finalReport.Runs[0].AutomationDetails.Properties.AdditionalProperties[analysisNameProperty] = options.AnalysisName
+	}
+}
+
+// SetAnalysisName stores the human-friendly analysis name in the automation details of the report at sarifPath.
+func SetAnalysisName(sarifPath string, name string) error {
+	report, err := ReadReport(sarifPath)
+	if err != nil {
+		return err
+	}
+	if len(report.Runs) == 0 {
+		return fmt.Errorf("error reading SARIF %s: no runs found", sarifPath)
+	}
+	run := &report.Runs[0]
+	if run.AutomationDetails == nil {
+		run.AutomationDetails = &sarif.RunAutomationDetails{}
+	}
+	if run.AutomationDetails.Properties == nil {
+		run.AutomationDetails.Properties = &sarif.PropertyBag{}
+	}
+	if run.AutomationDetails.Properties.AdditionalProperties == nil {
+		run.AutomationDetails.Properties.AdditionalProperties = map[string]interface{}{}
+	}
+	run.AutomationDetails.Properties.AdditionalProperties[analysisNameProperty] = name
+	return WriteReport(sarifPath, report)
+}
+
+// getAnalysisName returns the analysis name stored in the report, or an empty string.
+func getAnalysisName(report *sarif.Report) string {
+	if len(report.Runs) == 0 {
+		return ""
+	}
+	details := report.Runs[0].AutomationDetails
+	if details == nil || details.Properties == nil || details.Properties.AdditionalProperties == nil {
+		return ""
+	}
+	name, _ := details.Properties.AdditionalProperties[analysisNameProperty].(string)
+	return name
 }
 
 func findSarifFiles(root string) ([]string, error) {
@@ -221,16 +502,34 @@ func findSarifFiles(root string) ([]string, error) {
 	return files, nil
 }
 
+// collectReports reads the given SARIF files concurrently with at most GOMAXPROCS readers
+// and sends the parsed reports to ch in the same order as files. A reader slot is released only after
+// its report is taken from ch, so at most GOMAXPROCS parsed reports wait in memory.
 func collectReports(files []string, ch chan<- *sarif.Report) {
-	for _, file := range files {
-		r, err := ReadReport(file)
-		if err != nil {
-			fmt.Printf("Error reading SARIF %s: %s\n", file, err)
-			continue
+	defer close(ch)
+	reports := make([]chan *sarif.Report, len(files))
+	for i := range reports {
+		reports[i] = make(chan *sarif.Report, 1)
+	}
+	workers := make(chan struct{}, runtime.GOMAXPROCS(0))
+	go func() {
+		for i, file := range files {
+			workers <- struct{}{}
+			go func(i int, file string) {
+				r, err := ReadReport(file)
+				if err != nil {
+					fmt.Printf("Error reading SARIF %s: %s\n", file, err)
+				}
+				reports[i] <- r
+			}(i, file)
+		}
+	}()
+	for _, report := range reports {
+		if r := <-report; r != nil {
+			ch <- r
 		}
-		ch <- r
+		<-workers
 	}
-	close(ch)
 }
 
 func ReadReport(file string) (*sarif.Report, error) {
@@ -250,6 +549,9 @@ func ReadReport(file string) (*sarif.Report, error) {
 	if err := dec.Decode(&r); err != nil {
 		return nil, err
 	}
+	if err := validateReportVersion(&r); err != nil {
+		return nil, err
+	}
 
 	return &r, nil
 }
@@ -259,14 +561,31 @@ func ReadReportFromString(sarifStr string) (*sarif.Report, error) {
 	if err := json.Unmarshal([]byte(sarifStr), &r); err != nil {
 		return nil, err
 	}
+	if err := validateReportVersion(&r); err != nil {
+		return nil, err
+	}
 
 	return &r, nil
 }
 
+// validateReportVersion checks that the report is SARIF 2.1.0, the reports of other versions have another structure.
+func validateReportVersion(r *sarif.Report) error {
+	if r.Version == nil {
+		return fmt.Errorf("not a SARIF report: no version, expected %s", sarifVersion)
+	}
+	if version, ok := r.Version.(string); !ok || version != sarifVersion {
+		return fmt.Errorf("unsupported SARIF version %v, expected %s", r.Version, sarifVersion)
+	}
+	return nil
+}
+
 func mergeReports(ch <-chan *sarif.Report) (*sarif.Report, error) {
 	var finalReport *sarif.Report
 
 	for r := range ch {
+		if len(r.Runs) == 0 {
+			continue
+		}
 		if finalReport == nil {
 			// For the first file, keep the toolDesc configuration and initialize the 'Runs' slice
 			finalReport = &sarif.Report{
@@ -284,12 +603,60 @@ func mergeReports(ch <-chan *sarif.Report) (*sarif.Report, error) {
 		for _, run := range r.Runs {
 			finalReport.Runs[0].Results = append(finalReport.Runs[0].Results, run.Results...)
 			finalReport.Runs[0].Artifacts = append(finalReport.Runs[0].Artifacts, run.Artifacts...)
+			if run.Tool != nil {
+				if finalReport.Runs[0].Tool == nil {
+					finalReport.Runs[0].Tool = &sarif.Tool{}
+				}
+				mergeToolRules(finalReport.Runs[0].Tool, run.Tool)
+			}
 		}
 	}
+	if finalReport == nil {
+		return nil, errors.New("no runs found in the SARIF files")
+	}
 
 	return finalReport, nil
 }
 
+// mergeToolRules adds the rules of the driver and the extensions of src missing in dst, so the rule descriptions
+// are available whichever report defined the rule. The driver name and version of dst are kept.
+func mergeToolRules(dst *sarif.Tool, src *sarif.Tool) {
+	if src.Driver != nil {
+		if dst.Driver == nil {
+			dst.Driver = &sarif.ToolComponent{Name: src.Driver.Name, Version: src.Driver.Version}
+		}
+		dst.Driver.Rules = appendMissingRules(dst.Driver.Rules, src.Driver.Rules)
+	}
+	for _, extension := range src.Extensions {
+		merged := false
+		for i := range dst.Extensions {
+			if dst.Extensions[i].Name == extension.Name {
+				dst.Extensions[i].Rules = appendMissingRules(dst.Extensions[i].Rules, extension.Rules)
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			dst.Extensions = append(dst.Extensions, extension)
+		}
+	}
+}
+
+// appendMissingRules appends the rules with the ids not present in rules.
+func appendMissingRules(rules []sarif.ReportingDescriptor, other []sarif.ReportingDescriptor) []sarif.ReportingDescriptor {
+	ids := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		ids[rule.Id] = true
+	}
+	for _, rule := range other {
+		if !ids[rule.Id] {
+			ids[rule.Id] = true
+			rules = append(rules, rule)
+		}
+	}
+	return rules
+}
+
 func RunGUID() string {
 	runGUID := os.Getenv("QODANA_AUTOMATION_GUID")
 	if runGUID == "" {
@@ -321,9 +688,16 @@ func JobUrl() string {
 
 func getRuleDescription(report *sarif.Report, ruleId string) string {
 	for _, run := range report.Runs {
-		for _, extension := range run.Tool.Extensions {
-			for _, rule := range extension.Rules {
-				if rule.Id == ruleId {
+		if run.Tool == nil {
+			continue
+		}
+		components := run.Tool.Extensions
+		if run.Tool.Driver != nil {
+			components = append([]sarif.ToolComponent{*run.Tool.Driver}, components...)
+		}
+		for _, component := range components {
+			for _, rule := range component.Rules {
+				if rule.Id == ruleId && rule.ShortDescription != nil {
 					return rule.ShortDescription.Text
 				}
 			}
@@ -332,74 +706,153 @@ func getRuleDescription(report *sarif.Report, ruleId string) string {
 	return ""
 }
 
-// ProcessSarif concludes the result of analysis based on provided SARIF file
-// - can print problems to the output
-// - can create GitLab CodeQuality issues report
-// - can submit problems to BitBucket Code Insights
-func ProcessSarif(sarifPath, analysisId, reportUrl string, printProblems, codeClimate, codeInsights bool) {
-	newProblems := 0
+// ProcessSarifOptions configures the outputs ProcessSarif produces from the report.
+type ProcessSarifOptions struct {
+	ProjectDir       string            // Azure Pipelines annotations use the paths relative to the repository of the project
+	AnalysisId       string            // the id of the BitBucket Code Insights report
+	ReportUrl        string            // the Qodana Cloud report linked from BitBucket Code Insights
+	UriBase          string            // if set, the printed problems and BitBucket annotations link to the files under it
+	PrintProblems    bool              // print the problems to the output
+	CodeClimate      bool              // write the GitLab CodeQuality report next to the SARIF report
+	CodeInsights     bool              // send the BitBucket Code Insights report
+	AzureAnnotations bool              // print the problems as Azure Pipelines logging commands
+	OnlyNew          bool              // skip the absent results too, not only the unchanged ones
+	PrintFormat      *ProblemFormat    // if set, the problems are printed one per line according to the template
+	CollapseRepeated bool              // print consecutive repeated problems as one line
+	ProblemsGroupBy  string            // print the problems in groups, see ProblemsGroupByValues
+	ProblemsLimit    int               // print at most the given number of problems (in each group)
+	Thresholds       map[string]string // the failure thresholds BitBucket Code Insights reports are checked against
+}
+
+// ProcessSarif concludes the result of the analysis from the SARIF report at sarifPath: it prints the problems,
+// writes the GitLab CodeQuality report, sends BitBucket Code Insights and prints Azure Pipelines annotations as set
+// in options. The unchanged results are skipped in all outputs (with OnlyNew the absent ones too), so the outputs
+// show the same new results the problem count and the failure thresholds are based on.
+func ProcessSarif(sarifPath string, options ProcessSarifOptions) {
+	newProblems := newProblemCounts{}
 	s, err := ReadReport(sarifPath)
 	if err != nil {
 		log.Fatal(err)
 	}
+	if len(s.Runs) == 0 {
+		log.Fatalf("error reading SARIF %s: no runs found", sarifPath)
+	}
 	var codeClimateIssues = make([]CCIssue, 0)
 	var codeInsightIssues = make([]bbapi.ReportAnnotation, 0)
 	rulesDescriptions := make(map[string]string)
-	if printProblems {
+	var printedResults []*sarif.Result
+	var azureIssues []string
+	azurePrefix := ""
+	if options.AzureAnnotations {
+		azurePrefix = azureSourcePrefix(options.ProjectDir)
+	}
+	if options.PrintProblems {
 		EmptyMessage()
 	}
 	for _, run := range s.Runs {
 		for _, r := range run.Results {
 			ruleId := r.RuleId
-			message := r.Message.Text
-			baselineState := baselineStateEmpty
-			if r.BaselineState != nil {
-				baselineState = r.BaselineState.(string)
+			isNew := isNewResult(&r)
+			if isNew {
+				newProblems.add(&r)
 			}
-			if baselineState == baselineStateNew || baselineState == baselineStateEmpty {
-				newProblems++
-			}
-			if len(r.Locations) > 0 && baselineState != baselineStateUnchanged {
-				if codeClimate {
+			if len(r.Locations) > 0 && (isNew || !options.OnlyNew && r.BaselineState != baselineStateUnchanged) {
+				if options.CodeClimate {
 					codeClimateIssues = append(codeClimateIssues, sarifResultToCodeClimate(&r))
 				}
-				if codeInsights {
+				if options.CodeInsights {
 					ruleDescription, ok := rulesDescriptions[ruleId]
 					if !ok {
 						ruleDescription = getRuleDescription(s, ruleId)
 						rulesDescriptions[ruleId] = ruleDescription
 					}
-					codeInsightIssues = append(codeInsightIssues, buildAnnotation(&r, ruleDescription, reportUrl))
+					codeInsightIssues = append(codeInsightIssues, buildAnnotation(&r, ruleDescription, options.ReportUrl, options.UriBase))
+				}
+				if options.AzureAnnotations {
+					azureIssues = append(azureIssues, sarifResultToAzureLogIssue(&r, azurePrefix))
 				}
-				if printProblems {
-					printSarifProblem(&r, ruleId, message)
+				if options.PrintProblems {
+					printedResults = append(printedResults, &r)
 				}
 			}
 		}
 	}
-	if codeClimate {
+	printSarifProblems(printedResults, options.PrintFormat, options.CollapseRepeated, options.ProblemsGroupBy, options.ProblemsLimit, options.UriBase)
+	for _, issue := range azureIssues {
+		fmt.Println(issue)
+	}
+	if options.CodeClimate {
 		err = writeGlCodeQualityReport(codeClimateIssues, sarifPath)
 		if err != nil {
 			log.Warnf("Problems writing GitLab CodeQuality report: %v", err)
 		}
 	}
-	if codeInsights {
-		err = sendBitBucketReport(codeInsightIssues, s.Runs[0].Tool.Driver.FullName, reportUrl, "qodana-"+analysisId)
+	if options.CodeInsights {
+		failed, err := newProblems.exceeds(options.Thresholds)
+		if err != nil {
+			log.Warnf("Problems checking the fail thresholds for BitBucket Code Insights report: %v", err)
+		}
+		err = sendBitBucketReport(codeInsightIssues, newProblems[severityAny], failed, s.Runs[0].Tool.Driver.FullName, options.ReportUrl, "qodana-"+options.AnalysisId)
 		if err != nil {
 			log.Warnf("Problems sending BitBucket Code Insights report: %v", err)
 		}
 	}
 	if !IsContainer() {
-		if newProblems == 0 {
+		if name := getAnalysisName(s); name != "" {
+			SuccessMessage("Analysis: %s", name)
+		}
+		if newProblems[severityAny] == 0 {
 			SuccessMessage(getProblemsFoundMessage(0))
 		} else {
-			ErrorMessage(getProblemsFoundMessage(newProblems))
+			ErrorMessage(getProblemsFoundMessage(newProblems[severityAny]))
 		}
 	}
 }
 
+// isNewResult reports whether the result is new, i.e. not present in the baseline or analyzed without a baseline.
+func isNewResult(r *sarif.Result) bool {
+	return r.BaselineState == nil || r.BaselineState == baselineStateNew || r.BaselineState == baselineStateEmpty
+}
+
+// GetRuntimeNotifications returns the error notifications collected during the analysis
+// (the reason of QodanaInternalErrorExitCode) from the invocations of the report at sarifPath.
+func GetRuntimeNotifications(sarifPath string) ([]string, error) {
+	report, err := ReadReport(sarifPath)
+	if err != nil {
+		return nil, err
+	}
+	notifications := make([]string, 0)
+	for _, run := range report.Runs {
+		for _, invocation := range run.Invocations {
+			for _, n := range invocation.ToolExecutionNotifications {
+				if level, _ := n.Level.(string); level != sarifError {
+					continue
+				}
+				message := ""
+				if n.Message != nil {
+					message = n.Message.Text
+				}
+				if n.Exception != nil {
+					message = strings.TrimSpace(fmt.Sprintf("%s %s: %s", message, n.Exception.Kind, n.Exception.Message))
+				}
+				notifications = append(notifications, message)
+			}
+		}
+	}
+	return notifications, nil
+}
+
 // getFingerprint returns the fingerprint of the Qodana (or not) SARIF result.
 func getFingerprint(r *sarif.Result) string {
+	fingerprint := findFingerprint(r)
+	if fingerprint == "" {
+		log.Fatalf("failed to get fingerprint from result: %v", r)
+	}
+	return fingerprint
+}
+
+// findFingerprint returns the Qodana fingerprint of the result or an empty string if there is none.
+func findFingerprint(r *sarif.Result) string {
 	if r != nil && r.PartialFingerprints != nil {
 		fingerprint, ok := r.PartialFingerprints["equalIndicator/v2"]
 		if ok {
@@ -411,7 +864,6 @@ func getFingerprint(r *sarif.Result) string {
 			}
 		}
 	}
-	log.Fatalf("failed to get fingerprint from result: %v", r)
 	return ""
 }
 
@@ -18,8 +18,11 @@ package platform
 
 import (
 	"errors"
+	"fmt"
 	"github.com/JetBrains/qodana-cli/v2024/sarif"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
 )
 
@@ -118,3 +121,51 @@ func getAuthorEmail(pwd string) string {
 	}
 	return strings.TrimSpace(email)
 }
+
+// ValidateUriBase checks that the --uri-base value is an http(s) URL.
+func ValidateUriBase(uriBase string) error {
+	if uriBase == "" {
+		return nil
+	}
+	if u, err := url.Parse(uriBase); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid --uri-base %q, expected an http(s) URL", uriBase)
+	}
+	return nil
+}
+
+// ResolveUriBase returns the --uri-base value with {revision} and {branch} replaced by the version control details of pwd,
+// e.g. https://github.com/org/repo/blob/{revision} becomes the link to the analyzed revision.
+func ResolveUriBase(uriBase string, pwd string) (string, error) {
+	if err := ValidateUriBase(uriBase); err != nil || uriBase == "" {
+		return "", err
+	}
+	if !strings.Contains(uriBase, "{revision}") && !strings.Contains(uriBase, "{branch}") {
+		return uriBase, nil
+	}
+	details, err := GetVersionDetails(pwd)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the version control details for --uri-base: %w", err)
+	}
+	return strings.NewReplacer("{revision}", details.RevisionId, "{branch}", details.Branch).Replace(uriBase), nil
+}
+
+// sourceLink returns the link to the line of the file at the project-relative path, empty if uriBase or path is not set.
+func sourceLink(uriBase string, path string, line int) string {
+	if uriBase == "" || path == "" {
+		return ""
+	}
+	link := strings.TrimSuffix(uriBase, "/") + "/" + (&url.URL{Path: strings.TrimPrefix(path, "/")}).EscapedPath()
+	if line > 0 {
+		link += "#L" + strconv.Itoa(line)
+	}
+	return link
+}
+
+// resultSourceLink returns the link to the location of the result, empty if uriBase is not set.
+func resultSourceLink(uriBase string, r *sarif.Result) string {
+	location := extractLocationProperties(r)
+	if location == nil {
+		return ""
+	}
+	return sourceLink(uriBase, location.Uri, location.StartLine)
+}
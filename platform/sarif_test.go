@@ -17,8 +17,13 @@
 package platform
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/JetBrains/qodana-cli/v2024/sarif"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"testing"
 )
@@ -125,6 +130,509 @@ func TestMergeSarifReports(t *testing.T) {
 	}
 }
 
+func TestMergeReportsRules(t *testing.T) {
+	var reports []*sarif.Report
+	for _, data := range []string{
+		`{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "clang-tidy", "version": "17", "rules": [
+{"id": "bugprone-macro-parentheses", "shortDescription": {"text": "Macro parentheses"}}]}}, "results": []}]}`,
+		`{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "clang-tidy", "version": "18", "rules": [
+{"id": "bugprone-macro-parentheses", "shortDescription": {"text": "Duplicate"}},
+{"id": "cert-err58-cpp", "shortDescription": {"text": "Static exceptions"}}]},
+"extensions": [{"name": "misc", "rules": [{"id": "misc-unused", "shortDescription": {"text": "Unused"}}]}]}, "results": []}]}`,
+	} {
+		report, err := ReadReportFromString(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		reports = append(reports, report)
+	}
+	ch := make(chan *sarif.Report)
+	go func() {
+		for _, report := range reports {
+			ch <- report
+		}
+		close(ch)
+	}()
+	merged, err := mergeReports(ch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	driver := merged.Runs[0].Tool.Driver
+	if driver.Version != "17" || len(driver.Rules) != 2 {
+		t.Errorf("expected the first driver with 2 rules, got version %s with %d rules", driver.Version, len(driver.Rules))
+	}
+	for ruleId, expected := range map[string]string{
+		"bugprone-macro-parentheses": "Macro parentheses",
+		"cert-err58-cpp":             "Static exceptions",
+		"misc-unused":                "Unused",
+		"unknown":                    "",
+	} {
+		if actual := getRuleDescription(merged, ruleId); actual != expected {
+			t.Errorf("rule %s: expected description %q, got %q", ruleId, expected, actual)
+		}
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	report, err := ReadReport(filepath.Join("testdata", "merged.qodana.sarif.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	withTwoRuns := *report
+	withTwoRuns.Runs = append([]sarif.Run{}, report.Runs...)
+	withTwoRuns.Runs = append(withTwoRuns.Runs, report.Runs[0])
+	withoutResults := *report
+	withoutResults.Runs = append([]sarif.Run{}, report.Runs...)
+	withoutResults.Runs[0].Results = nil
+
+	for name, r := range map[string]*sarif.Report{"merged": report, "two runs": &withTwoRuns, "no results": &withoutResults} {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			expectedPath := filepath.Join(dir, "expected.sarif.json")
+			actualPath := filepath.Join(dir, "actual.sarif.json")
+			if err := writeIndentedReport(expectedPath, r); err != nil {
+				t.Fatal(err)
+			}
+			if err := WriteReport(actualPath, r); err != nil {
+				t.Fatal(err)
+			}
+			expected, err := os.ReadFile(expectedPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual, err := os.ReadFile(actualPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(expected, actual) {
+				t.Fatalf("the streamed report differs from the indented one: %d vs %d bytes", len(actual), len(expected))
+			}
+		})
+	}
+}
+
+func TestSetVersionControlParamsAnalysisName(t *testing.T) {
+	opts := DefineOptions(func() ThirdPartyOptions {
+		return &TestOptions{
+			linterInfo: &LinterInfo{
+				ProductCode: "QDCL",
+				LinterName:  "Qodana for C/C++ (CMake)",
+			},
+		}
+	})
+	opts.ProjectDir = t.TempDir()
+	opts.AnalysisName = "nightly main"
+	report := &sarif.Report{Runs: []sarif.Run{{Tool: &sarif.Tool{Driver: &sarif.ToolComponent{}}}}}
+
+	SetVersionControlParams(opts, "", report)
+	if name := getAnalysisName(report); name != "nightly main" {
+		t.Errorf("expected analysis name %q in the report properties, got %q", "nightly main", name)
+	}
+	if report.Runs[0].AutomationDetails.Guid == "" {
+		t.Error("analysis name must not replace the run GUID")
+	}
+
+	sarifPath := filepath.Join(t.TempDir(), QodanaSarifName)
+	if err := WriteReport(sarifPath, &sarif.Report{Version: "2.1.0", Runs: []sarif.Run{{Tool: &sarif.Tool{Driver: &sarif.ToolComponent{}}}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetAnalysisName(sarifPath, "release 1.0"); err != nil {
+		t.Fatal(err)
+	}
+	actual, err := ReadReport(sarifPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name := getAnalysisName(actual); name != "release 1.0" {
+		t.Errorf("expected analysis name %q in the report properties, got %q", "release 1.0", name)
+	}
+}
+
+func TestGetRuntimeNotifications(t *testing.T) {
+	sarifPath := filepath.Join(t.TempDir(), QodanaShortSarifName)
+	report := `{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "QDJVM"}}, "invocations": [{"exitCode": 70, "executionSuccessful": false,
+ "toolExecutionNotifications": [
+  {"level": "error", "message": {"text": "Inspection 'ConstantValue' failed"}, "exception": {"kind": "java.lang.IllegalStateException", "message": "Unexpected element"}},
+  {"level": "warning", "message": {"text": "Slow inspection"}},
+  {"level": "error", "message": {"text": "Failed to resolve the project SDK"}}
+ ]}], "results": []}]}`
+	if err := os.WriteFile(sarifPath, []byte(report), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	notifications, err := GetRuntimeNotifications(sarifPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"Inspection 'ConstantValue' failed java.lang.IllegalStateException: Unexpected element",
+		"Failed to resolve the project SDK",
+	}
+	if strings.Join(notifications, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("expected %v, got %v", expected, notifications)
+	}
+	actual, err := ReadReport(sarifPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code := actual.Runs[0].Invocations[0].ExitCode; code != QodanaInternalErrorExitCode {
+		t.Errorf("expected exit code %d, got %d", QodanaInternalErrorExitCode, code)
+	}
+}
+
+func TestSplitReport(t *testing.T) {
+	dir := t.TempDir()
+	sarifPath := filepath.Join(dir, QodanaSarifName)
+	if parts, err := SplitReport(sarifPath, 0); err != nil || len(parts) != 0 {
+		t.Fatalf("Expected a missing report not to be split without a limit, got %v, %v", parts, err)
+	}
+	longText := strings.Repeat("x", 1000)
+	report := &sarif.Report{
+		Version: "2.1.0",
+		Runs: []sarif.Run{{
+			Tool: &sarif.Tool{Driver: &sarif.ToolComponent{Name: "QDJVM"}},
+			Results: []sarif.Result{
+				{RuleId: "First", Message: &sarif.Message{Text: longText}},
+				{RuleId: "Second", Message: &sarif.Message{Text: longText}},
+			},
+		}},
+	}
+	if err := WriteReport(sarifPath, report); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(sarifPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts, err := SplitReport(sarifPath, info.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) != 0 {
+		t.Fatalf("Expected no parts for a report within the limit, got %v", parts)
+	}
+
+	maxSize := info.Size() - 500
+	parts, err = SplitReport(sarifPath, maxSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) != 2 {
+		t.Fatalf("Expected 2 parts, got %v", parts)
+	}
+	for i, part := range parts {
+		if filepath.Base(part) != fmt.Sprintf("qodana.part-%d.sarif.json", i+1) {
+			t.Errorf("Unexpected part name %s", part)
+		}
+		partInfo, err := os.Stat(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if partInfo.Size() > maxSize {
+			t.Errorf("Part %s exceeds the limit: %d > %d", part, partInfo.Size(), maxSize)
+		}
+		partReport, err := ReadReport(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if partReport.Runs[0].Tool.Driver.Name != "QDJVM" || len(partReport.Runs[0].Results) != 1 {
+			t.Errorf("Unexpected content of part %s", part)
+		}
+	}
+
+	// the report has changed and fits the limit now, the parts of the previous split are removed
+	parts, err = SplitReport(sarifPath, info.Size())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(parts) != 0 {
+		t.Fatalf("Expected no parts for a report within the limit, got %v", parts)
+	}
+	if stale, _ := filepath.Glob(filepath.Join(dir, "qodana.part-*.sarif.json")); len(stale) != 0 {
+		t.Errorf("Expected the stale parts to be removed, got %v", stale)
+	}
+}
+
+func BenchmarkCollectReports(b *testing.B) {
+	workingDir, err := os.Getwd()
+	if err != nil {
+		b.Fatal(err)
+	}
+	report, err := os.ReadFile(filepath.Join(workingDir, "testdata", "merge", "qodana.sarif.json"))
+	if err != nil {
+		b.Fatal(err)
+	}
+	dir := b.TempDir()
+	files := make([]string, 0, 64)
+	for i := 0; i < cap(files); i++ {
+		file := filepath.Join(dir, fmt.Sprintf("%03d%s", i, extension))
+		if err := os.WriteFile(file, report, 0o600); err != nil {
+			b.Fatal(err)
+		}
+		files = append(files, file)
+	}
+
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			for _, file := range files {
+				if _, err := ReadReport(file); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	})
+	b.Run("concurrent", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ch := make(chan *sarif.Report)
+			go collectReports(files, ch)
+			for range ch {
+			}
+		}
+	})
+}
+
 func normalize(s string) string {
 	return strings.NewReplacer("\r\n", "\n", "\r", "\n").Replace(s)
 }
+
+func TestRebaseUri(t *testing.T) {
+	rebases, err := ParseUriRebases([]string{"/data/project=.", "/data/cache=/home/user/.cache/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		uri      string
+		expected string
+	}{
+		{"/data/project/src/main.go", "src/main.go"},
+		{"file:///data/project/src/main.go", "src/main.go"},
+		{"/data/project2/src/main.go", "/data/project2/src/main.go"},
+		{"/data/cache/lib/a.jar", "/home/user/.cache/lib/a.jar"},
+		{"file:///data/cache/lib/a.jar", "file:///home/user/.cache/lib/a.jar"},
+		{"src/main.go", "src/main.go"},
+	} {
+		t.Run(tc.uri, func(t *testing.T) {
+			if actual := rebaseUri(tc.uri, rebases); actual != tc.expected {
+				t.Errorf("expected %q, got %q", tc.expected, actual)
+			}
+		})
+	}
+
+	if _, err := ParseUriRebases([]string{"/data/project"}); err == nil {
+		t.Error("expected an error for a rebase without '='")
+	}
+}
+
+func TestRebaseSarifUris(t *testing.T) {
+	sarifPath := filepath.Join(t.TempDir(), "qodana.sarif.json")
+	report := &sarif.Report{Version: "2.1.0", Runs: []sarif.Run{{
+		Results: []sarif.Result{{
+			Locations: []sarif.Location{{
+				PhysicalLocation: &sarif.PhysicalLocation{
+					ArtifactLocation: &sarif.ArtifactLocation{Uri: "/data/project/src/main.go"},
+				},
+			}},
+		}},
+	}}}
+	if err := WriteReport(sarifPath, report); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := RebaseSarifUris(sarifPath, []string{"/data/project=."}); err != nil {
+		t.Fatal(err)
+	}
+
+	rebased, err := ReadReport(sarifPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	uri := rebased.Runs[0].Results[0].Locations[0].PhysicalLocation.ArtifactLocation.Uri
+	if uri != "src/main.go" {
+		t.Errorf("expected the URI to be rebased to src/main.go, got %q", uri)
+	}
+}
+
+func TestApplySeverityOverrides(t *testing.T) {
+	sarifPath := filepath.Join(t.TempDir(), "qodana.sarif.json")
+	report := &sarif.Report{Version: "2.1.0", Runs: []sarif.Run{{
+		Results: []sarif.Result{
+			{RuleId: "UnusedImport", Properties: &sarif.PropertyBag{AdditionalProperties: map[string]interface{}{"qodanaSeverity": qodanaHigh}}},
+			{RuleId: "ConstantValue", Level: sarifError},
+			{RuleId: "DuplicatedCode", Properties: &sarif.PropertyBag{AdditionalProperties: map[string]interface{}{"qodanaSeverity": qodanaModerate}}},
+		},
+	}}}
+	if err := WriteReport(sarifPath, report); err != nil {
+		t.Fatal(err)
+	}
+
+	changed, err := ApplySeverityOverrides(sarifPath, map[string]string{"UnusedImport": "info", "ConstantValue": "Critical", "DuplicatedCode": "moderate"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed != 2 {
+		t.Errorf("expected 2 changed results, got %d", changed)
+	}
+
+	remapped, err := ReadReport(sarifPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{qodanaInfo, qodanaCritical, qodanaModerate}
+	for i, r := range remapped.Runs[0].Results {
+		if severity := getSeverity(&r); severity != expected[i] {
+			t.Errorf("%s: expected severity %s, got %s", r.RuleId, expected[i], severity)
+		}
+	}
+}
+
+func TestSourceLink(t *testing.T) {
+	t.Setenv("QODANA_REMOTE_URL", "https://github.com/org/repo.git")
+	t.Setenv("QODANA_BRANCH", "main")
+	t.Setenv("QODANA_REVISION", "0123abc")
+	uriBase, err := ResolveUriBase("https://github.com/org/repo/blob/{revision}/", t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uriBase != "https://github.com/org/repo/blob/0123abc/" {
+		t.Fatalf("unexpected resolved URI base %q", uriBase)
+	}
+
+	for _, testData := range []struct {
+		uriBase  string
+		path     string
+		line     int
+		expected string
+	}{
+		{uriBase, "src/main.go", 12, "https://github.com/org/repo/blob/0123abc/src/main.go#L12"},
+		{uriBase, "docs/read me.md", 0, "https://github.com/org/repo/blob/0123abc/docs/read%20me.md"},
+		{uriBase, "", 3, ""},
+		{"", "src/main.go", 12, ""},
+	} {
+		if actual := sourceLink(testData.uriBase, testData.path, testData.line); actual != testData.expected {
+			t.Errorf("expected %q, got %q", testData.expected, actual)
+		}
+	}
+
+	for _, invalid := range []string{"github.com/org/repo", "file:///tmp/repo", "https://"} {
+		if _, err := ResolveUriBase(invalid, "."); err == nil {
+			t.Errorf("expected an error for %q", invalid)
+		}
+	}
+}
+
+func TestRemoveDuplicates(t *testing.T) {
+	report, err := ReadReportFromString(`{"version": "2.1.0", "runs": [{"results": [
+{"ruleId": "A", "message": {"text": "first"}, "properties": {"qodanaSeverity": "Moderate"}, "partialFingerprints": {"equalIndicator/v1": "1"}},
+{"ruleId": "B", "message": {"text": "unique"}, "properties": {"qodanaSeverity": "Low"}, "partialFingerprints": {"equalIndicator/v1": "2"}},
+{"ruleId": "A", "message": {"text": "critical duplicate"}, "properties": {"qodanaSeverity": "Critical"}, "partialFingerprints": {"equalIndicator/v1": "1"}},
+{"ruleId": "A", "message": {"text": "high duplicate"}, "properties": {"qodanaSeverity": "High"}, "partialFingerprints": {"equalIndicator/v1": "1"}}
+]}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := func(results []sarif.Result) string {
+		var texts []string
+		for _, r := range results {
+			texts = append(texts, r.Message.Text)
+		}
+		return strings.Join(texts, ", ")
+	}
+
+	for _, testData := range []struct {
+		keepHighestSeverity bool
+		expected            string
+	}{
+		{false, "first, unique"},
+		{true, "critical duplicate, unique"},
+	} {
+		results := append([]sarif.Result{}, report.Runs[0].Results...)
+		if actual := messages(removeDuplicates(results, testData.keepHighestSeverity)); actual != testData.expected {
+			t.Errorf("keepHighestSeverity=%v: expected %q, got %q", testData.keepHighestSeverity, testData.expected, actual)
+		}
+	}
+}
+
+func TestProcessSarifOnlyNew(t *testing.T) {
+	location := `"locations": [{"physicalLocation": {"artifactLocation": {"uri": "src/Main.java"}, "region": {"startLine": 1}}}]`
+	report := `{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "QDJVM"}}, "results": [
+{"ruleId": "New", "message": {"text": "new"}, "partialFingerprints": {"equalIndicator/v1": "a"}, "baselineState": "new", ` + location + `},
+{"ruleId": "NoBaseline", "message": {"text": "no baseline"}, "partialFingerprints": {"equalIndicator/v1": "b"}, ` + location + `},
+{"ruleId": "Unchanged", "message": {"text": "unchanged"}, "partialFingerprints": {"equalIndicator/v1": "c"}, "baselineState": "unchanged", ` + location + `},
+{"ruleId": "Absent", "message": {"text": "absent"}, "partialFingerprints": {"equalIndicator/v1": "d"}, "baselineState": "absent", ` + location + `}
+]}]}`
+	for _, tc := range []struct {
+		onlyNew  bool
+		expected []string
+	}{
+		{false, []string{"Absent", "New", "NoBaseline"}},
+		{true, []string{"New", "NoBaseline"}},
+	} {
+		sarifPath := filepath.Join(t.TempDir(), QodanaSarifName)
+		if err := os.WriteFile(sarifPath, []byte(report), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		ProcessSarif(sarifPath, ProcessSarifOptions{CodeClimate: true, OnlyNew: tc.onlyNew})
+
+		content, err := os.ReadFile(filepath.Join(filepath.Dir(sarifPath), glCodeQualityReport))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var issues []CCIssue
+		if err = json.Unmarshal(content, &issues); err != nil {
+			t.Fatal(err)
+		}
+		var checks []string
+		for _, issue := range issues {
+			checks = append(checks, issue.CheckName)
+		}
+		sort.Strings(checks)
+		if strings.Join(checks, ",") != strings.Join(tc.expected, ",") {
+			t.Errorf("onlyNew=%v: expected %v, got %v", tc.onlyNew, tc.expected, checks)
+		}
+	}
+}
+
+func TestReadReportVersion(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		report string
+		valid  bool
+	}{
+		{"2.1.0", `{"version": "2.1.0", "runs": []}`, true},
+		{"wrong version", `{"version": "2.0.0", "runs": [{"tool": {"driver": {"name": "QDJVM"}}}]}`, false},
+		{"numeric version", `{"version": 2.1, "runs": []}`, false},
+		{"no version", `{"runs": []}`, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			sarifPath := filepath.Join(t.TempDir(), QodanaSarifName)
+			if err := os.WriteFile(sarifPath, []byte(tc.report), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := ReadReport(sarifPath); (err == nil) != tc.valid {
+				t.Errorf("ReadReport: expected valid=%v, got %v", tc.valid, err)
+			}
+			if _, err := ReadReportFromString(tc.report); (err == nil) != tc.valid {
+				t.Errorf("ReadReportFromString: expected valid=%v, got %v", tc.valid, err)
+			}
+		})
+	}
+}
+
+func TestEmptyRunsReport(t *testing.T) {
+	dir := t.TempDir()
+	sarifPath := filepath.Join(dir, QodanaSarifName)
+	if err := os.WriteFile(sarifPath, []byte(`{"version": "2.1.0", "runs": []}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := MakeShortSarif(sarifPath, filepath.Join(dir, QodanaShortSarifName)); err == nil {
+		t.Error("MakeShortSarif: expected an error for a report without runs")
+	}
+
+	ch := make(chan *sarif.Report)
+	go collectReports([]string{sarifPath}, ch)
+	if _, err := mergeReports(ch); err == nil {
+		t.Error("mergeReports: expected an error for reports without runs")
+	}
+}
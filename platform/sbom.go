@@ -0,0 +1,63 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+const (
+	SbomFormatSpdx      = "spdx"
+	SbomFormatCycloneDx = "cyclonedx"
+)
+
+// sbomLinters lists the linters that can produce an SBOM in the given format:
+// both SPDX and CycloneDX are generated by the IntelliJ-based dependency analysis
+// (JVM, JS, Python, PHP and Go linters), .NET linters support only CycloneDX,
+// other linters ignore the SBOM options.
+var sbomLinters = map[string][]string{
+	SbomFormatSpdx:      {QDJVM, QDJS, QDPY, QDPHP, QDGO},
+	SbomFormatCycloneDx: {QDJVM, QDJS, QDPY, QDPHP, QDGO, QDNET},
+}
+
+// ValidateSbomFormat checks that --sbom-format is one of the supported formats.
+func ValidateSbomFormat(format string) error {
+	if format == "" {
+		return nil
+	}
+	if _, ok := sbomLinters[Lower(format)]; !ok {
+		return fmt.Errorf("unknown SBOM format %q, expected one of: %s, %s", format, SbomFormatSpdx, SbomFormatCycloneDx)
+	}
+	return nil
+}
+
+// SbomSupported returns true if the linter with the given product code can produce the SBOM in the given format.
+func SbomSupported(code string, format string) bool {
+	if format == "" {
+		format = SbomFormatSpdx
+	}
+	return Contains(sbomLinters[Lower(format)], code)
+}
+
+// SbomOutputPath returns the SBOM output path, relative paths are resolved against the results directory.
+func (o *QodanaOptions) SbomOutputPath() string {
+	if o.SbomOutput == "" || filepath.IsAbs(o.SbomOutput) {
+		return o.SbomOutput
+	}
+	return filepath.Join(o.ResultsDir, o.SbomOutput)
+}
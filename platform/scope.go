@@ -0,0 +1,140 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// ValidateScopeGlobs checks the --scope-glob patterns.
+func ValidateScopeGlobs(globs []string) error {
+	for _, glob := range globs {
+		for _, segment := range strings.Split(glob, "/") {
+			if _, err := path.Match(segment, ""); err != nil {
+				return fmt.Errorf("invalid --scope-glob %q: %w", glob, err)
+			}
+		}
+	}
+	return nil
+}
+
+// MatchScopeGlob checks if the slash-separated path relative to the project directory matches the glob.
+// Besides the path.Match syntax, a ** segment matches any number of directories.
+func MatchScopeGlob(glob string, file string) bool {
+	return matchGlobSegments(strings.Split(strings.TrimPrefix(glob, "./"), "/"), strings.Split(file, "/"))
+}
+
+func matchGlobSegments(glob []string, segments []string) bool {
+	for len(glob) > 0 {
+		if glob[0] == "**" {
+			for i := 0; i <= len(segments); i++ {
+				if matchGlobSegments(glob[1:], segments[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(segments) == 0 {
+			return false
+		}
+		if matched, _ := path.Match(glob[0], segments[0]); !matched {
+			return false
+		}
+		glob, segments = glob[1:], segments[1:]
+	}
+	return len(segments) == 0
+}
+
+func matchesAnyScopeGlob(globs []string, file string) bool {
+	for _, glob := range globs {
+		if MatchScopeGlob(glob, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// GlobScopeFiles returns the files of projectDir matching any of the globs as a scope of whole-file changes.
+func GlobScopeFiles(projectDir string, globs []string) (ChangedFiles, error) {
+	absProjectDir, err := computeAbsPath(projectDir)
+	if err != nil {
+		return ChangedFiles{}, err
+	}
+	files := make([]*ChangedFile, 0)
+	err = filepath.WalkDir(absProjectDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(absProjectDir, p)
+		if err != nil || !matchesAnyScopeGlob(globs, filepath.ToSlash(rel)) {
+			return err
+		}
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		files = append(files, &ChangedFile{
+			Path:    p,
+			Added:   []*ChangedRegion{{FirstLine: 1, Count: countLines(data)}},
+			Deleted: []*ChangedRegion{},
+		})
+		return nil
+	})
+	if err != nil {
+		return ChangedFiles{}, err
+	}
+	if len(files) == 0 {
+		return ChangedFiles{}, fmt.Errorf("no files in %s match --scope-glob %s", projectDir, strings.Join(globs, ", "))
+	}
+	return ChangedFiles{Files: files}, nil
+}
+
+// FilterChangedFiles keeps only the changed files under projectDir matching any of the globs.
+func FilterChangedFiles(changes ChangedFiles, projectDir string, globs []string) (ChangedFiles, error) {
+	absProjectDir, err := computeAbsPath(projectDir)
+	if err != nil {
+		return ChangedFiles{}, err
+	}
+	files := make([]*ChangedFile, 0, len(changes.Files))
+	for _, file := range changes.Files {
+		rel, err := filepath.Rel(absProjectDir, file.Path)
+		if err == nil && matchesAnyScopeGlob(globs, filepath.ToSlash(rel)) {
+			files = append(files, file)
+		}
+	}
+	return ChangedFiles{Files: files}, nil
+}
+
+func countLines(data []byte) int {
+	lines := bytes.Count(data, []byte("\n"))
+	if len(data) == 0 || data[len(data)-1] != '\n' {
+		lines++
+	}
+	return lines
+}
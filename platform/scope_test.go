@@ -0,0 +1,103 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMatchScopeGlob(t *testing.T) {
+	for _, tc := range []struct {
+		glob     string
+		file     string
+		expected bool
+	}{
+		{glob: "src/**/*.kt", file: "src/Main.kt", expected: true},
+		{glob: "src/**/*.kt", file: "src/main/kotlin/Main.kt", expected: true},
+		{glob: "./src/**/*.kt", file: "src/main/Main.kt", expected: true},
+		{glob: "src/**/*.kt", file: "test/Main.kt", expected: false},
+		{glob: "src/**/*.kt", file: "src/Main.java", expected: false},
+		{glob: "**/*.kt", file: "Main.kt", expected: true},
+		{glob: "*.kt", file: "src/Main.kt", expected: false},
+		{glob: "src/**", file: "src/a/b/c.txt", expected: true},
+		{glob: "src/*/Main.kt", file: "src/a/b/Main.kt", expected: false},
+	} {
+		t.Run(tc.glob+" "+tc.file, func(t *testing.T) {
+			if actual := MatchScopeGlob(tc.glob, tc.file); actual != tc.expected {
+				t.Errorf("MatchScopeGlob(%q, %q) = %v, expected %v", tc.glob, tc.file, actual, tc.expected)
+			}
+		})
+	}
+}
+
+func TestValidateScopeGlobs(t *testing.T) {
+	if err := ValidateScopeGlobs([]string{"src/**/*.kt", "*.java"}); err != nil {
+		t.Errorf("ValidateScopeGlobs() unexpected error: %v", err)
+	}
+	if err := ValidateScopeGlobs([]string{"src/[a-/*.kt"}); err == nil {
+		t.Error("ValidateScopeGlobs() expected an error for a malformed glob")
+	}
+}
+
+func TestGlobScopeFiles(t *testing.T) {
+	projectDir := t.TempDir()
+	for name, content := range map[string]string{
+		"src/main/Main.kt":  "fun main() {\n}\n",
+		"src/main/Util.kt":  "val x = 1",
+		"src/main/App.java": "class App {}\n",
+		".git/config.kt":    "",
+	} {
+		p := filepath.Join(projectDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	absProjectDir, err := computeAbsPath(projectDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	scope, err := GlobScopeFiles(projectDir, []string{"**/*.kt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := ChangedFiles{Files: []*ChangedFile{
+		{Path: filepath.Join(absProjectDir, "src", "main", "Main.kt"), Added: []*ChangedRegion{{FirstLine: 1, Count: 2}}, Deleted: []*ChangedRegion{}},
+		{Path: filepath.Join(absProjectDir, "src", "main", "Util.kt"), Added: []*ChangedRegion{{FirstLine: 1, Count: 1}}, Deleted: []*ChangedRegion{}},
+	}}
+	if !reflect.DeepEqual(scope, expected) {
+		t.Errorf("GlobScopeFiles() = %+v, expected %+v", scope, expected)
+	}
+
+	filtered, err := FilterChangedFiles(scope, projectDir, []string{"**/Util.kt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(filtered.Files) != 1 || filtered.Files[0] != scope.Files[1] {
+		t.Errorf("FilterChangedFiles() = %+v, expected only Util.kt", filtered)
+	}
+
+	if _, err := GlobScopeFiles(projectDir, []string{"**/*.py"}); err == nil {
+		t.Error("GlobScopeFiles() expected an error when no files match")
+	}
+}
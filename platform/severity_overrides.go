@@ -0,0 +1,84 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/JetBrains/qodana-cli/v2024/sarif"
+)
+
+// qodanaSeverities maps the lowercase severity names to the qodanaSeverity values used in SARIF reports.
+var qodanaSeverities = map[string]string{
+	severityCritical: qodanaCritical,
+	severityHigh:     qodanaHigh,
+	severityModerate: qodanaModerate,
+	severityLow:      qodanaLow,
+	severityInfo:     qodanaInfo,
+}
+
+// ValidateSeverityOverrides checks that every severityOverrides value is a known Qodana severity.
+func (q *QodanaYaml) ValidateSeverityOverrides() error {
+	ruleIds := make([]string, 0, len(q.SeverityOverrides))
+	for ruleId := range q.SeverityOverrides {
+		ruleIds = append(ruleIds, ruleId)
+	}
+	sort.Strings(ruleIds)
+	for _, ruleId := range ruleIds {
+		severity := q.SeverityOverrides[ruleId]
+		if _, ok := qodanaSeverities[Lower(severity)]; !ok {
+			return fmt.Errorf("unknown severity %q for rule %s in severityOverrides, expected one of: Critical, High, Moderate, Low, Info", severity, ruleId)
+		}
+	}
+	return nil
+}
+
+// ApplySeverityOverrides rewrites the qodanaSeverity property of the results whose rule is listed in overrides (rule id -> severity).
+// Everything produced from the report afterward (printed problems, CodeClimate, BitBucket annotations) uses the new severity.
+// Returns the number of results whose severity was changed.
+func ApplySeverityOverrides(sarifPath string, overrides map[string]string) (int, error) {
+	if len(overrides) == 0 {
+		return 0, nil
+	}
+	report, err := ReadReport(sarifPath)
+	if err != nil {
+		return 0, err
+	}
+	changed := 0
+	for i := range report.Runs {
+		for j := range report.Runs[i].Results {
+			r := &report.Runs[i].Results[j]
+			severity, ok := qodanaSeverities[Lower(overrides[r.RuleId])]
+			if !ok || getSeverity(r) == severity {
+				continue
+			}
+			changed++
+			if r.Properties == nil {
+				r.Properties = &sarif.PropertyBag{}
+			}
+			if r.Properties.AdditionalProperties == nil {
+				r.Properties.AdditionalProperties = map[string]interface{}{}
+			}
+			r.Properties.AdditionalProperties["qodanaSeverity"] = severity
+		}
+	}
+	if changed == 0 {
+		return 0, nil
+	}
+	return changed, WriteReport(sarifPath, report)
+}
@@ -18,7 +18,9 @@ package platform
 
 import (
 	"fmt"
+	"github.com/JetBrains/qodana-cli/v2024/sarif"
 	"strconv"
+	"strings"
 )
 
 const severityAny = "any"
@@ -28,6 +30,11 @@ const severityModerate = "moderate"
 const severityLow = "low"
 const severityInfo = "info"
 
+// FailureThresholds returns the failure thresholds of the run, computed from qodana.yaml and the command line options.
+func (o *QodanaOptions) FailureThresholds() map[string]string {
+	return getFailureThresholds(&o.QdConfig, o)
+}
+
 func getFailureThresholds(yaml *QodanaYaml, options *QodanaOptions) map[string]string {
 	ret := make(map[string]string)
 	if yaml.FailThreshold != nil {
@@ -58,9 +65,207 @@ func getFailureThresholds(yaml *QodanaYaml, options *QodanaOptions) map[string]s
 		ret = make(map[string]string)
 		ret[severityAny] = options.FailThreshold
 	}
+	for _, severity := range options.FailOnSeverity { // any new problem of the given severity fails the run
+		ret[Lower(severity)] = "0"
+	}
 	return ret
 }
 
+// ValidateFailOnSeverity checks that all severities passed via --fail-on-severity are known.
+func ValidateFailOnSeverity(severities []string) error {
+	for _, severity := range severities {
+		if err := validateSeverity(severity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateAbsentMinSeverity checks the severity passed via --absent-min-severity, empty value is allowed.
+func ValidateAbsentMinSeverity(severity string) error {
+	if severity == "" {
+		return nil
+	}
+	return validateSeverity(severity)
+}
+
+func validateSeverity(severity string) error {
+	if !Contains([]string{severityCritical, severityHigh, severityModerate, severityLow, severityInfo}, Lower(severity)) {
+		return fmt.Errorf("unknown severity %q, expected one of: critical, high, moderate, low, info", severity)
+	}
+	return nil
+}
+
+// HasNewProblemsOfSeverity reports whether the SARIF report contains at least one new problem (not present in the baseline)
+// with one of the given severities.
+func HasNewProblemsOfSeverity(sarifPath string, severities []string) (bool, error) {
+	s, err := ReadReport(sarifPath)
+	if err != nil {
+		return false, err
+	}
+	for _, run := range s.Runs {
+		for _, r := range run.Results {
+			if !isNewResult(&r) {
+				continue
+			}
+			severity := Lower(getSeverity(&r))
+			for _, failSeverity := range severities {
+				if Lower(failSeverity) == severity {
+					return true, nil
+				}
+			}
+		}
+	}
+	return false, nil
+}
+
+// LicenseRuleIds are the ids of the license audit rules, their results are the dependency license violations
+// (prohibited or not allowed licenses, see licenseRules in qodana.yaml) checked with --fail-on-license-violation.
+var LicenseRuleIds = []string{"CheckDependencyLicenses", "CheckModuleLicenses"}
+
+// CountNewLicenseViolations returns the number of new (not present in the baseline) license violations in the SARIF report,
+// the results with the same fingerprint are counted once.
+func CountNewLicenseViolations(sarifPath string) (int, error) {
+	s, err := ReadReport(sarifPath)
+	if err != nil {
+		return 0, err
+	}
+	count := 0
+	fingerprints := make(map[string]bool)
+	for _, run := range s.Runs {
+		for _, r := range run.Results {
+			if r.RuleId == "" || !Contains(LicenseRuleIds, r.RuleId) {
+				continue
+			}
+			if !isNewResult(&r) {
+				continue
+			}
+			if fingerprint := findFingerprint(&r); fingerprint != "" {
+				if fingerprints[fingerprint] {
+					continue
+				}
+				fingerprints[fingerprint] = true
+			}
+			count++
+		}
+	}
+	return count, nil
+}
+
+// ParseSeverityExitCodes parses the --severity-exit-codes values in the severity=code format (e.g. critical=10)
+// into a map from the lowercase severity to the exit code.
+func ParseSeverityExitCodes(values []string) (map[string]int, error) {
+	codes := make(map[string]int)
+	for _, value := range values {
+		severity, code, found := strings.Cut(value, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid severity exit code %q, expected the severity=code format, e.g. critical=10", value)
+		}
+		severity = Lower(strings.TrimSpace(severity))
+		if err := validateSeverity(severity); err != nil {
+			return nil, fmt.Errorf("invalid severity exit code %q: %w", value, err)
+		}
+		exitCode, err := parseExitCode(code)
+		if err != nil {
+			return nil, fmt.Errorf("invalid severity exit code %q: %w", value, err)
+		}
+		if existing, ok := codes[severity]; ok && existing != exitCode {
+			return nil, fmt.Errorf("severity %s is mapped to both %d and %d", severity, existing, exitCode)
+		}
+		codes[severity] = exitCode
+	}
+	return codes, nil
+}
+
+// SeverityExitCode returns the exit code configured in codes (see ParseSeverityExitCodes) for the most severe
+// new problem in the SARIF report. SARIF levels are matched with the severities of the same rank.
+// The returned severity is empty if there are no new problems with a configured severity.
+func SeverityExitCode(sarifPath string, codes map[string]int) (string, int, error) {
+	if len(codes) == 0 {
+		return "", 0, nil
+	}
+	s, err := ReadReport(sarifPath)
+	if err != nil {
+		return "", 0, err
+	}
+	present := make(map[int]bool)
+	for _, run := range s.Runs {
+		for _, r := range run.Results {
+			if !isNewResult(&r) {
+				continue
+			}
+			if rank, ok := severityRanks[Lower(getSeverity(&r))]; ok {
+				present[rank] = true
+			}
+		}
+	}
+	severity, code := "", 0
+	for configured, configuredCode := range codes {
+		rank := severityRanks[configured]
+		if present[rank] && (severity == "" || rank > severityRanks[severity]) {
+			severity, code = configured, configuredCode
+		}
+	}
+	return severity, code, nil
+}
+
+// ExceedsFailureThresholds reports whether the number of new problems in the SARIF report
+// exceeds any of the thresholds (severity -> maximum number of new problems, see getFailureThresholds).
+func ExceedsFailureThresholds(sarifPath string, thresholds map[string]string) (bool, error) {
+	s, err := ReadReport(sarifPath)
+	if err != nil {
+		return false, err
+	}
+	counts := newProblemCounts{}
+	for _, run := range s.Runs {
+		for _, r := range run.Results {
+			if isNewResult(&r) {
+				counts.add(&r)
+			}
+		}
+	}
+	return counts.exceeds(thresholds)
+}
+
+// RecheckFailureThresholds recomputes the exit code of the run after its report at sarifPath was changed:
+// the run fails with QodanaFailThresholdExitCode if the new problems exceed the thresholds and succeeds otherwise.
+// The exit codes of the failed runs are kept.
+func RecheckFailureThresholds(sarifPath string, thresholds map[string]string, exitCode int) (int, error) {
+	if exitCode != QodanaSuccessExitCode && exitCode != QodanaFailThresholdExitCode {
+		return exitCode, nil
+	}
+	exceeded, err := ExceedsFailureThresholds(sarifPath, thresholds)
+	if err != nil {
+		return exitCode, err
+	}
+	if exceeded {
+		return QodanaFailThresholdExitCode, nil
+	}
+	return QodanaSuccessExitCode, nil
+}
+
+// newProblemCounts holds the number of new problems by severity (lowercase) and the total number as severityAny.
+type newProblemCounts map[string]int
+
+func (c newProblemCounts) add(r *sarif.Result) {
+	c[severityAny]++
+	c[Lower(getSeverity(r))]++
+}
+
+// exceeds reports whether the counts exceed any of the thresholds (severity -> maximum number of new problems).
+func (c newProblemCounts) exceeds(thresholds map[string]string) (bool, error) {
+	for severity, value := range thresholds {
+		threshold, err := strconv.Atoi(value)
+		if err != nil {
+			return false, fmt.Errorf("invalid %s threshold %q: %w", severity, value, err)
+		}
+		if c[severity] > threshold {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 func thresholdsToArgs(thresholds map[string]string) []string {
 	args := make([]string, 0)
 	for severity, value := range thresholds {
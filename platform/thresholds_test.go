@@ -25,10 +25,11 @@ import (
 
 func TestFailureThresholds(t *testing.T) {
 	for _, testData := range []struct {
-		name     string
-		yaml     string
-		option   string
-		expected string
+		name       string
+		yaml       string
+		option     string
+		severities []string
+		expected   string
 	}{
 		{
 			name:     "empty",
@@ -99,6 +100,24 @@ failThreshold: 123
 			option:   "123",
 			expected: " --threshold-any=123",
 		},
+		{
+			name: "fail on severity overrides yaml severity thresholds",
+			yaml: `failureConditions:
+  severityThresholds:
+    critical: 2
+    high: 3
+    moderate: 4
+`,
+			severities: []string{"Critical", "high"},
+			expected:   " --threshold-critical=0 --threshold-high=0 --threshold-moderate=4",
+		},
+		{
+			name:       "fail on severity combined with cli fail threshold",
+			yaml:       "",
+			option:     "10",
+			severities: []string{"critical"},
+			expected:   " --threshold-any=10 --threshold-critical=0",
+		},
 	} {
 		t.Run(testData.name, func(t *testing.T) {
 			tempDir := t.TempDir()
@@ -109,7 +128,7 @@ failThreshold: 123
 				}
 			}
 			yaml := LoadQodanaYaml(tempDir, "qodana.yaml")
-			thresholds := getFailureThresholds(yaml, &QodanaOptions{FailThreshold: testData.option})
+			thresholds := getFailureThresholds(yaml, &QodanaOptions{FailThreshold: testData.option, FailOnSeverity: testData.severities})
 			thresholdArgs := thresholdsToArgs(thresholds)
 			sort.Strings(thresholdArgs)
 			argString := ""
@@ -123,3 +142,139 @@ failThreshold: 123
 		})
 	}
 }
+
+func TestHasNewProblemsOfSeverity(t *testing.T) {
+	sarifPath := filepath.Join(t.TempDir(), QodanaSarifName)
+	report := `{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "QDJVM"}}, "results": [
+{"ruleId": "A", "message": {"text": "a"}, "baselineState": "unchanged", "properties": {"qodanaSeverity": "Critical"}},
+{"ruleId": "B", "message": {"text": "b"}, "baselineState": "new", "properties": {"qodanaSeverity": "High"}},
+{"ruleId": "C", "message": {"text": "c"}, "properties": {"qodanaSeverity": "Low"}}
+]}]}`
+	if err := os.WriteFile(sarifPath, []byte(report), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, testData := range []struct {
+		severities []string
+		expected   bool
+	}{
+		{[]string{"critical"}, false},
+		{[]string{"critical", "high"}, true},
+		{[]string{"low"}, true},
+		{[]string{"moderate", "info"}, false},
+	} {
+		actual, err := HasNewProblemsOfSeverity(sarifPath, testData.severities)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != testData.expected {
+			t.Errorf("severities %v: expected %v, got %v", testData.severities, testData.expected, actual)
+		}
+	}
+
+	if err := ValidateFailOnSeverity([]string{"High", "blocker"}); err == nil {
+		t.Error("expected an error for an unknown severity")
+	}
+}
+
+func TestRecheckFailureThresholds(t *testing.T) {
+	sarifPath := filepath.Join(t.TempDir(), QodanaSarifName)
+	report := `{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "QDJVM"}}, "results": [
+{"ruleId": "A", "message": {"text": "a"}, "baselineState": "unchanged", "properties": {"qodanaSeverity": "Critical"}},
+{"ruleId": "B", "message": {"text": "b"}, "baselineState": "new", "properties": {"qodanaSeverity": "High"}}
+]}]}`
+	if err := os.WriteFile(sarifPath, []byte(report), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, testData := range []struct {
+		thresholds map[string]string
+		exitCode   int
+		expected   int
+	}{
+		{map[string]string{severityHigh: "0"}, QodanaSuccessExitCode, QodanaFailThresholdExitCode},
+		{map[string]string{severityCritical: "0"}, QodanaFailThresholdExitCode, QodanaSuccessExitCode},
+		{map[string]string{severityAny: "1"}, QodanaFailThresholdExitCode, QodanaSuccessExitCode},
+		{map[string]string{severityHigh: "0"}, 1, 1},
+	} {
+		actual, err := RecheckFailureThresholds(sarifPath, testData.thresholds, testData.exitCode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual != testData.expected {
+			t.Errorf("thresholds %v, exit code %d: expected %d, got %d", testData.thresholds, testData.exitCode, testData.expected, actual)
+		}
+	}
+}
+
+func TestSeverityExitCode(t *testing.T) {
+	sarifPath := filepath.Join(t.TempDir(), QodanaSarifName)
+	report := `{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "QDJVM"}}, "results": [
+{"ruleId": "A", "message": {"text": "a"}, "baselineState": "unchanged", "properties": {"qodanaSeverity": "Critical"}},
+{"ruleId": "B", "message": {"text": "b"}, "baselineState": "new", "properties": {"qodanaSeverity": "High"}},
+{"ruleId": "C", "message": {"text": "c"}, "properties": {"qodanaSeverity": "Low"}}
+]}]}`
+	if err := os.WriteFile(sarifPath, []byte(report), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, testData := range []struct {
+		codes            []string
+		expectedSeverity string
+		expectedCode     int
+	}{
+		{nil, "", 0},
+		{[]string{"critical=10"}, "", 0},
+		{[]string{"Critical=10", "High=11"}, "high", 11},
+		{[]string{"high=11", "low=13"}, "high", 11},
+		{[]string{"low=13", "moderate=12"}, "low", 13},
+		{[]string{"info=14"}, "", 0},
+	} {
+		codes, err := ParseSeverityExitCodes(testData.codes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		severity, code, err := SeverityExitCode(sarifPath, codes)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if severity != testData.expectedSeverity || code != testData.expectedCode {
+			t.Errorf("codes %v: expected %q=%d, got %q=%d", testData.codes, testData.expectedSeverity, testData.expectedCode, severity, code)
+		}
+	}
+}
+
+func TestParseSeverityExitCodesErrors(t *testing.T) {
+	for _, codes := range [][]string{
+		{"critical"},
+		{"blocker=10"},
+		{"critical=256"},
+		{"critical=10", "Critical=11"},
+	} {
+		if _, err := ParseSeverityExitCodes(codes); err == nil {
+			t.Errorf("codes %v: expected an error", codes)
+		}
+	}
+}
+
+func TestCountNewLicenseViolations(t *testing.T) {
+	sarifPath := filepath.Join(t.TempDir(), QodanaSarifName)
+	report := `{"version": "2.1.0", "runs": [{"tool": {"driver": {"name": "QDJVM"}}, "results": [
+{"ruleId": "CheckDependencyLicenses", "message": {"text": "GPL-3.0 is prohibited"}, "baselineState": "unchanged", "partialFingerprints": {"equalIndicator/v1": "a"}},
+{"ruleId": "CheckDependencyLicenses", "message": {"text": "AGPL-3.0 is prohibited"}, "baselineState": "new", "partialFingerprints": {"equalIndicator/v1": "b"}},
+{"ruleId": "CheckDependencyLicenses", "message": {"text": "AGPL-3.0 is prohibited"}, "baselineState": "new", "partialFingerprints": {"equalIndicator/v1": "b"}},
+{"ruleId": "CheckModuleLicenses", "message": {"text": "Module license is not allowed"}},
+{"ruleId": "VulnerableLibrariesLocal", "message": {"text": "Vulnerable library"}, "baselineState": "new"}
+]}]}`
+	if err := os.WriteFile(sarifPath, []byte(report), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	count, err := CountNewLicenseViolations(sarifPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Errorf("expected 2 new license violations, got %d", count)
+	}
+}
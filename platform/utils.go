@@ -83,6 +83,19 @@ func CheckDirFiles(dir string) bool {
 	return len(files) > 0
 }
 
+// IsHomeDirectory returns true if the given path is the user's home directory.
+func IsHomeDirectory(path string) bool {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return false
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return false
+	}
+	return absPath == home
+}
+
 // FindFiles returns a slice of files with the given extensions from the given root (recursive).
 func FindFiles(root string, extensions []string) []string {
 	var files []string
@@ -181,16 +194,31 @@ func getJavaExecutablePath() (string, error) {
 	javaHome := split[1]
 	javaHome = strings.Trim(javaHome, "\r\n ")
 
-	var javaExecFileName string
+	javaExecutablePath := filepath.Join(javaHome, "bin", javaExecFileName())
+	return javaExecutablePath, nil
+}
+
+func javaExecFileName() string {
 	//goland:noinspection GoBoolExpressions
 	if runtime.GOOS == "windows" {
-		javaExecFileName = "java.exe"
-	} else {
-		javaExecFileName = "java"
+		return "java.exe"
 	}
+	return "java"
+}
 
-	javaExecutablePath := filepath.Join(javaHome, "bin", javaExecFileName)
-	return javaExecutablePath, nil
+// JavaExecutable returns the java executable of the given Java home (--java-home),
+// checking that it exists and can be executed.
+func JavaExecutable(javaHome string) (string, error) {
+	java := filepath.Join(javaHome, "bin", javaExecFileName())
+	info, err := os.Stat(java)
+	if err != nil {
+		return "", fmt.Errorf("java executable is not found in %s: %w", javaHome, err)
+	}
+	//goland:noinspection GoBoolExpressions
+	if info.IsDir() || (runtime.GOOS != "windows" && info.Mode().Perm()&0o111 == 0) {
+		return "", fmt.Errorf("%s is not an executable file", java)
+	}
+	return java, nil
 }
 
 // LaunchAndLog launches a process and logs its output.
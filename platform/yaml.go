@@ -28,16 +28,27 @@ import (
 	"strings"
 )
 
-// GetQodanaYamlPath returns the path to qodana.yaml or qodana.yml
+// GetQodanaYamlPath returns the path to the first existing of the QODANA_CONFIG_NAME files, qodana.yaml or qodana.yml
 func GetQodanaYamlPath(project string) (string, error) {
-	qodanaYamlPath := filepath.Join(project, "qodana.yaml")
-	if _, err := os.Stat(qodanaYamlPath); errors.Is(err, os.ErrNotExist) {
-		qodanaYamlPath = filepath.Join(project, "qodana.yml")
+	for _, name := range append(configNamesFromEnv(), "qodana.yaml", "qodana.yml") {
+		qodanaYamlPath := filepath.Join(project, name)
+		if _, err := os.Stat(qodanaYamlPath); err == nil {
+			return qodanaYamlPath, nil
+		}
 	}
-	if _, err := os.Stat(qodanaYamlPath); errors.Is(err, os.ErrNotExist) {
-		return "", errors.New("qodana.yaml or qodana.yml not found")
+	return "", errors.New("qodana.yaml or qodana.yml not found")
+}
+
+// configNamesFromEnv returns the configuration file names from the comma-separated QODANA_CONFIG_NAME list,
+// they are looked up in the given order before qodana.yml and qodana.yaml. The --config flag takes precedence over them.
+func configNamesFromEnv() []string {
+	var names []string
+	for _, name := range strings.Split(os.Getenv(QodanaConfigNameEnv), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
 	}
-	return qodanaYamlPath, nil
+	return names
 }
 
 // GetQodanaYaml returns a parsed qodana.yaml or qodana.yml or error if not found/invalid
@@ -51,7 +62,7 @@ func GetQodanaYaml(project string) (QodanaYaml, error) {
 	if err != nil {
 		return *q, err
 	}
-	err = yaml.Unmarshal(yamlFile, q)
+	err = unmarshalQodanaYaml(qodanaYamlPath, yamlFile, q)
 	if err != nil {
 		return *q, fmt.Errorf("not a valid qodana.yaml: %w", err)
 	}
@@ -73,9 +84,16 @@ type QodanaYaml struct {
 	// The qodana.yaml version of this log file.
 	Version string `yaml:"version,omitempty"`
 
+	// Extends is the path to the base configuration (relative to this file) this configuration is merged over.
+	// For container runs, the base configuration has to be inside the project directory.
+	Extends string `yaml:"extends,omitempty"`
+
 	// Profile is the profile configuration for Qodana analysis (either a profile name or a profile path).
 	Profile Profile `yaml:"profile,omitempty"`
 
+	// InlineProfile is the inspection profile XML content to use instead of a separate profile file.
+	InlineProfile string `yaml:"inlineProfile,omitempty"`
+
 	// FailThreshold is a number of problems to fail the analysis (to exit from Qodana with code 255).
 	FailThreshold *int `yaml:"failThreshold,omitempty"`
 
@@ -85,6 +103,9 @@ type QodanaYaml struct {
 	// Clude property to disable the wanted checks on the wanted paths.
 	Excludes []Clude `yaml:"exclude,omitempty"`
 
+	// GeneratedFiles contains additional glob patterns of generated files to drop from the results with --exclude-generated.
+	GeneratedFiles []string `yaml:"generatedFiles,omitempty"`
+
 	// Include property to enable the wanted checks.
 	Includes []Clude `yaml:"include,omitempty"`
 
@@ -100,6 +121,9 @@ type QodanaYaml struct {
 	// Properties property to override IDE properties.
 	Properties map[string]string `yaml:"properties,omitempty"`
 
+	// PropertiesFile property to load IDE properties from a Java .properties file, the properties set inline win.
+	PropertiesFile string `yaml:"propertiesFile,omitempty"`
+
 	// LicenseRules contains a list of license rules to apply for license checks.
 	LicenseRules []LicenseRule `yaml:"licenseRules,omitempty"`
 
@@ -162,6 +186,12 @@ type QodanaYaml struct {
 
 	// RaiseLicenseProblems property to show license problems like other inspections.
 	RaiseLicenseProblems bool `yaml:"raiseLicenseProblems,omitempty"`
+
+	// SeverityOverrides property to remap the severity of the reported problems, rule id -> Critical, High, Moderate, Low or Info.
+	SeverityOverrides map[string]string `yaml:"severityOverrides,omitempty"`
+
+	// RuleIgnores property to drop the results of the given rule ids from the reports after the analysis.
+	RuleIgnores []string `yaml:"ruleIgnores,omitempty"`
 }
 
 // WriteConfig writes QodanaYaml to the given path.
@@ -202,6 +232,30 @@ type Clude struct {
 	Paths []string `yaml:"paths,omitempty"`
 }
 
+// ParseCludes parses the --include/--exclude values in the name:path format, the path can be omitted
+// to include/exclude the check for the whole project. Values with the same name are combined in the given order.
+func ParseCludes(values []string) ([]Clude, error) {
+	var cludes []Clude
+	indexes := make(map[string]int)
+	for _, value := range values {
+		name, path, _ := strings.Cut(value, ":")
+		name = strings.TrimSpace(name)
+		if name == "" {
+			return nil, fmt.Errorf("invalid check %q, expected the name:path format, e.g. All:src/generated", value)
+		}
+		index, ok := indexes[name]
+		if !ok {
+			index = len(cludes)
+			indexes[name] = index
+			cludes = append(cludes, Clude{Name: name})
+		}
+		if path != "" {
+			cludes[index].Paths = append(cludes[index].Paths, path)
+		}
+	}
+	return cludes, nil
+}
+
 // Plugin to be installed during the Qodana run.
 //
 //goland:noinspection GoUnnecessarilyExportedIdentifiers
@@ -358,23 +412,37 @@ type Php struct {
 	Version string `yaml:"version,omitempty"`
 }
 
-// FindQodanaYaml checks whether qodana.yaml exists or not
+// FindQodanaYaml returns the name of the configuration file of the project: the first existing of the QODANA_CONFIG_NAME files,
+// qodana.yml, or qodana.yaml. If none of them exists, the first QODANA_CONFIG_NAME file or qodana.yaml is returned to be created.
 func FindQodanaYaml(project string) string {
-	filename := configName + ".yml"
-	if info, _ := os.Stat(filepath.Join(project, filename)); info != nil {
-		return filename
-	} else {
-		return configName + ".yaml"
+	envNames := configNamesFromEnv()
+	for _, filename := range append(envNames, configName+".yml", configName+".yaml") {
+		if info, _ := os.Stat(filepath.Join(project, filename)); info != nil {
+			return filename
+		}
+	}
+	if len(envNames) > 0 {
+		return envNames[0]
 	}
+	return configName + ".yaml"
 }
 
-// LoadQodanaYaml gets Qodana YAML from the project.
+// LoadQodanaYaml gets Qodana YAML from the project, the configuration it extends is merged in.
 func LoadQodanaYaml(project string, filename string) *QodanaYaml {
+	return loadQodanaYaml(project, filename, true)
+}
+
+// loadQodanaYaml gets Qodana YAML from the project. Without resolveExtends, the extends key is kept as is,
+// so the configuration can be written back without inlining the base one.
+func loadQodanaYaml(project string, filename string, resolveExtends bool) *QodanaYaml {
 	q := &QodanaYaml{}
 	if filename == "" {
 		filename = FindQodanaYaml(project)
 	}
 	qodanaYamlPath := filepath.Join(project, filename)
+	if filepath.IsAbs(filename) {
+		qodanaYamlPath = filename
+	}
 	if _, err := os.Stat(qodanaYamlPath); errors.Is(err, os.ErrNotExist) {
 		return q
 	}
@@ -382,13 +450,340 @@ func LoadQodanaYaml(project string, filename string) *QodanaYaml {
 	if err != nil {
 		log.Printf("yamlFile.Get err   #%v ", err)
 	}
-	err = yaml.Unmarshal(yamlFile, q)
+	if resolveExtends {
+		err = unmarshalQodanaYaml(qodanaYamlPath, yamlFile, q)
+	} else {
+		err = yaml.Unmarshal(yamlFile, q)
+	}
 	if err != nil {
 		log.Fatalf("Unmarshal: %v", err)
 	}
 	return q
 }
 
+// LoadPropertiesFile merges the properties from propertiesFile (relative to the project directory) into Properties,
+// the properties set inline in qodana.yaml win.
+func (q *QodanaYaml) LoadPropertiesFile(project string) error {
+	if q.PropertiesFile == "" {
+		return nil
+	}
+	path := q.PropertiesFile
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(project, path)
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read propertiesFile: %w", err)
+	}
+	properties := parseJavaProperties(string(content))
+	for key, value := range q.Properties {
+		properties[key] = value
+	}
+	q.Properties = properties
+	return nil
+}
+
+// ResolveProfilePath makes the relative profile.path relative to the directory of qodanaYamlPath (itself relative
+// to the project directory) instead of the project directory, e.g. for --config pointing outside the project.
+// Absolute paths are kept. It reports whether the path has changed.
+func (q *QodanaYaml) ResolveProfilePath(project string, qodanaYamlPath string) bool {
+	if q.Profile.Path == "" || filepath.IsAbs(q.Profile.Path) {
+		return false
+	}
+	if !filepath.IsAbs(qodanaYamlPath) {
+		qodanaYamlPath = filepath.Join(project, qodanaYamlPath)
+	}
+	configDir := filepath.Dir(qodanaYamlPath)
+	if filepath.Clean(configDir) == filepath.Clean(project) {
+		return false
+	}
+	q.Profile.Path = filepath.Join(configDir, q.Profile.Path)
+	return true
+}
+
+// parseJavaProperties parses the key=value (or key: value) lines of a Java .properties file.
+// Lines starting with # or ! are comments, a line ending with a backslash continues on the next one.
+func parseJavaProperties(content string) map[string]string {
+	properties := make(map[string]string)
+	var logical strings.Builder
+	for _, line := range strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n") {
+		line = strings.TrimLeft(line, " \t\f")
+		if logical.Len() == 0 && (line == "" || line[0] == '#' || line[0] == '!') {
+			continue
+		}
+		trailing := len(line) - len(strings.TrimRight(line, "\\"))
+		if trailing%2 == 1 {
+			logical.WriteString(line[:len(line)-1])
+			continue
+		}
+		logical.WriteString(line)
+		key, value := splitJavaProperty(logical.String())
+		logical.Reset()
+		if key != "" {
+			properties[key] = value
+		}
+	}
+	if logical.Len() > 0 {
+		if key, value := splitJavaProperty(logical.String()); key != "" {
+			properties[key] = value
+		}
+	}
+	return properties
+}
+
+// splitJavaProperty splits the property line at the first unescaped =, : or whitespace.
+func splitJavaProperty(line string) (string, string) {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '=', ':', ' ', '\t', '\f':
+			value := strings.TrimLeft(line[i+1:], " \t\f")
+			// "key = value": the whitespace before the separator is not the separator itself
+			if line[i] != '=' && line[i] != ':' && value != "" && (value[0] == '=' || value[0] == ':') {
+				value = strings.TrimLeft(value[1:], " \t\f")
+			}
+			return unescapeJavaProperty(line[:i]), unescapeJavaProperty(value)
+		}
+	}
+	return unescapeJavaProperty(line), ""
+}
+
+// unescapeJavaProperty replaces the escape sequences of the .properties format (\t, \n, \\, \= etc.).
+func unescapeJavaProperty(value string) string {
+	if !strings.Contains(value, "\\") {
+		return value
+	}
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '\\' || i == len(value)-1 {
+			b.WriteByte(value[i])
+			continue
+		}
+		i++
+		switch value[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		default:
+			b.WriteByte(value[i])
+		}
+	}
+	return b.String()
+}
+
+// ExpandEnv expands ${VAR}, ${VAR:-default} and $VAR references to the environment variables
+// in bootstrap, properties values and dotnet fields. A missing ${VAR} without a default value is an error,
+// a bare $VAR that is not set is kept as is, so shell variables like $i or $? and IDE macros like $PROJECT_DIR$ still work.
+// It's applied only to the configuration used for the run, so WriteConfig still writes the references.
+func (q *QodanaYaml) ExpandEnv() error {
+	var err error
+	expand := func(value *string) {
+		if err != nil {
+			return
+		}
+		*value, err = expandEnv(*value)
+	}
+	expand(&q.Bootstrap)
+	if q.Properties != nil {
+		properties := make(map[string]string, len(q.Properties))
+		for key, value := range q.Properties {
+			expand(&value)
+			properties[key] = value
+		}
+		q.Properties = properties
+	}
+	expand(&q.DotNet.Solution)
+	expand(&q.DotNet.Project)
+	expand(&q.DotNet.Configuration)
+	expand(&q.DotNet.Platform)
+	expand(&q.DotNet.Frameworks)
+	return err
+}
+
+// expandEnv expands the environment variables in the value, see ExpandEnv.
+func expandEnv(value string) (string, error) {
+	var missing []string
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '$' || i+1 == len(value) {
+			b.WriteByte(value[i])
+			continue
+		}
+		switch next := value[i+1]; {
+		case next == '$': // $$ is an escaped dollar sign
+			b.WriteByte('$')
+			i++
+		case next == '{':
+			end := strings.IndexByte(value[i+2:], '}')
+			if end < 0 {
+				b.WriteString(value[i:])
+				i = len(value)
+				continue
+			}
+			name, defaultValue, hasDefault := strings.Cut(value[i+2:i+2+end], ":-")
+			if v, ok := os.LookupEnv(name); ok && (v != "" || !hasDefault) {
+				b.WriteString(v)
+			} else if hasDefault {
+				b.WriteString(defaultValue)
+			} else {
+				missing = append(missing, name)
+			}
+			i += end + 2
+		case next == '_' || 'a' <= next && next <= 'z' || 'A' <= next && next <= 'Z':
+			end := i + 2
+			for end < len(value) && isEnvNameChar(value[end]) {
+				end++
+			}
+			if v, ok := os.LookupEnv(value[i+1 : end]); ok {
+				b.WriteString(v)
+			} else {
+				b.WriteString(value[i:end])
+			}
+			i = end - 1
+		default:
+			b.WriteByte('$')
+		}
+	}
+	if len(missing) > 0 {
+		return value, fmt.Errorf("environment variable %s referenced in %q is not set", strings.Join(missing, ", "), value)
+	}
+	return b.String(), nil
+}
+
+func isEnvNameChar(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+// MergeQodanaYamlFiles deep-merges the overlay configuration onto the base one and returns the merged YAML:
+// mappings are merged recursively, scalars from the overlay replace the base ones,
+// and lists from the overlay are appended to the base lists. A missing base file is treated as empty.
+func MergeQodanaYamlFiles(basePath string, overlayPath string) ([]byte, error) {
+	base, err := readYamlMap(basePath, true)
+	if err != nil {
+		return nil, err
+	}
+	overlay, err := readYamlMap(overlayPath, false)
+	if err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(mergeYamlMaps(base, overlay))
+}
+
+// readYamlMap reads the YAML file at path as a generic map, a missing file is read as an empty map if optional is set.
+// If the file extends another configuration, the file is merged over it, see readExtendedYamlMap.
+func readYamlMap(path string, optional bool) (map[string]interface{}, error) {
+	return readExtendedYamlMap(path, optional, nil)
+}
+
+// readExtendedYamlMap reads the YAML file at path and resolves its extends key: the base configuration (relative
+// to the directory of the file) is read the same way and the file is merged over it with mergeYamlMaps.
+// The chain holds the absolute paths of the files extending this one to detect cyclic extends.
+func readExtendedYamlMap(path string, optional bool, chain []string) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	content, err := os.ReadFile(path)
+	if err != nil && !(optional && errors.Is(err, os.ErrNotExist)) {
+		return nil, err
+	}
+	if err = yaml.Unmarshal(content, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	extends, ok := result["extends"]
+	if !ok {
+		return result, nil
+	}
+	delete(result, "extends")
+	basePath, ok := extends.(string)
+	if !ok || basePath == "" {
+		return nil, fmt.Errorf("invalid extends in %s: expected the path to the base configuration", path)
+	}
+	if !filepath.IsAbs(basePath) {
+		basePath = filepath.Join(filepath.Dir(path), basePath)
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	if basePath, err = filepath.Abs(basePath); err != nil {
+		return nil, err
+	}
+	chain = append(chain, absPath)
+	if Contains(chain, basePath) {
+		return nil, fmt.Errorf("cyclic extends: %s", strings.Join(append(chain, basePath), " -> "))
+	}
+	base, err := readExtendedYamlMap(basePath, false, chain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load %s extended by %s: %w", basePath, path, err)
+	}
+	return mergeYamlMaps(base, result), nil
+}
+
+// unmarshalQodanaYaml parses the content of the qodana.yaml file at path into q. If the configuration extends
+// another one, the file is merged over the base configuration, otherwise the content is parsed as is.
+func unmarshalQodanaYaml(path string, content []byte, q *QodanaYaml) error {
+	if err := yaml.Unmarshal(content, q); err != nil || q.Extends == "" {
+		return err
+	}
+	merged, err := readYamlMap(path, false)
+	if err != nil {
+		return err
+	}
+	if content, err = yaml.Marshal(merged); err != nil {
+		return err
+	}
+	*q = QodanaYaml{}
+	return yaml.Unmarshal(content, q)
+}
+
+// cliConfigOverlay is the part of qodana.yaml that can be set from the command line, nil values are not set.
+type cliConfigOverlay struct {
+	Includes                []Clude `yaml:"include,omitempty"`
+	Excludes                []Clude `yaml:"exclude,omitempty"`
+	MaxRuntimeNotifications *int    `yaml:"maxRuntimeNotifications,omitempty"`
+	FailOnErrorNotification *bool   `yaml:"failOnErrorNotification,omitempty"`
+}
+
+// yamlMap returns the overlay as a generic YAML map to be merged with mergeYamlMaps.
+func (c cliConfigOverlay) yamlMap() (map[string]interface{}, error) {
+	content, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]interface{})
+	if err = yaml.Unmarshal(content, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// mergeYamlMaps merges overlay onto base, see MergeQodanaYamlFiles.
+func mergeYamlMaps(base map[string]interface{}, overlay map[string]interface{}) map[string]interface{} {
+	if base == nil {
+		base = make(map[string]interface{})
+	}
+	for key, value := range overlay {
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if baseMap, ok := base[key].(map[string]interface{}); ok {
+				base[key] = mergeYamlMaps(baseMap, v)
+				continue
+			}
+		case []interface{}:
+			if baseList, ok := base[key].([]interface{}); ok {
+				base[key] = append(baseList, v...)
+				continue
+			}
+		}
+		base[key] = value
+	}
+	return base
+}
+
 // Sort makes QodanaYaml prettier.
 func (q *QodanaYaml) Sort() *QodanaYaml {
 	sort.Slice(q.Includes, func(i, j int) bool {
@@ -429,12 +824,12 @@ func (q *QodanaYaml) IsDotNet() bool {
 
 // SetQodanaLinter adds the linter to the qodana.yaml file.
 func SetQodanaLinter(path string, linter string, filename string) {
-	q := LoadQodanaYaml(path, filename)
+	q := loadQodanaYaml(path, filename, false)
 	if q.Version == "" {
 		q.Version = "1.0"
 	}
 	q.Sort()
-	if Contains(AllCodes, linter) {
+	if Contains(AllCodes, strings.TrimSuffix(linter, eapSuffix)) {
 		q.Ide = linter
 	} else {
 		q.Linter = linter
@@ -447,7 +842,7 @@ func SetQodanaLinter(path string, linter string, filename string) {
 
 // setQodanaDotNet adds the .NET configuration to the qodana.yaml file.
 func setQodanaDotNet(path string, dotNet *DotNet, filename string) bool {
-	q := LoadQodanaYaml(path, filename)
+	q := loadQodanaYaml(path, filename, false)
 	q.DotNet = *dotNet
 	err := q.WriteConfig(filepath.Join(path, filename))
 	if err != nil {
@@ -154,3 +154,477 @@ script:
 		})
 	}
 }
+
+func TestFindQodanaYamlWithConfigNameEnv(t *testing.T) {
+	projectDir := t.TempDir()
+	assert.Equal(t, "qodana.yaml", FindQodanaYaml(projectDir))
+
+	t.Setenv(QodanaConfigNameEnv, "qodana.ci.yaml, qodana.local.yaml")
+	assert.Equal(t, "qodana.ci.yaml", FindQodanaYaml(projectDir), "the first name is created by init")
+	_, err := GetQodanaYamlPath(projectDir)
+	assert.Error(t, err)
+
+	for _, name := range []string{"qodana.yaml", "qodana.local.yaml", "qodana.ci.yaml"} {
+		assert.NoError(t, os.WriteFile(filepath.Join(projectDir, name), []byte("version: \"1.0\"\n"), 0o600))
+		assert.Equal(t, name, FindQodanaYaml(projectDir))
+		path, err := GetQodanaYamlPath(projectDir)
+		assert.NoError(t, err)
+		assert.Equal(t, filepath.Join(projectDir, name), path)
+	}
+}
+
+func TestValidateQodanaYaml(t *testing.T) {
+	testCases := []struct {
+		description string
+		content     string
+		warnings    []string
+		errors      []string
+	}{
+		{
+			description: "valid config",
+			content: `version: "1.0"
+linter: jetbrains/qodana-jvm:latest
+failThreshold: 0
+exclude:
+  - name: All
+    paths:
+      - build
+`,
+		},
+		{
+			description: "unknown keys",
+			content: `version: "1.0"
+lintr: jetbrains/qodana-jvm:latest
+exclude:
+  - name: All
+    path:
+      - build
+`,
+			warnings: []string{`line 2: unknown key "lintr"`, `line 5: unknown key "exclude.path"`},
+		},
+		{
+			description: "type mismatch",
+			content: `version: "1.0"
+failThreshold: many
+`,
+			errors: []string{"line 2: cannot unmarshal !!str `many` into int"},
+		},
+		{
+			description: "inconsistent options",
+			content: `version: "1.0"
+linter: jetbrains/qodana-jvm:latest
+ide: QDJVM
+failThreshold: -1
+failureConditions:
+  severityThresholds:
+    critical: -2
+fixesStrategy: fix
+severityOverrides:
+  UnusedImport: info
+  ConstantValue: Blocker
+dotnet:
+  solution: App.sln
+`,
+			warnings: []string{"the dotnet section is ignored by non-.NET linters"},
+			errors: []string{
+				"both linter (jetbrains/qodana-jvm:latest) and ide (QDJVM) are set, only one of them can be used",
+				"failThreshold must not be negative, got -1",
+				"failureConditions.severityThresholds.critical must not be negative, got -2",
+				`unknown fixesStrategy "fix", expected one of: none, apply, cleanup`,
+				`unknown severity "Blocker" for rule ConstantValue in severityOverrides, expected one of: Critical, High, Moderate, Low, Info`,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.description, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "qodana.yaml")
+			if err := os.WriteFile(path, []byte(tc.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			issues, err := ValidateQodanaYaml(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.ElementsMatch(t, tc.warnings, issues.Warnings)
+			assert.ElementsMatch(t, tc.errors, issues.Errors)
+			assert.Equal(t, len(tc.errors) > 0, issues.HasErrors())
+		})
+	}
+}
+
+func TestQodanaYamlExpandEnv(t *testing.T) {
+	t.Setenv("QODANA_TEST_SETTINGS", "/root/.m2/settings.xml")
+	t.Setenv("QODANA_TEST_EMPTY", "")
+	content := `version: "1.0"
+bootstrap: mvn -s ${QODANA_TEST_SETTINGS} -Dprofile=${QODANA_TEST_PROFILE:-ci} -Dempty=${QODANA_TEST_EMPTY:-none} -Dcost=$$5
+properties:
+  settings: $QODANA_TEST_SETTINGS
+dotnet:
+  solution: ${QODANA_TEST_SOLUTION:-App.sln}
+`
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "qodana.yaml"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	q := LoadQodanaYaml(dir, "qodana.yaml")
+	if err := q.ExpandEnv(); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "mvn -s /root/.m2/settings.xml -Dprofile=ci -Dempty=none -Dcost=$5", q.Bootstrap)
+	assert.Equal(t, map[string]string{"settings": "/root/.m2/settings.xml"}, q.Properties)
+	assert.Equal(t, "App.sln", q.DotNet.Solution)
+
+	// the configuration written back keeps the references
+	raw := LoadQodanaYaml(dir, "qodana.yaml")
+	if err := raw.WriteConfig(filepath.Join(dir, "qodana.yaml")); err != nil {
+		t.Fatal(err)
+	}
+	assert.Contains(t, LoadQodanaYaml(dir, "qodana.yaml").Bootstrap, "${QODANA_TEST_SETTINGS}")
+
+	q = &QodanaYaml{Bootstrap: "mvn -s ${QODANA_TEST_MISSING}"}
+	assert.ErrorContains(t, q.ExpandEnv(), "QODANA_TEST_MISSING")
+}
+
+func TestQodanaYamlExpandEnvKeepsShellVariables(t *testing.T) {
+	t.Setenv("QODANA_TEST_SETTINGS", "/root/.m2/settings.xml")
+	for _, tc := range []struct {
+		value    string
+		expected string
+	}{
+		{value: "for i in 1 2; do echo $i; done", expected: "for i in 1 2; do echo $i; done"},
+		{value: "make; test $? -eq 0", expected: "make; test $? -eq 0"},
+		{value: "$PROJECT_DIR$/build", expected: "$PROJECT_DIR$/build"},
+		{value: "cp $QODANA_TEST_SETTINGS $HOME_UNSET_QODANA_TEST/", expected: "cp /root/.m2/settings.xml $HOME_UNSET_QODANA_TEST/"},
+		{value: "echo ${QODANA_TEST_SETTINGS}x $", expected: "echo /root/.m2/settings.xmlx $"},
+		{value: "echo ${unterminated", expected: "echo ${unterminated"},
+	} {
+		t.Run(tc.value, func(t *testing.T) {
+			q := &QodanaYaml{Bootstrap: tc.value, Properties: map[string]string{"key": tc.value}}
+			if err := q.ExpandEnv(); err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.expected, q.Bootstrap)
+			assert.Equal(t, tc.expected, q.Properties["key"])
+		})
+	}
+}
+
+func TestMergeQodanaYamlFiles(t *testing.T) {
+	dir := t.TempDir()
+	base := `version: "1.0"
+linter: jetbrains/qodana-jvm:latest
+failThreshold: 10
+plugins:
+  - id: org.intellij.scala
+failureConditions:
+  severityThresholds:
+    critical: 0
+    high: 5
+`
+	overlay := `failThreshold: 0
+plugins:
+  - id: com.example.custom
+failureConditions:
+  severityThresholds:
+    high: 1
+`
+	basePath := filepath.Join(dir, "qodana.yaml")
+	overlayPath := filepath.Join(dir, "qodana.ci.yaml")
+	if err := os.WriteFile(basePath, []byte(base), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(overlayPath, []byte(overlay), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &QodanaOptions{ProjectDir: dir, ConfigOverride: overlayPath}
+	q, merged, err := opts.LoadQodanaYaml("qodana.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEmpty(t, merged)
+	assert.Equal(t, "jetbrains/qodana-jvm:latest", q.Linter)
+	assert.Equal(t, 0, *q.FailThreshold)
+	assert.Equal(t, []Plugin{{Id: "org.intellij.scala"}, {Id: "com.example.custom"}}, q.Plugins)
+	assert.Equal(t, 0, *q.FailureConditions.SeverityThresholds.Critical)
+	assert.Equal(t, 1, *q.FailureConditions.SeverityThresholds.High)
+
+	// the base file itself is not changed
+	assert.Equal(t, 10, *LoadQodanaYaml(dir, "qodana.yaml").FailThreshold)
+}
+
+func TestLoadQodanaYamlExtends(t *testing.T) {
+	root := t.TempDir()
+	project := filepath.Join(root, "services", "api")
+	if err := os.MkdirAll(project, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	base := `version: "1.0"
+linter: jetbrains/qodana-jvm:latest
+failThreshold: 10
+exclude:
+  - name: All
+    paths:
+      - build
+plugins:
+  - id: org.intellij.scala
+`
+	child := `extends: ../../qodana.base.yaml
+failThreshold: 0
+exclude:
+  - name: JavaDocReference
+plugins:
+  - id: com.example.custom
+`
+	if err := os.WriteFile(filepath.Join(root, "qodana.base.yaml"), []byte(base), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(project, "qodana.yaml"), []byte(child), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	q := LoadQodanaYaml(project, "qodana.yaml")
+	assert.Equal(t, "jetbrains/qodana-jvm:latest", q.Linter)
+	assert.Equal(t, 0, *q.FailThreshold)
+	assert.Equal(t, []Clude{{Name: "All", Paths: []string{"build"}}, {Name: "JavaDocReference"}}, q.Excludes)
+	assert.Equal(t, []Plugin{{Id: "org.intellij.scala"}, {Id: "com.example.custom"}}, q.Plugins)
+	assert.Empty(t, q.Extends)
+
+	byPath, err := LoadQodanaYamlByFullPath(filepath.Join(project, "qodana.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, q, byPath)
+
+	opts := &QodanaOptions{ProjectDir: project}
+	q, merged, err := opts.LoadQodanaYaml("qodana.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEmpty(t, merged, "the IDE needs the merged configuration")
+	assert.Equal(t, "jetbrains/qodana-jvm:latest", q.Linter)
+
+	// the extends key is kept when the configuration is written back
+	SetQodanaLinter(project, "jetbrains/qodana-jvm-community:latest", "qodana.yaml")
+	assert.Equal(t, "../../qodana.base.yaml", loadQodanaYaml(project, "qodana.yaml", false).Extends)
+}
+
+func TestLoadQodanaYamlCyclicExtends(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "qodana.yaml"), []byte("extends: qodana.base.yaml\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "qodana.base.yaml"), []byte("extends: ./qodana.yaml\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := LoadQodanaYamlByFullPath(filepath.Join(dir, "qodana.yaml"))
+	assert.ErrorContains(t, err, "cyclic extends")
+}
+
+func TestLoadQodanaYamlWithCludes(t *testing.T) {
+	dir := t.TempDir()
+	base := `version: "1.0"
+linter: jetbrains/qodana-jvm:latest
+exclude:
+  - name: All
+    paths:
+      - build
+`
+	overlay := `exclude:
+  - name: JavaDocReference
+`
+	overlayPath := filepath.Join(dir, "qodana.ci.yaml")
+	if err := os.WriteFile(filepath.Join(dir, "qodana.yaml"), []byte(base), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(overlayPath, []byte(overlay), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	opts := &QodanaOptions{
+		ProjectDir:     dir,
+		ConfigOverride: overlayPath,
+		Includes:       []string{"ConstantValue:src/main"},
+		Excludes:       []string{"All:src/generated", "UnusedDeclaration", "All:out"},
+	}
+	q, merged, err := opts.LoadQodanaYaml("qodana.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NotEmpty(t, merged)
+	assert.Equal(t, "jetbrains/qodana-jvm:latest", q.Linter)
+	assert.Equal(t, []Clude{{Name: "ConstantValue", Paths: []string{"src/main"}}}, q.Includes)
+	assert.Equal(t, []Clude{
+		{Name: "All", Paths: []string{"build"}},
+		{Name: "JavaDocReference"},
+		{Name: "All", Paths: []string{"src/generated", "out"}},
+		{Name: "UnusedDeclaration"},
+	}, q.Excludes)
+
+	opts = &QodanaOptions{ProjectDir: t.TempDir(), Excludes: []string{"All:build"}}
+	q, _, err = opts.LoadQodanaYaml("qodana.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []Clude{{Name: "All", Paths: []string{"build"}}}, q.Excludes)
+}
+
+func TestLoadQodanaYamlWithRuntimeNotifications(t *testing.T) {
+	dir := t.TempDir()
+	base := `version: "1.0"
+maxRuntimeNotifications: 10
+failOnErrorNotification: true
+`
+	if err := os.WriteFile(filepath.Join(dir, "qodana.yaml"), []byte(base), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tc := range []struct {
+		name                 string
+		maxNotifications     int
+		failOnError          string
+		expectedMax          int
+		expectedFailOnError  bool
+		expectedMergedConfig bool
+	}{
+		{"qodana.yaml values", 0, "", 10, true, false},
+		{"overridden max", 50, "", 50, true, true},
+		{"disabled fail on error", 0, "false", 10, false, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := &QodanaOptions{ProjectDir: dir, MaxRuntimeNotifications: tc.maxNotifications, FailOnErrorNotification: tc.failOnError}
+			q, merged, err := opts.LoadQodanaYaml("qodana.yaml")
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Equal(t, tc.expectedMax, q.MaxRuntimeNotifications)
+			assert.Equal(t, tc.expectedFailOnError, q.FailOnErrorNotification)
+			assert.Equal(t, tc.expectedMergedConfig, merged != nil)
+		})
+	}
+
+	opts := &QodanaOptions{ProjectDir: dir, FailOnErrorNotification: "sometimes"}
+	assert.Error(t, opts.ValidateFailOnErrorNotification())
+	_, _, err := opts.LoadQodanaYaml("qodana.yaml")
+	assert.Error(t, err)
+}
+
+func TestParseCludes(t *testing.T) {
+	cludes, err := ParseCludes([]string{"All", "JavaDocReference:src/main", "JavaDocReference:src/test"})
+	assert.NoError(t, err)
+	assert.Equal(t, []Clude{
+		{Name: "All"},
+		{Name: "JavaDocReference", Paths: []string{"src/main", "src/test"}},
+	}, cludes)
+
+	_, err = ParseCludes([]string{":src"})
+	assert.Error(t, err)
+}
+
+func TestEffectiveConfig(t *testing.T) {
+	projectDir := t.TempDir()
+	content := `version: "1.0"
+linter: jetbrains/qodana-jvm:latest
+profile:
+  name: qodana.recommended
+script:
+  name: php-migration
+  parameters:
+    fromLevel: "7.1"
+failThreshold: 10
+properties:
+  idea.some.property: yaml
+  idea.other.property: yaml
+`
+	if err := os.WriteFile(filepath.Join(projectDir, "qodana.yaml"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	options := &QodanaOptions{
+		ProjectDir:  projectDir,
+		ProfileName: "qodana.starter",
+		Script:      "default",
+		Property:    []string{"idea.some.property=cli"},
+	}
+
+	config, err := options.EffectiveConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, filepath.Join(projectDir, "qodana.yaml"), config.ConfigFile)
+	assert.Equal(t, "jetbrains/qodana-jvm:latest", config.Linter)
+	assert.Equal(t, "qodana.starter", config.ProfileName, "CLI profile overrides qodana.yaml")
+	assert.Equal(t, "php-migration", config.Script, "default CLI script doesn't override qodana.yaml")
+	assert.Equal(t, map[string]interface{}{"fromLevel": "7.1"}, config.ScriptParameters)
+	assert.Equal(t, map[string]string{"idea.some.property": "cli", "idea.other.property": "yaml"}, config.Properties)
+	assert.Equal(t, map[string]string{"any": "10"}, config.FailureThresholds)
+}
+
+func TestResolveProfilePath(t *testing.T) {
+	projectDir := t.TempDir()
+	configDir := t.TempDir()
+	absolute := filepath.Join(t.TempDir(), "profile.xml")
+	for _, tc := range []struct {
+		name        string
+		config      string
+		profilePath string
+		expected    string
+	}{
+		{"project dir", "qodana.yaml", "profiles/qodana.xml", "profiles/qodana.xml"},
+		{"project subdir", filepath.Join(".qodana", "qodana.yaml"), "qodana.xml", filepath.Join(projectDir, ".qodana", "qodana.xml")},
+		{"config dir", filepath.Join(configDir, "qodana.yaml"), "profiles/qodana.xml", filepath.Join(configDir, "profiles", "qodana.xml")},
+		{"absolute", filepath.Join(configDir, "qodana.yaml"), absolute, absolute},
+		{"no profile path", filepath.Join(configDir, "qodana.yaml"), "", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			q := &QodanaYaml{Profile: Profile{Path: tc.profilePath}}
+			changed := q.ResolveProfilePath(projectDir, tc.config)
+			assert.Equal(t, tc.expected, q.Profile.Path)
+			assert.Equal(t, tc.expected != tc.profilePath, changed)
+		})
+	}
+
+	content := "version: \"1.0\"\nprofile:\n  path: profiles/qodana.xml\n"
+	if err := os.WriteFile(filepath.Join(configDir, "qodana.yaml"), []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	options := &QodanaOptions{ProjectDir: projectDir, ConfigName: filepath.Join(configDir, "qodana.yaml")}
+	config, err := options.EffectiveConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, filepath.Join(configDir, "profiles", "qodana.xml"), config.ProfilePath)
+}
+
+func TestLoadPropertiesFile(t *testing.T) {
+	projectDir := t.TempDir()
+	content := `# comment
+! another comment
+idea.log.level = debug
+qodana.paths: a,\
+    b,\
+    c
+idea.separator=a\=b
+idea.windows.path=C:\\tools
+qodana.empty
+idea.inline=file
+`
+	if err := os.WriteFile(filepath.Join(projectDir, "qodana.properties"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	q := &QodanaYaml{PropertiesFile: "qodana.properties", Properties: map[string]string{"idea.inline": "yaml"}}
+	if err := q.LoadPropertiesFile(projectDir); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, map[string]string{
+		"idea.log.level":    "debug",
+		"qodana.paths":      "a,b,c",
+		"idea.separator":    "a=b",
+		"idea.windows.path": `C:\tools`,
+		"qodana.empty":      "",
+		"idea.inline":       "yaml",
+	}, q.Properties)
+
+	q = &QodanaYaml{PropertiesFile: "missing.properties"}
+	assert.Error(t, q.LoadPropertiesFile(projectDir))
+}
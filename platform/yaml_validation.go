@@ -0,0 +1,170 @@
+/*
+ * Copyright 2021-2024 JetBrains s.r.o.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ * https://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// QodanaYamlIssues holds the problems found in qodana.yaml by ValidateQodanaYaml.
+type QodanaYamlIssues struct {
+	Warnings []string
+	Errors   []string
+}
+
+// HasErrors checks if there is at least one error.
+func (i *QodanaYamlIssues) HasErrors() bool {
+	return len(i.Errors) > 0
+}
+
+// LoadQodanaYamlByFullPath reads and parses the qodana.yaml file at the given path merged over the configuration it extends.
+func LoadQodanaYamlByFullPath(path string) (*QodanaYaml, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	q := &QodanaYaml{}
+	if err = unmarshalQodanaYaml(path, content, q); err != nil {
+		return q, err
+	}
+	return q, nil
+}
+
+// ValidateQodanaYaml checks the qodana.yaml file at the given path: unknown keys are reported as warnings,
+// type mismatches and inconsistent options as errors. The returned error is set only if the file can't be read or parsed.
+func ValidateQodanaYaml(path string) (*QodanaYamlIssues, error) {
+	issues := &QodanaYamlIssues{}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var root yaml.Node
+	if err = yaml.Unmarshal(content, &root); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	checkUnknownKeys(&root, reflect.TypeOf(QodanaYaml{}), "", issues)
+
+	q, err := LoadQodanaYamlByFullPath(path)
+	var typeError *yaml.TypeError
+	if errors.As(err, &typeError) {
+		issues.Errors = append(issues.Errors, typeError.Errors...)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	q.checkConstraints(issues)
+	return issues, nil
+}
+
+// checkUnknownKeys walks the YAML node along with the type it is decoded to and reports the keys that have no matching field.
+func checkUnknownKeys(node *yaml.Node, t reflect.Type, prefix string, issues *QodanaYamlIssues) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			checkUnknownKeys(child, t, prefix, issues)
+		}
+	case yaml.MappingNode:
+		if t.Kind() != reflect.Struct {
+			return
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key := node.Content[i]
+			name := key.Value
+			if prefix != "" {
+				name = prefix + "." + key.Value
+			}
+			field, ok := fields[key.Value]
+			if !ok {
+				issues.Warnings = append(issues.Warnings, fmt.Sprintf("line %d: unknown key %q", key.Line, name))
+				continue
+			}
+			checkUnknownKeys(node.Content[i+1], field, name, issues)
+		}
+	case yaml.SequenceNode:
+		if t.Kind() != reflect.Slice {
+			return
+		}
+		for _, child := range node.Content {
+			checkUnknownKeys(child, t.Elem(), prefix, issues)
+		}
+	}
+}
+
+// yamlFields returns the types of the struct fields by their YAML keys.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if key == "-" {
+			continue
+		}
+		if key == "" {
+			key = strings.ToLower(field.Name)
+		}
+		fields[key] = field.Type
+	}
+	return fields
+}
+
+// checkConstraints checks the options that can't be validated separately.
+func (q *QodanaYaml) checkConstraints(issues *QodanaYamlIssues) {
+	if q.Linter != "" && q.Ide != "" {
+		issues.Errors = append(issues.Errors, fmt.Sprintf("both linter (%s) and ide (%s) are set, only one of them can be used", q.Linter, q.Ide))
+	}
+	if q.FailThreshold != nil && *q.FailThreshold < 0 {
+		issues.Errors = append(issues.Errors, fmt.Sprintf("failThreshold must not be negative, got %d", *q.FailThreshold))
+	}
+	if thresholds := q.FailureConditions.SeverityThresholds; thresholds != nil {
+		for _, threshold := range []struct {
+			name  string
+			value *int
+		}{
+			{severityAny, thresholds.Any},
+			{severityCritical, thresholds.Critical},
+			{severityHigh, thresholds.High},
+			{severityModerate, thresholds.Moderate},
+			{severityLow, thresholds.Low},
+			{severityInfo, thresholds.Info},
+		} {
+			if threshold.value != nil && *threshold.value < 0 {
+				issues.Errors = append(issues.Errors, fmt.Sprintf("failureConditions.severityThresholds.%s must not be negative, got %d", threshold.name, *threshold.value))
+			}
+		}
+	}
+	if q.FixesStrategy != "" && !Contains([]string{"none", "apply", "cleanup"}, Lower(q.FixesStrategy)) {
+		issues.Errors = append(issues.Errors, fmt.Sprintf("unknown fixesStrategy %q, expected one of: none, apply, cleanup", q.FixesStrategy))
+	}
+	if err := q.ValidateSeverityOverrides(); err != nil {
+		issues.Errors = append(issues.Errors, err.Error())
+	}
+	if !q.DotNet.IsEmpty() && (q.Linter != "" || q.Ide != "") && !q.IsDotNet() {
+		issues.Warnings = append(issues.Warnings, "the dotnet section is ignored by non-.NET linters")
+	}
+}